@@ -0,0 +1,325 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v9"
+
+	"github.com/aweris/gale/common/log"
+)
+
+// ByteSize is a size in bytes. It's parsed from the human-readable values with binary units, e.g. 512MB or 10GB.
+type ByteSize int64
+
+// parseByteSize parses the given human-readable size. Values without a unit are in bytes.
+func parseByteSize(value string) (interface{}, error) {
+	str := strings.ToUpper(strings.TrimSpace(value))
+
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{suffix: "TB", multiplier: 1 << 40},
+		{suffix: "GB", multiplier: 1 << 30},
+		{suffix: "MB", multiplier: 1 << 20},
+		{suffix: "KB", multiplier: 1 << 10},
+		{suffix: "B", multiplier: 1},
+	}
+
+	multiplier := int64(1)
+
+	for _, unit := range units {
+		if strings.HasSuffix(str, unit.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, unit.suffix))
+			multiplier = unit.multiplier
+
+			break
+		}
+	}
+
+	size, err := strconv.ParseInt(str, 10, 64)
+	if err != nil || size < 0 {
+		return nil, fmt.Errorf("invalid size %q", value)
+	}
+
+	return ByteSize(size * multiplier), nil
+}
+
+// envParsers are the custom parsers of the service configuration.
+var envParsers = map[reflect.Type]env.ParserFunc{
+	reflect.TypeOf(ByteSize(0)): parseByteSize,
+}
+
+// EvictionConfig is the configuration of the cache eviction. Zero values disable the related limit.
+type EvictionConfig struct {
+	// MaxSize is the total size limit of the cache entries. Least recently used entries are evicted when the limit is
+	// exceeded.
+	MaxSize ByteSize `env:"CACHE_MAX_SIZE" envDefault:"20GB"`
+
+	// RepositoryMaxSize is the default size limit of the cache entries of a single repository.
+	RepositoryMaxSize ByteSize `env:"CACHE_REPOSITORY_MAX_SIZE" envDefault:"10GB"`
+
+	// RepositoryMaxSizes overrides the default size limit of the given repositories, e.g. `aweris/gale:5GB`.
+	RepositoryMaxSizes map[string]ByteSize `env:"CACHE_REPOSITORY_MAX_SIZES"`
+
+	// MaxAge is the duration a cache entry is kept since it's last used. Same as GitHub, it's 7 days by default.
+	MaxAge time.Duration `env:"CACHE_MAX_AGE" envDefault:"168h"`
+
+	// ReservationTimeout is the duration an incomplete reservation is kept. Reservations are left incomplete when the
+	// upload fails, and they block the same key and version until they're removed.
+	ReservationTimeout time.Duration `env:"CACHE_RESERVATION_TIMEOUT" envDefault:"1h"`
+
+	// Interval is the interval of the background eviction.
+	Interval time.Duration `env:"CACHE_EVICTION_INTERVAL" envDefault:"10m"`
+}
+
+// repositoryMaxSize returns the size limit of the given repository.
+func (c EvictionConfig) repositoryMaxSize(repository string) ByteSize {
+	if size, ok := c.RepositoryMaxSizes[repository]; ok {
+		return size
+	}
+
+	return c.RepositoryMaxSize
+}
+
+// EvictionStats is the result of a single eviction run.
+type EvictionStats struct {
+	StaleReservations int   // StaleReservations is the number of evicted incomplete reservations.
+	Expired           int   // Expired is the number of evicted entries not used longer than the max age.
+	RepositoryLimit   int   // RepositoryLimit is the number of evicted entries to fit the repository size limits.
+	SizeLimit         int   // SizeLimit is the number of evicted entries to fit the total size limit.
+	Orphaned          int   // Orphaned is the number of removed archives without a cache entry.
+	FreedBytes        int64 // FreedBytes is the total size of the removed files.
+	RemainingEntries  int   // RemainingEntries is the number of the complete entries left in the cache.
+	RemainingBytes    int64 // RemainingBytes is the total size of the complete entries left in the cache.
+}
+
+// StartEviction runs the eviction once and then periodically in the background until the service is closed.
+func (s *LocalService) StartEviction() {
+	if s.eviction.Interval <= 0 {
+		log.Info("Cache eviction is disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.eviction.Interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.Evict(time.Now()); err != nil {
+				log.Errorf("Failed to evict cache entries", "error", err)
+			}
+
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Evict removes the stale reservations, the expired entries and the least recently used entries exceeding the size
+// limits, then removes the orphaned archives left in the cache directory.
+func (s *LocalService) Evict(now time.Time) (*EvictionStats, error) {
+	start := time.Now()
+	stats := &EvictionStats{}
+
+	entries, err := s.db.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		active = make([]*CacheEntry, 0, len(entries))
+		sizes  = make(map[uint64]int64, len(entries))
+	)
+
+	for _, entry := range entries {
+		sizes[entry.ID] = s.getCacheSize(entry.ID)
+
+		switch {
+		case !entry.Complete:
+			if s.eviction.ReservationTimeout <= 0 || now.Sub(time.Unix(entry.CreatedAt, 0)) <= s.eviction.ReservationTimeout {
+				continue
+			}
+
+			if err := s.evict(entry, sizes[entry.ID], "stale_reservation", stats); err != nil {
+				return nil, err
+			}
+
+			stats.StaleReservations++
+		case s.eviction.MaxAge > 0 && now.Sub(time.Unix(entry.LastUsedAt, 0)) > s.eviction.MaxAge:
+			if err := s.evict(entry, sizes[entry.ID], "expired", stats); err != nil {
+				return nil, err
+			}
+
+			stats.Expired++
+		default:
+			active = append(active, entry)
+		}
+	}
+
+	// least recently used entries are evicted first
+	sort.SliceStable(active, func(i, j int) bool { return active[i].LastUsedAt < active[j].LastUsedAt })
+
+	repositorySizes := make(map[string]int64)
+
+	for _, entry := range active {
+		repositorySizes[entry.Repository] += sizes[entry.ID]
+	}
+
+	var (
+		kept  = make([]*CacheEntry, 0, len(active))
+		total int64
+	)
+
+	for _, entry := range active {
+		limit := int64(s.eviction.repositoryMaxSize(entry.Repository))
+
+		if limit > 0 && repositorySizes[entry.Repository] > limit {
+			if err := s.evict(entry, sizes[entry.ID], "repository_limit", stats); err != nil {
+				return nil, err
+			}
+
+			repositorySizes[entry.Repository] -= sizes[entry.ID]
+			stats.RepositoryLimit++
+
+			continue
+		}
+
+		kept = append(kept, entry)
+		total += sizes[entry.ID]
+	}
+
+	for _, entry := range kept {
+		if s.eviction.MaxSize > 0 && total > int64(s.eviction.MaxSize) {
+			if err := s.evict(entry, sizes[entry.ID], "size_limit", stats); err != nil {
+				return nil, err
+			}
+
+			total -= sizes[entry.ID]
+			stats.SizeLimit++
+
+			continue
+		}
+
+		stats.RemainingEntries++
+	}
+
+	stats.RemainingBytes = total
+
+	if err := s.removeOrphans(stats); err != nil {
+		return nil, err
+	}
+
+	log.Infof(
+		"Cache eviction completed",
+		"stale_reservations", stats.StaleReservations,
+		"expired", stats.Expired,
+		"repository_limit", stats.RepositoryLimit,
+		"size_limit", stats.SizeLimit,
+		"orphaned", stats.Orphaned,
+		"freed_bytes", stats.FreedBytes,
+		"remaining_entries", stats.RemainingEntries,
+		"remaining_bytes", stats.RemainingBytes,
+		"duration", time.Since(start),
+	)
+
+	return stats, nil
+}
+
+// evict deletes the given cache entry and its files.
+func (s *LocalService) evict(entry *CacheEntry, size int64, reason string, stats *EvictionStats) error {
+	// delete the entry first, so the entry is not served without its files
+	if err := s.db.Delete(entry.ID); err != nil {
+		log.Errorf("Failed to delete cache entry", "error", err, "id", entry.ID)
+		return err
+	}
+
+	if err := os.RemoveAll(s.getCacheDir(int(entry.ID))); err != nil {
+		log.Errorf("Failed to remove cache entry files", "error", err, "id", entry.ID)
+		return err
+	}
+
+	stats.FreedBytes += size
+
+	log.Debugf("Evicted cache entry", "id", entry.ID, "key", entry.Key, "version", entry.Version, "repository", entry.Repository, "reason", reason, "size", size)
+
+	return nil
+}
+
+// removeOrphans removes the cache directories without a cache entry, e.g. left from a crash during the eviction.
+func (s *LocalService) removeOrphans(stats *EvictionStats) error {
+	// directories must be listed before the entries. A new reservation is saved before its directory is created, so
+	// any directory listed here without an entry is an orphan.
+	dirs, err := os.ReadDir(s.path)
+	if err != nil {
+		return err
+	}
+
+	entries, err := s.db.List()
+	if err != nil {
+		return err
+	}
+
+	ids := make(map[uint64]bool, len(entries))
+
+	for _, entry := range entries {
+		ids[entry.ID] = true
+	}
+
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+
+		// only numeric directories are cache directories
+		id, err := strconv.ParseUint(dir.Name(), 10, 64)
+		if err != nil || ids[id] {
+			continue
+		}
+
+		size := s.getCacheSize(id)
+
+		if err := os.RemoveAll(filepath.Join(s.path, dir.Name())); err != nil {
+			log.Errorf("Failed to remove orphaned cache files", "error", err, "id", id)
+			return err
+		}
+
+		stats.Orphaned++
+		stats.FreedBytes += size
+
+		log.Debugf("Removed orphaned cache files", "id", id, "size", size)
+	}
+
+	return nil
+}
+
+// getCacheSize returns the total size of the files of the cache entry with the given id.
+func (s *LocalService) getCacheSize(cacheID uint64) int64 {
+	var size int64
+
+	// missing or unreadable files are not counted, nothing to do with the error
+	_ = filepath.WalkDir(s.getCacheDir(int(cacheID)), func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return size
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caarlos0/env/v9"
+)
+
+func TestServiceConfig_Eviction(t *testing.T) {
+	t.Setenv("CACHE_MAX_SIZE", "1GB")
+	t.Setenv("CACHE_REPOSITORY_MAX_SIZES", "aweris/gale:512MB,octocat/hello-world:100")
+
+	var config ServiceConfig
+
+	if err := env.ParseWithOptions(&config, env.Options{FuncMap: envParsers}); err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+
+	if config.Eviction.MaxSize != 1<<30 || config.Eviction.RepositoryMaxSize != 10<<30 || config.Eviction.MaxAge != 7*24*time.Hour {
+		t.Errorf("Unexpected eviction config %+v", config.Eviction)
+	}
+
+	if got := config.Eviction.repositoryMaxSize("aweris/gale"); got != 512<<20 {
+		t.Errorf("Expected repository limit %d, but got %d", 512<<20, got)
+	}
+
+	if got := config.Eviction.repositoryMaxSize("octocat/hello-world"); got != 100 {
+		t.Errorf("Expected repository limit %d, but got %d", 100, got)
+	}
+}
+
+func TestLocalService_Evict(t *testing.T) {
+	now := time.Now()
+
+	srv, err := NewLocalService(t.TempDir(), EvictionConfig{
+		MaxSize:            25,
+		RepositoryMaxSize:  15,
+		MaxAge:             24 * time.Hour,
+		ReservationTimeout: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	defer srv.Close()
+
+	// each committed entry has a 10 bytes archive
+	create := func(key, repository string, lastUsed time.Duration, complete bool) uint64 {
+		id, err := srv.Reserve(key, "v1", repository, 10)
+		if err != nil {
+			t.Fatalf("Failed to reserve cache: %v", err)
+		}
+
+		if err := srv.Upload(int(id), 0, strings.NewReader("0123456789")); err != nil {
+			t.Fatalf("Failed to upload cache: %v", err)
+		}
+
+		if complete {
+			if err := srv.Commit(int(id)); err != nil {
+				t.Fatalf("Failed to commit cache: %v", err)
+			}
+		}
+
+		entry, err := srv.db.FindByID(id)
+		if err != nil {
+			t.Fatalf("Failed to find cache: %v", err)
+		}
+
+		entry.LastUsedAt = now.Add(-lastUsed).Unix()
+		entry.CreatedAt = now.Add(-lastUsed).Unix()
+
+		if err := srv.db.Update(entry); err != nil {
+			t.Fatalf("Failed to update cache: %v", err)
+		}
+
+		return id
+	}
+
+	stale := create("stale", "aweris/gale", 2*time.Hour, false)
+	pending := create("pending", "aweris/gale", time.Minute, false)
+	expired := create("expired", "aweris/gale", 48*time.Hour, true)
+	repoOld := create("repo-old", "aweris/gale", 3*time.Hour, true)
+	repoNew := create("repo-new", "aweris/gale", time.Minute, true)
+	otherOld := create("other-old", "octocat/hello-world", 2*time.Hour, true)
+	otherNew := create("other-new", "octocat/hello-world", time.Hour, true)
+
+	// archive without a cache entry
+	if err := os.MkdirAll(filepath.Join(srv.path, "999"), 0755); err != nil {
+		t.Fatalf("Failed to create orphan: %v", err)
+	}
+
+	stats, err := srv.Evict(now)
+	if err != nil {
+		t.Fatalf("Failed to evict: %v", err)
+	}
+
+	expected := EvictionStats{
+		StaleReservations: 1,
+		Expired:           1,
+		RepositoryLimit:   2, // repo-old and other-old
+		SizeLimit:         0,
+		Orphaned:          1,
+		FreedBytes:        40,
+		RemainingEntries:  2,
+		RemainingBytes:    20,
+	}
+
+	if *stats != expected {
+		t.Errorf("Expected stats %+v, but got %+v", expected, *stats)
+	}
+
+	for _, id := range []uint64{stale, expired, repoOld, otherOld} {
+		if _, err := srv.db.FindByID(id); err == nil {
+			t.Errorf("Expected cache %d to be evicted", id)
+		}
+
+		if _, err := os.Stat(srv.getCacheDir(int(id))); !os.IsNotExist(err) {
+			t.Errorf("Expected files of cache %d to be removed, but got %v", id, err)
+		}
+	}
+
+	for _, id := range []uint64{pending, repoNew, otherNew} {
+		if _, err := srv.db.FindByID(id); err != nil {
+			t.Errorf("Expected cache %d to be kept, but got %v", id, err)
+		}
+	}
+
+	// evicted keys can be reserved again
+	if ok, _ := srv.Exist("stale", "v1"); ok {
+		t.Errorf("Expected stale reservation to be removed from the index")
+	}
+
+	// the total limit evicts the least recently used entry across repositories
+	srv.eviction.MaxSize = 15
+
+	if stats, err = srv.Evict(now); err != nil {
+		t.Fatalf("Failed to evict: %v", err)
+	}
+
+	if stats.SizeLimit != 1 || stats.RemainingEntries != 1 {
+		t.Errorf("Expected 1 entry evicted by size limit and 1 remaining, but got %+v", *stats)
+	}
+
+	if _, err := srv.db.FindByID(otherNew); err == nil {
+		t.Errorf("Expected least recently used cache %d to be evicted", otherNew)
+	}
+}
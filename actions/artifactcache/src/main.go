@@ -9,24 +9,27 @@ import (
 
 // ServiceConfig is the configuration for the artifactcache service.
 type ServiceConfig struct {
-	CacheDir string `env:"CACHE_DIR" envDefault:"/cache"`
-	Port     string `env:"PORT" envDefault:"8080"`
+	CacheDir string         `env:"CACHE_DIR" envDefault:"/cache"`
+	Port     string         `env:"PORT" envDefault:"8080"`
+	Eviction EvictionConfig // Eviction is the configuration of the cache size limits and the cleanup.
 }
 
 func main() {
 	var config ServiceConfig
 
-	if err := env.Parse(&config); err != nil {
+	if err := env.ParseWithOptions(&config, env.Options{FuncMap: envParsers}); err != nil {
 		fmt.Printf("Error parsing environment variables: %s\n", err.Error())
 		os.Exit(1)
 	}
 
-	srv, err := NewLocalService(config.CacheDir)
+	srv, err := NewLocalService(config.CacheDir, config.Eviction)
 	if err != nil {
 		fmt.Printf("Error starting artifact service: %s\n", err.Error())
 		os.Exit(1)
 	}
 
+	srv.StartEviction()
+
 	if err := Serve(config.Port, srv); err != nil {
 		fmt.Printf("Error starting artifact service: %s\n", err.Error())
 		os.Exit(1)
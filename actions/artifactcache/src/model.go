@@ -52,6 +52,7 @@ type CacheEntry struct {
 	ID         uint64 `json:"id"`         // ID is the unique identifier of the cache entry
 	Key        string `json:"key"`        // Key is the cache key of the cache entry. It is used to identify the cache entry
 	Version    string `json:"version"`    // Version is the version of the cache entry. It is used to identify the cache entry
+	Repository string `json:"repository"` // Repository is the repository reserved the cache entry. It is used to apply the repository size limits
 	Size       int    `json:"size"`       // Size is the size of the cache entry in bytes. It'll be -1 for old actions doesn't support size
 	Complete   bool   `json:"complete"`   // Complete indicates whether the cache entry is committed or not
 	LastUsedAt int64  `json:"lastUsedAt"` // LastUsedAt is the timestamp of the last time the cache entry is used
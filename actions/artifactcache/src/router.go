@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -89,7 +90,7 @@ func (h *handler) HandleReserveCache(w http.ResponseWriter, r *http.Request, _ h
 	}
 
 	// reserve cache
-	id, err := h.srv.Reserve(req.Key, req.Version, req.CacheSize)
+	id, err := h.srv.Reserve(req.Key, req.Version, repositoryFromRequest(r), req.CacheSize)
 	if err != nil {
 		log.Errorf("Failed to reserve cache", "error", err)
 		h.sendJSON(w, http.StatusInternalServerError, err.Error())
@@ -175,6 +176,33 @@ func (h *handler) HandleHealthz(w http.ResponseWriter, _ *http.Request, _ httpro
 	w.WriteHeader(http.StatusOK)
 }
 
+// repositoryFromRequest returns the repository of the request from the `repository` claim of the runtime token. The
+// token is not verified, it's only used to apply the repository size limits. If the token doesn't have the claim, it
+// returns an empty string.
+func repositoryFromRequest(r *http.Request) string {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+
+	var claims struct {
+		Repository string `json:"repository"`
+	}
+
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+
+	return claims.Repository
+}
+
 // sendJSON sends a JSON response with the given status code and data. If data is nil, skips the body.
 func (h *handler) sendJSON(w http.ResponseWriter, code int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -23,8 +23,9 @@ type Service interface {
 	Exist(key, version string) (bool, error)
 
 	// Reserve reserves a cache entry for the given key. The cache entry will be created if it does not exist,
-	// otherwise it will return error.
-	Reserve(key, version string, size int) (uint64, error)
+	// otherwise it will return error. Repository is the owner of the cache entry, it's used to apply the repository
+	// size limits.
+	Reserve(key, version, repository string, size int) (uint64, error)
 
 	// Upload uploads the given data to the cache entry with the given id. The data will be appended to the cache
 	// entry. The offset indicates the offset of the data in the cache entry.
@@ -52,24 +53,26 @@ var (
 )
 
 type LocalService struct {
-	path string     // path to the artifact cache directory
-	db   *BoltStore // db to store cache entries
+	path     string         // path to the artifact cache directory
+	db       *BoltStore     // db to store cache entries
+	eviction EvictionConfig // eviction configuration of the cache entries
+	done     chan struct{}  // done is closed when the service is closed to stop the background eviction
 }
 
-// TODO: add cache entry expiration / cleanup. Currently, the cache entries are never deleted. Only when dagger cache volume is deleted, the cache entries are deleted.
-
 // NewLocalService creates a new local artifact service.
-func NewLocalService(root string) (*LocalService, error) {
+func NewLocalService(root string, eviction EvictionConfig) (*LocalService, error) {
 	db, err := NewBoltStore(filepath.Join(root, "metadata"))
 	if err != nil {
 		return nil, err
 	}
 
-	return &LocalService{db: db, path: root}, nil
+	return &LocalService{db: db, path: root, eviction: eviction, done: make(chan struct{})}, nil
 }
 
 // Close closes the local artifact service.
 func (s *LocalService) Close() error {
+	close(s.done)
+
 	return s.db.Close()
 }
 
@@ -86,7 +89,7 @@ func (s *LocalService) Exist(key, version string) (bool, error) {
 	return ok, nil
 }
 
-func (s *LocalService) Reserve(key, version string, size int) (uint64, error) {
+func (s *LocalService) Reserve(key, version, repository string, size int) (uint64, error) {
 	if size == 0 {
 		size = -1
 	}
@@ -97,6 +100,7 @@ func (s *LocalService) Reserve(key, version string, size int) (uint64, error) {
 		ID:         0,
 		Key:        key,
 		Version:    version,
+		Repository: repository,
 		Size:       size,
 		Complete:   false,
 		LastUsedAt: now,
@@ -108,7 +112,7 @@ func (s *LocalService) Reserve(key, version string, size int) (uint64, error) {
 		return 0, err
 	}
 
-	log.Debugf("Reserved cache entry", "key", key, "version", version, "id", entry.ID)
+	log.Debugf("Reserved cache entry", "key", key, "version", version, "repository", repository, "id", entry.ID)
 
 	return entry.ID, nil
 }
@@ -283,6 +283,72 @@ func (b *BoltStore) Update(entry *CacheEntry) error {
 	})
 }
 
+// List returns all cache entries in the database, including the incomplete ones.
+func (b *BoltStore) List() ([]*CacheEntry, error) {
+	var entries []*CacheEntry
+
+	err := b.conn.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(dbEntries)
+		if bucket == nil {
+			return fmt.Errorf("bucket %s not found", dbEntries)
+		}
+
+		return bucket.ForEach(func(_, val []byte) error {
+			var entry *CacheEntry
+
+			if err := json.Unmarshal(val, &entry); err != nil {
+				return err
+			}
+
+			entries = append(entries, entry)
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Delete deletes the cache entry with the given id and its index. It's no-op if the entry doesn't exist.
+func (b *BoltStore) Delete(cacheID uint64) error {
+	return b.conn.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(dbEntries)
+		if bucket == nil {
+			return fmt.Errorf("bucket %s not found", dbEntries)
+		}
+
+		idx := tx.Bucket(idxVersionKey)
+		if idx == nil {
+			return fmt.Errorf("bucket %s not found", string(idxVersionKey))
+		}
+
+		val := bucket.Get(itob(cacheID))
+		if val == nil {
+			return nil
+		}
+
+		var entry *CacheEntry
+
+		if err := json.Unmarshal(val, &entry); err != nil {
+			return err
+		}
+
+		// remove the index only if it points to the deleted entry
+		idxKey := []byte(fmt.Sprintf("%s:%s", entry.Version, entry.Key))
+
+		if bytes.Equal(idx.Get(idxKey), itob(cacheID)) {
+			if err := idx.Delete(idxKey); err != nil {
+				return err
+			}
+		}
+
+		return bucket.Delete(itob(cacheID))
+	})
+}
+
 // itob returns an 8-byte big endian representation of v.
 func itob(u uint64) []byte {
 	buf := make([]byte, 8)
@@ -39,8 +39,8 @@ func (m *ActionsArtifactService) Service(ctx context.Context) (*Service, error)
 	return ctr.AsService(), nil
 }
 
-// Binds the artifact service as a service to the given container and configures ACTIONS_RUNTIME_URL and
-// ACTIONS_RUNTIME_TOKEN to allow the artifact service to communicate with the github actions runner.
+// Binds the artifact service as a service to the given container and configures ACTIONS_RUNTIME_URL,
+// ACTIONS_RESULTS_URL and ACTIONS_RUNTIME_TOKEN to allow the artifact service to communicate with the github actions runner.
 func (m *ActionsArtifactService) BindAsService(
 	// context to use for binding the artifact service.
 	ctx context.Context,
@@ -67,6 +67,9 @@ func (m *ActionsArtifactService) BindAsService(
 	// set the runtime url and token
 	ctr = ctr.WithEnvVariable("ACTIONS_RUNTIME_URL", endpoint).WithEnvVariable("ACTIONS_RUNTIME_TOKEN", "token")
 
+	// set the results url for the v4 artifact actions, the same service serves both versions
+	ctr = ctr.WithEnvVariable("ACTIONS_RESULTS_URL", endpoint)
+
 	return ctr, nil
 }
 
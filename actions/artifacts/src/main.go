@@ -20,7 +20,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := Serve(config.Port, NewLocalService(config.ArtifactDir)); err != nil {
+	if err := Serve(config.Port, NewLocalService(config.ArtifactDir), NewLocalServiceV4(config.ArtifactDir)); err != nil {
 		fmt.Printf("Error starting artifact service: %s\n", err.Error())
 		os.Exit(1)
 	}
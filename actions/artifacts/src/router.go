@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -43,10 +44,18 @@ type QueryArtifactResponse struct {
 }
 
 // Serve starts the artifact service router on the given port
-func Serve(port string, srv Service) error {
+func Serve(port string, srv Service, srvV4 ServiceV4) error {
 	router := httprouter.New()
 
-	handler := &handler{srv: srv}
+	// key is used to sign the blob urls of the v4 artifacts. It's generated on each start, so the urls are only valid
+	// for the lifetime of the service.
+	key := make([]byte, 32)
+
+	if _, err := rand.Read(key); err != nil {
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	handler := &handler{srv: srv, srvV4: srvV4, key: key}
 
 	router.POST("/_apis/pipelines/workflows/:runID/artifacts", handler.HandleCreateArtifactInNameContainer)
 	router.PATCH("/_apis/pipelines/workflows/:runID/artifacts", handler.HandlePatchArtifactSize)
@@ -56,6 +65,15 @@ func Serve(port string, srv Service) error {
 	router.GET("/artifact/*path", handler.HandleDownloadSingleArtifact)
 	router.GET("/healthz", handler.HandleHealthz)
 
+	// v4 artifacts API used by actions/upload-artifact@v4 and actions/download-artifact@v4
+	router.POST(twirpArtifactServicePath+"CreateArtifact", handler.HandleCreateArtifact)
+	router.POST(twirpArtifactServicePath+"FinalizeArtifact", handler.HandleFinalizeArtifact)
+	router.POST(twirpArtifactServicePath+"ListArtifacts", handler.HandleListArtifactsV4)
+	router.POST(twirpArtifactServicePath+"GetSignedArtifactURL", handler.HandleGetSignedArtifactURL)
+	router.POST(twirpArtifactServicePath+"DeleteArtifact", handler.HandleDeleteArtifact)
+	router.PUT("/blob/:runID/:name", handler.HandleUploadBlob)
+	router.GET("/blob/:runID/:name", handler.HandleDownloadBlob)
+
 	server := &http.Server{
 		Addr:              fmt.Sprintf(":%s", port),
 		Handler:           router,
@@ -71,7 +89,9 @@ const (
 )
 
 type handler struct {
-	srv Service
+	srv   Service
+	srvV4 ServiceV4
+	key   []byte // key to sign the blob urls of the v4 artifacts
 }
 
 func (h *handler) HandleCreateArtifactInNameContainer(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
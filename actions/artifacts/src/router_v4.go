@@ -0,0 +1,428 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// twirpArtifactServicePath is the path prefix of the Twirp ArtifactService methods used by the v4 artifact actions.
+// Source: https://github.com/actions/toolkit/blob/main/packages/artifact/src/generated/results/api/v1/artifact.twirp-client.ts
+const twirpArtifactServicePath = "/twirp/github.actions.results.api.v1.ArtifactService/"
+
+const (
+	// signedURLExpiry is the validity duration of the signed blob urls. Large artifacts are uploaded in many blocks,
+	// so the upload url should be valid long enough to complete the upload.
+	signedURLExpiry = 6 * time.Hour
+
+	permissionRead  = "r"
+	permissionWrite = "w"
+)
+
+// int64String is an int64 value encoded as string in JSON, same as the protobuf JSON mapping of int64 fields. Numbers
+// are accepted as well while decoding.
+type int64String int64
+
+func (i int64String) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatInt(int64(i), 10))
+}
+
+func (i *int64String) UnmarshalJSON(data []byte) error {
+	str := strings.Trim(string(data), `"`)
+
+	if str == "" || str == "null" {
+		*i = 0
+		return nil
+	}
+
+	val, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	*i = int64String(val)
+
+	return nil
+}
+
+// CreateArtifactRequest represents the request to create a new artifact. Expiration and mime type of the artifact are
+// ignored.
+// Source: https://github.com/actions/toolkit/blob/main/packages/artifact/src/generated/results/api/v1/artifact.ts
+type CreateArtifactRequest struct {
+	WorkflowRunBackendID    string `json:"workflow_run_backend_id"`
+	WorkflowJobRunBackendID string `json:"workflow_job_run_backend_id"`
+	Name                    string `json:"name"`
+	Version                 int    `json:"version"`
+}
+
+// CreateArtifactResponse represents the response of the artifact creation with the url to upload the artifact blob.
+type CreateArtifactResponse struct {
+	OK              bool   `json:"ok"`
+	SignedUploadURL string `json:"signed_upload_url"`
+}
+
+// FinalizeArtifactRequest represents the request to complete the upload of an artifact. Hash is the digest of the
+// uploaded blob in `sha256:<hex>` format.
+type FinalizeArtifactRequest struct {
+	WorkflowRunBackendID    string      `json:"workflow_run_backend_id"`
+	WorkflowJobRunBackendID string      `json:"workflow_job_run_backend_id"`
+	Name                    string      `json:"name"`
+	Size                    int64String `json:"size"`
+	Hash                    string      `json:"hash"`
+}
+
+// FinalizeArtifactResponse represents the response of the artifact finalization.
+type FinalizeArtifactResponse struct {
+	OK         bool        `json:"ok"`
+	ArtifactID int64String `json:"artifact_id"`
+}
+
+// ListArtifactsV4Request represents the request to list the artifacts of a workflow run with optional filters.
+type ListArtifactsV4Request struct {
+	WorkflowRunBackendID    string      `json:"workflow_run_backend_id"`
+	WorkflowJobRunBackendID string      `json:"workflow_job_run_backend_id"`
+	NameFilter              string      `json:"name_filter"`
+	IDFilter                int64String `json:"id_filter"`
+}
+
+// MonolithArtifact represents a single artifact in the artifact listing.
+type MonolithArtifact struct {
+	WorkflowRunBackendID    string      `json:"workflow_run_backend_id"`
+	WorkflowJobRunBackendID string      `json:"workflow_job_run_backend_id"`
+	DatabaseID              int64String `json:"database_id"`
+	Name                    string      `json:"name"`
+	Size                    int64String `json:"size"`
+	CreatedAt               time.Time   `json:"created_at"`
+	Digest                  string      `json:"digest,omitempty"`
+}
+
+// ListArtifactsV4Response represents the response of the artifact listing.
+type ListArtifactsV4Response struct {
+	Artifacts []MonolithArtifact `json:"artifacts"`
+}
+
+// GetSignedArtifactURLRequest represents the request to get the download url of an artifact.
+type GetSignedArtifactURLRequest struct {
+	WorkflowRunBackendID    string `json:"workflow_run_backend_id"`
+	WorkflowJobRunBackendID string `json:"workflow_job_run_backend_id"`
+	Name                    string `json:"name"`
+}
+
+// GetSignedArtifactURLResponse represents the response with the url to download the artifact blob.
+type GetSignedArtifactURLResponse struct {
+	SignedURL string `json:"signed_url"`
+}
+
+// DeleteArtifactRequest represents the request to delete an artifact. Upload action deletes the existing artifact
+// before uploading it again if `overwrite` is enabled.
+type DeleteArtifactRequest struct {
+	WorkflowRunBackendID    string `json:"workflow_run_backend_id"`
+	WorkflowJobRunBackendID string `json:"workflow_job_run_backend_id"`
+	Name                    string `json:"name"`
+}
+
+// DeleteArtifactResponse represents the response of the artifact deletion.
+type DeleteArtifactResponse struct {
+	OK         bool        `json:"ok"`
+	ArtifactID int64String `json:"artifact_id"`
+}
+
+// twirpError represents the error response of the Twirp methods.
+// Source: https://twitchtv.github.io/twirp/docs/spec_v7.html#error-codes
+type twirpError struct {
+	Code string `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+func (h *handler) HandleCreateArtifact(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var req CreateArtifactRequest
+
+	if !h.readTwirpRequest(w, r, &req) {
+		return
+	}
+
+	artifact, err := h.srvV4.CreateArtifact(req.WorkflowRunBackendID, req.WorkflowJobRunBackendID, req.Name)
+	if err != nil {
+		h.sendTwirpError(w, err)
+		return
+	}
+
+	uploadURL := h.signedBlobURL(r.Host, artifact.RunID, artifact.Name, permissionWrite)
+
+	h.sendJSON(w, http.StatusOK, CreateArtifactResponse{OK: true, SignedUploadURL: uploadURL})
+}
+
+func (h *handler) HandleFinalizeArtifact(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var req FinalizeArtifactRequest
+
+	if !h.readTwirpRequest(w, r, &req) {
+		return
+	}
+
+	artifact, err := h.srvV4.FinalizeArtifact(req.WorkflowRunBackendID, req.Name, int64(req.Size), req.Hash)
+	if err != nil {
+		h.sendTwirpError(w, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, FinalizeArtifactResponse{OK: true, ArtifactID: int64String(artifact.ID)})
+}
+
+func (h *handler) HandleListArtifactsV4(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var req ListArtifactsV4Request
+
+	if !h.readTwirpRequest(w, r, &req) {
+		return
+	}
+
+	// artifacts are listed for the whole workflow run, job run id is ignored same as GitHub
+	artifacts, err := h.srvV4.ListArtifacts(req.WorkflowRunBackendID, req.NameFilter, int64(req.IDFilter))
+	if err != nil {
+		h.sendTwirpError(w, err)
+		return
+	}
+
+	// pre-allocate the slice to avoid reallocation
+	resp := ListArtifactsV4Response{Artifacts: make([]MonolithArtifact, 0, len(artifacts))}
+
+	for _, artifact := range artifacts {
+		resp.Artifacts = append(resp.Artifacts, MonolithArtifact{
+			WorkflowRunBackendID:    artifact.RunID,
+			WorkflowJobRunBackendID: artifact.JobRunID,
+			DatabaseID:              int64String(artifact.ID),
+			Name:                    artifact.Name,
+			Size:                    int64String(artifact.Size),
+			CreatedAt:               artifact.CreatedAt,
+			Digest:                  artifact.Digest,
+		})
+	}
+
+	h.sendJSON(w, http.StatusOK, resp)
+}
+
+func (h *handler) HandleGetSignedArtifactURL(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var req GetSignedArtifactURLRequest
+
+	if !h.readTwirpRequest(w, r, &req) {
+		return
+	}
+
+	artifact, err := h.srvV4.GetArtifact(req.WorkflowRunBackendID, req.Name)
+	if err != nil {
+		h.sendTwirpError(w, err)
+		return
+	}
+
+	downloadURL := h.signedBlobURL(r.Host, artifact.RunID, artifact.Name, permissionRead)
+
+	h.sendJSON(w, http.StatusOK, GetSignedArtifactURLResponse{SignedURL: downloadURL})
+}
+
+func (h *handler) HandleDeleteArtifact(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var req DeleteArtifactRequest
+
+	if !h.readTwirpRequest(w, r, &req) {
+		return
+	}
+
+	artifact, err := h.srvV4.DeleteArtifact(req.WorkflowRunBackendID, req.Name)
+	if err != nil {
+		h.sendTwirpError(w, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, DeleteArtifactResponse{OK: true, ArtifactID: int64String(artifact.ID)})
+}
+
+// HandleUploadBlob handles the blob uploads to the signed upload url. The endpoint mimics the Azure Blob Storage API
+// used by the upload client, blocks are staged with `comp=block` and committed with `comp=blocklist`.
+// Source: https://learn.microsoft.com/en-us/rest/api/storageservices/put-block-list
+func (h *handler) HandleUploadBlob(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	runID, name := params.ByName("runID"), params.ByName("name")
+
+	if !h.verifySignature(r.URL.Query(), runID, name, permissionWrite) {
+		http.Error(w, "invalid or expired signature", http.StatusForbidden)
+		return
+	}
+
+	var err error
+
+	switch comp := r.URL.Query().Get("comp"); comp {
+	case "block":
+		err = h.srvV4.StageBlock(runID, name, r.URL.Query().Get("blockid"), r.Body)
+	case "blocklist":
+		var blockIDs []string
+
+		if blockIDs, err = parseBlockList(r.Body); err != nil {
+			err = fmt.Errorf("%w: %s", ErrInvalidArtifact, err.Error())
+			break
+		}
+
+		err = h.srvV4.CommitBlocks(runID, name, blockIDs)
+	case "":
+		err = h.srvV4.PutBlob(runID, name, r.Body)
+	default:
+		err = fmt.Errorf("%w: unsupported operation %s", ErrInvalidArtifact, comp)
+	}
+
+	if err != nil {
+		fmt.Printf("Error uploading artifact blob: %s\n", err.Error())
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// HandleDownloadBlob handles the artifact downloads from the signed download url.
+func (h *handler) HandleDownloadBlob(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	runID, name := params.ByName("runID"), params.ByName("name")
+
+	if !h.verifySignature(r.URL.Query(), runID, name, permissionRead) {
+		http.Error(w, "invalid or expired signature", http.StatusForbidden)
+		return
+	}
+
+	reader, err := h.srvV4.OpenArtifact(runID, name)
+	if err != nil {
+		fmt.Printf("Error downloading artifact blob: %s\n", err.Error())
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	defer reader.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.WriteHeader(http.StatusOK)
+
+	io.Copy(w, reader) //nolint:errcheck // nothing to do with the error, the response is already started
+}
+
+// readTwirpRequest decodes the JSON request body to the given value. If the body is malformed, it sends the error
+// response and returns false.
+func (h *handler) readTwirpRequest(w http.ResponseWriter, r *http.Request, val interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(val); err != nil {
+		fmt.Printf("Error decoding request: %s\n", err.Error())
+		h.sendJSON(w, http.StatusBadRequest, twirpError{Code: "malformed", Msg: err.Error()})
+
+		return false
+	}
+
+	return true
+}
+
+// sendTwirpError sends the given error as Twirp error response.
+func (h *handler) sendTwirpError(w http.ResponseWriter, err error) {
+	fmt.Printf("Error processing request: %s\n", err.Error())
+
+	code := "internal"
+
+	switch {
+	case errors.Is(err, ErrArtifactNotFound):
+		code = "not_found"
+	case errors.Is(err, ErrArtifactExists):
+		code = "already_exists"
+	case errors.Is(err, ErrInvalidArtifact):
+		code = "invalid_argument"
+	}
+
+	h.sendJSON(w, errorStatus(err), twirpError{Code: code, Msg: err.Error()})
+}
+
+// signedBlobURL returns the signed url of the artifact blob with the given permission.
+func (h *handler) signedBlobURL(host, runID, name, permission string) string {
+	expiry := strconv.FormatInt(time.Now().Add(signedURLExpiry).Unix(), 10)
+
+	query := url.Values{}
+
+	query.Set("sp", permission)
+	query.Set("se", expiry)
+	query.Set("sig", h.sign(runID, name, permission, expiry))
+
+	return fmt.Sprintf("http://%s/blob/%s/%s?%s", host, url.PathEscape(runID), url.PathEscape(name), query.Encode())
+}
+
+// verifySignature verifies the signature of the blob url is valid for the given permission and not expired.
+func (h *handler) verifySignature(query url.Values, runID, name, permission string) bool {
+	if query.Get("sp") != permission {
+		return false
+	}
+
+	expiry, err := strconv.ParseInt(query.Get("se"), 10, 64)
+	if err != nil || time.Now().Unix() > expiry {
+		return false
+	}
+
+	expected := h.sign(runID, name, permission, query.Get("se"))
+
+	return hmac.Equal([]byte(expected), []byte(query.Get("sig")))
+}
+
+// sign returns the signature of the blob url parameters.
+func (h *handler) sign(runID, name, permission, expiry string) string {
+	mac := hmac.New(sha256.New, h.key)
+
+	mac.Write([]byte(strings.Join([]string{runID, name, permission, expiry}, "\n")))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseBlockList parses the block ids from the block list in the given reader in the order they are listed.
+func parseBlockList(reader io.Reader) ([]string, error) {
+	var (
+		ids     []string
+		decoder = xml.NewDecoder(reader)
+	)
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return ids, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "Latest", "Committed", "Uncommitted":
+			var id string
+
+			if err := decoder.DecodeElement(&id, &start); err != nil {
+				return nil, err
+			}
+
+			ids = append(ids, id)
+		}
+	}
+}
+
+// errorStatus returns the http status code for the given service error.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrArtifactNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrArtifactExists):
+		return http.StatusConflict
+	case errors.Is(err, ErrInvalidArtifact):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func newTestRouterV4(t *testing.T) http.Handler {
+	t.Helper()
+
+	handler := &handler{srvV4: NewLocalServiceV4(t.TempDir()), key: []byte("test-key")}
+
+	router := httprouter.New()
+	router.POST(twirpArtifactServicePath+"CreateArtifact", handler.HandleCreateArtifact)
+	router.POST(twirpArtifactServicePath+"FinalizeArtifact", handler.HandleFinalizeArtifact)
+	router.POST(twirpArtifactServicePath+"ListArtifacts", handler.HandleListArtifactsV4)
+	router.POST(twirpArtifactServicePath+"GetSignedArtifactURL", handler.HandleGetSignedArtifactURL)
+	router.POST(twirpArtifactServicePath+"DeleteArtifact", handler.HandleDeleteArtifact)
+	router.PUT("/blob/:runID/:name", handler.HandleUploadBlob)
+	router.GET("/blob/:runID/:name", handler.HandleDownloadBlob)
+
+	return router
+}
+
+func doRequest(t *testing.T, router http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, err := http.NewRequest(method, target, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Host = "example.com"
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	return rr
+}
+
+// pathAndQuery returns the path and query of the given signed url to send the request to the test router.
+func pathAndQuery(t *testing.T, signed string) string {
+	t.Helper()
+
+	parsed, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("Failed to parse signed url %s: %v", signed, err)
+	}
+
+	if parsed.Host != "example.com" {
+		t.Errorf("Expected signed url host example.com, but got %s", parsed.Host)
+	}
+
+	return parsed.RequestURI()
+}
+
+func TestHandler_ArtifactV4Lifecycle(t *testing.T) {
+	router := newTestRouterV4(t)
+
+	rr := doRequest(t, router, "POST", twirpArtifactServicePath+"CreateArtifact", `{"workflow_run_backend_id":"123","workflow_job_run_backend_id":"456","name":"my artifact","version":4}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var created CreateArtifactResponse
+	if err := json.NewDecoder(rr.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+
+	upload := pathAndQuery(t, created.SignedUploadURL)
+
+	// upload url can't be used for downloads
+	if rr := doRequest(t, router, "GET", upload, ""); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d for download with upload url, but got %d", http.StatusForbidden, rr.Code)
+	}
+
+	if rr := doRequest(t, router, "PUT", upload+"&comp=block&blockid=Zmlyc3Q%3D", "hello "); rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, but got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	if rr := doRequest(t, router, "PUT", upload+"&comp=block&blockid=c2Vjb25k", "world"); rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, but got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	blockList := `<?xml version="1.0" encoding="utf-8"?><BlockList><Latest>Zmlyc3Q=</Latest><Uncommitted>c2Vjb25k</Uncommitted></BlockList>`
+	if rr := doRequest(t, router, "PUT", upload+"&comp=blocklist", blockList); rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, but got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	// size is sent as string by the client
+	rr = doRequest(t, router, "POST", twirpArtifactServicePath+"FinalizeArtifact", `{"workflow_run_backend_id":"123","workflow_job_run_backend_id":"456","name":"my artifact","size":"11","hash":"sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	rr = doRequest(t, router, "POST", twirpArtifactServicePath+"ListArtifacts", `{"workflow_run_backend_id":"123","name_filter":"my artifact"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var listed ListArtifactsV4Response
+	if err := json.NewDecoder(rr.Body).Decode(&listed); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(listed.Artifacts) != 1 || listed.Artifacts[0].Size != 11 || listed.Artifacts[0].Name != "my artifact" {
+		t.Fatalf("Unexpected artifact listing %+v", listed)
+	}
+
+	rr = doRequest(t, router, "POST", twirpArtifactServicePath+"GetSignedArtifactURL", `{"workflow_run_backend_id":"123","name":"my artifact"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var signed GetSignedArtifactURLResponse
+	if err := json.NewDecoder(rr.Body).Decode(&signed); err != nil {
+		t.Fatal(err)
+	}
+
+	rr = doRequest(t, router, "GET", pathAndQuery(t, signed.SignedURL), "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	if content, _ := io.ReadAll(rr.Body); string(content) != "hello world" {
+		t.Errorf("Expected content %q, but got %q", "hello world", string(content))
+	}
+
+	// artifact is immutable, uploading the same name again is rejected
+	rr = doRequest(t, router, "POST", twirpArtifactServicePath+"CreateArtifact", `{"workflow_run_backend_id":"123","name":"my artifact"}`)
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), `"already_exists"`) {
+		t.Errorf("Expected already_exists error, but got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = doRequest(t, router, "POST", twirpArtifactServicePath+"DeleteArtifact", `{"workflow_run_backend_id":"123","name":"my artifact"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	rr = doRequest(t, router, "POST", twirpArtifactServicePath+"GetSignedArtifactURL", `{"workflow_run_backend_id":"123","name":"my artifact"}`)
+	if rr.Code != http.StatusNotFound || !strings.Contains(rr.Body.String(), `"not_found"`) {
+		t.Errorf("Expected not_found error, but got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestHandler_HandleUploadBlobRejectsInvalidSignature(t *testing.T) {
+	router := newTestRouterV4(t)
+
+	rr := doRequest(t, router, "POST", twirpArtifactServicePath+"CreateArtifact", `{"workflow_run_backend_id":"123","name":"test"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var created CreateArtifactResponse
+	if err := json.NewDecoder(rr.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+
+	// signature is bound to the artifact, it can't be used for another one
+	tampered := strings.Replace(pathAndQuery(t, created.SignedUploadURL), "/blob/123/test", "/blob/123/other", 1)
+
+	if rr := doRequest(t, router, "PUT", tampered, "content"); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d, but got %d", http.StatusForbidden, rr.Code)
+	}
+}
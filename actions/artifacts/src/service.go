@@ -6,6 +6,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 
 	galefs "github.com/aweris/gale/common/fs"
 )
@@ -89,6 +90,11 @@ func (s *LocalService) ListArtifacts(runID string) (string, []string, error) {
 	artifacts := make([]string, 0, len(entries))
 
 	for _, entry := range entries {
+		// skip hidden entries, e.g. v4 artifacts, they're not part of the v3 artifact listing
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
 		artifacts = append(artifacts, entry.Name())
 	}
 
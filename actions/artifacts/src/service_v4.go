@@ -0,0 +1,424 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	galefs "github.com/aweris/gale/common/fs"
+)
+
+var (
+	// ErrArtifactNotFound is returned when the artifact does not exist or not finalized yet.
+	ErrArtifactNotFound = errors.New("artifact not found")
+
+	// ErrArtifactExists is returned when an artifact with the same name is already finalized in the workflow run.
+	// Artifacts are immutable once finalized, they can only be deleted and uploaded again.
+	ErrArtifactExists = errors.New("an artifact with this name already exists on the workflow run")
+
+	// ErrInvalidArtifact is returned when the request is not valid for the artifact. e.g. invalid name or digest
+	// mismatch.
+	ErrInvalidArtifact = errors.New("invalid artifact")
+)
+
+// dirV4 is the directory name of the v4 artifacts under the run directory. The directory is hidden to not list them as
+// v3 artifacts, since the artifacts of the two versions are not compatible.
+const dirV4 = ".v4"
+
+// Artifact represents a v4 artifact. Content of the artifact is a single zip blob uploaded by the client.
+type Artifact struct {
+	ID        int64     `json:"id"`         // ID is the unique id of the artifact.
+	RunID     string    `json:"run_id"`     // RunID is the workflow run id the artifact belongs to.
+	JobRunID  string    `json:"job_run_id"` // JobRunID is the job run id uploaded the artifact.
+	Name      string    `json:"name"`       // Name is the name of the artifact. Unique in the workflow run.
+	Size      int64     `json:"size"`       // Size is the size of the artifact blob in bytes.
+	Digest    string    `json:"digest"`     // Digest is the sha256 digest of the artifact blob in `sha256:<hex>` format.
+	CreatedAt time.Time `json:"created_at"` // CreatedAt is the creation time of the artifact.
+	Finalized bool      `json:"finalized"`  // Finalized indicates that the upload is completed. Finalized artifacts are immutable.
+}
+
+// ServiceV4 represents the artifact service for the v4 artifact actions. Unlike v3, artifacts are uploaded as a single
+// blob in blocks, and they are immutable once finalized.
+type ServiceV4 interface {
+	// CreateArtifact creates a new pending artifact in the workflow run. If a pending artifact with the same name
+	// exists, it's replaced. If the artifact is already finalized, ErrArtifactExists is returned.
+	CreateArtifact(runID, jobRunID, name string) (*Artifact, error)
+
+	// StageBlock stores the given block of the pending artifact blob to commit later.
+	StageBlock(runID, name, blockID string, reader io.Reader) error
+
+	// CommitBlocks writes the artifact blob from the staged blocks in the given order.
+	CommitBlocks(runID, name string, blockIDs []string) error
+
+	// PutBlob writes the artifact blob in a single request.
+	PutBlob(runID, name string, reader io.Reader) error
+
+	// FinalizeArtifact completes the upload of the artifact. Size and digest of the blob are verified if given.
+	FinalizeArtifact(runID, name string, size int64, digest string) (*Artifact, error)
+
+	// ListArtifacts returns the finalized artifacts of the workflow run. Artifacts are filtered by name and id if the
+	// filters are not empty.
+	ListArtifacts(runID, nameFilter string, idFilter int64) ([]Artifact, error)
+
+	// GetArtifact returns the finalized artifact with the given name.
+	GetArtifact(runID, name string) (*Artifact, error)
+
+	// OpenArtifact opens the blob of the finalized artifact with the given name.
+	OpenArtifact(runID, name string) (io.ReadCloser, error)
+
+	// DeleteArtifact deletes the artifact with the given name and returns the deleted artifact.
+	DeleteArtifact(runID, name string) (*Artifact, error)
+}
+
+var _ ServiceV4 = new(LocalServiceV4)
+
+// LocalServiceV4 stores the v4 artifacts in the same artifact directory with the v3 artifacts.
+type LocalServiceV4 struct {
+	path string     // path to the artifact directory
+	mu   sync.Mutex // mu guards the metadata changes of the artifacts
+}
+
+func NewLocalServiceV4(path string) *LocalServiceV4 {
+	return &LocalServiceV4{path: path}
+}
+
+func (s *LocalServiceV4) CreateArtifact(runID, jobRunID, name string) (*Artifact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir, err := s.artifactDir(runID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.readArtifact(dir)
+	if err != nil && !errors.Is(err, ErrArtifactNotFound) {
+		return nil, err
+	}
+
+	if existing != nil && existing.Finalized {
+		return nil, ErrArtifactExists
+	}
+
+	// start from scratch, pending artifacts are left from a failed upload
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+
+	id, err := s.nextArtifactID()
+	if err != nil {
+		return nil, err
+	}
+
+	artifact := &Artifact{ID: id, RunID: runID, JobRunID: jobRunID, Name: name, CreatedAt: time.Now().UTC()}
+
+	if err := galefs.WriteJSONFile(filepath.Join(dir, "artifact.json"), artifact); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Created artifact %s for run %s\n", name, runID)
+
+	return artifact, nil
+}
+
+func (s *LocalServiceV4) StageBlock(runID, name, blockID string, reader io.Reader) error {
+	dir, err := s.pendingArtifactDir(runID, name)
+	if err != nil {
+		return err
+	}
+
+	if blockID == "" {
+		return fmt.Errorf("%w: block id is required", ErrInvalidArtifact)
+	}
+
+	// block ids are base64 strings, hex encoding them makes them safe to use as file names
+	return writeFile(filepath.Join(dir, "blocks", hex.EncodeToString([]byte(blockID))), reader)
+}
+
+func (s *LocalServiceV4) CommitBlocks(runID, name string, blockIDs []string) error {
+	dir, err := s.pendingArtifactDir(runID, name)
+	if err != nil {
+		return err
+	}
+
+	readers := make([]io.Reader, 0, len(blockIDs))
+
+	for _, blockID := range blockIDs {
+		file, err := os.Open(filepath.Join(dir, "blocks", hex.EncodeToString([]byte(blockID))))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("%w: block %s is not staged", ErrInvalidArtifact, blockID)
+			}
+
+			return err
+		}
+		defer file.Close()
+
+		readers = append(readers, file)
+	}
+
+	if err := writeFile(filepath.Join(dir, "artifact.zip"), io.MultiReader(readers...)); err != nil {
+		return err
+	}
+
+	return os.RemoveAll(filepath.Join(dir, "blocks"))
+}
+
+func (s *LocalServiceV4) PutBlob(runID, name string, reader io.Reader) error {
+	dir, err := s.pendingArtifactDir(runID, name)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filepath.Join(dir, "artifact.zip"), reader)
+}
+
+func (s *LocalServiceV4) FinalizeArtifact(runID, name string, size int64, digest string) (*Artifact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir, err := s.pendingArtifactDir(runID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	artifact, err := s.readArtifact(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filepath.Join(dir, "artifact.zip"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: artifact %s is not uploaded", ErrInvalidArtifact, name)
+		}
+
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+
+	actualSize, err := io.Copy(hash, file)
+	if err != nil {
+		return nil, err
+	}
+
+	actualDigest := "sha256:" + hex.EncodeToString(hash.Sum(nil))
+
+	if size > 0 && size != actualSize {
+		return nil, fmt.Errorf("%w: size mismatch, expected %d but uploaded %d bytes", ErrInvalidArtifact, size, actualSize)
+	}
+
+	if digest != "" && !strings.EqualFold(digest, actualDigest) {
+		return nil, fmt.Errorf("%w: digest mismatch, expected %s but uploaded %s", ErrInvalidArtifact, digest, actualDigest)
+	}
+
+	artifact.Size = actualSize
+	artifact.Digest = actualDigest
+	artifact.Finalized = true
+
+	if err := galefs.WriteJSONFile(filepath.Join(dir, "artifact.json"), artifact); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Artifact %s upload complete for run %s\n", name, runID)
+
+	return artifact, nil
+}
+
+func (s *LocalServiceV4) ListArtifacts(runID, nameFilter string, idFilter int64) ([]Artifact, error) {
+	if err := validatePathSegment("run id", runID); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(s.path, runID, dirV4))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	artifacts := make([]Artifact, 0, len(entries))
+
+	for _, entry := range entries {
+		artifact, err := s.readArtifact(filepath.Join(s.path, runID, dirV4, entry.Name()))
+		if err != nil {
+			// broken entries are not visible to the clients
+			continue
+		}
+
+		if !artifact.Finalized || (nameFilter != "" && artifact.Name != nameFilter) || (idFilter != 0 && artifact.ID != idFilter) {
+			continue
+		}
+
+		artifacts = append(artifacts, *artifact)
+	}
+
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].ID < artifacts[j].ID })
+
+	return artifacts, nil
+}
+
+func (s *LocalServiceV4) GetArtifact(runID, name string) (*Artifact, error) {
+	dir, err := s.artifactDir(runID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	artifact, err := s.readArtifact(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if !artifact.Finalized {
+		return nil, ErrArtifactNotFound
+	}
+
+	return artifact, nil
+}
+
+func (s *LocalServiceV4) OpenArtifact(runID, name string) (io.ReadCloser, error) {
+	if _, err := s.GetArtifact(runID, name); err != nil {
+		return nil, err
+	}
+
+	dir, err := s.artifactDir(runID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(filepath.Join(dir, "artifact.zip"))
+}
+
+func (s *LocalServiceV4) DeleteArtifact(runID, name string) (*Artifact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir, err := s.artifactDir(runID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	artifact, err := s.readArtifact(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Deleted artifact %s for run %s\n", name, runID)
+
+	return artifact, nil
+}
+
+// artifactDir returns the directory of the v4 artifact with the given name in the workflow run.
+func (s *LocalServiceV4) artifactDir(runID, name string) (string, error) {
+	if err := validatePathSegment("run id", runID); err != nil {
+		return "", err
+	}
+
+	if err := validatePathSegment("artifact name", name); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(s.path, runID, dirV4, name), nil
+}
+
+// pendingArtifactDir returns the directory of the artifact if the artifact is created and not finalized yet.
+func (s *LocalServiceV4) pendingArtifactDir(runID, name string) (string, error) {
+	dir, err := s.artifactDir(runID, name)
+	if err != nil {
+		return "", err
+	}
+
+	artifact, err := s.readArtifact(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if artifact.Finalized {
+		return "", ErrArtifactExists
+	}
+
+	return dir, nil
+}
+
+// readArtifact reads the artifact metadata in the given artifact directory.
+func (s *LocalServiceV4) readArtifact(dir string) (*Artifact, error) {
+	var artifact Artifact
+
+	if err := galefs.ReadJSONFile(filepath.Join(dir, "artifact.json"), &artifact); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrArtifactNotFound
+		}
+
+		return nil, err
+	}
+
+	return &artifact, nil
+}
+
+// nextArtifactID returns the next unique artifact id. Ids are unique across the workflow runs, same as GitHub. The last
+// used id is kept in the artifact directory, so ids are not reused after a restart.
+func (s *LocalServiceV4) nextArtifactID() (int64, error) {
+	path := filepath.Join(s.path, "last_artifact_id")
+
+	var last int64
+
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+
+	if len(content) > 0 {
+		if last, err = strconv.ParseInt(string(bytes.TrimSpace(content)), 10, 64); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := galefs.WriteFile(path, []byte(strconv.FormatInt(last+1, 10)), 0600); err != nil {
+		return 0, err
+	}
+
+	return last + 1, nil
+}
+
+// validatePathSegment validates the given value is a safe single path segment, to not allow accessing files outside the
+// artifact directory.
+func validatePathSegment(kind, value string) error {
+	if value == "" || value == "." || value == ".." || strings.ContainsAny(value, `/\`) {
+		return fmt.Errorf("%w: invalid %s %q", ErrInvalidArtifact, kind, value)
+	}
+
+	return nil
+}
+
+// writeFile writes the content of the reader to the given file. Parent directories are created if they don't exist.
+func writeFile(path string, reader io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, reader); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalServiceV4_UploadAndDownload(t *testing.T) {
+	service := NewLocalServiceV4(t.TempDir())
+
+	runID, name := "testRunID", "test-artifact"
+
+	artifact, err := service.CreateArtifact(runID, "testJobRunID", name)
+	if err != nil {
+		t.Fatalf("Failed to create artifact: %v", err)
+	}
+
+	// blocks are committed in the given order, not the staging order
+	if err := service.StageBlock(runID, name, "YmxvY2st", strings.NewReader(" world")); err != nil {
+		t.Fatalf("Failed to stage block: %v", err)
+	}
+
+	if err := service.StageBlock(runID, name, "YmxvY2sx", strings.NewReader("hello")); err != nil {
+		t.Fatalf("Failed to stage block: %v", err)
+	}
+
+	if err := service.CommitBlocks(runID, name, []string{"YmxvY2sx", "YmxvY2st"}); err != nil {
+		t.Fatalf("Failed to commit blocks: %v", err)
+	}
+
+	// pending artifacts are not visible
+	if _, err := service.GetArtifact(runID, name); !errors.Is(err, ErrArtifactNotFound) {
+		t.Errorf("Expected error %v for pending artifact, but got %v", ErrArtifactNotFound, err)
+	}
+
+	hash := sha256.Sum256([]byte("hello world"))
+	digest := "sha256:" + hex.EncodeToString(hash[:])
+
+	finalized, err := service.FinalizeArtifact(runID, name, 11, digest)
+	if err != nil {
+		t.Fatalf("Failed to finalize artifact: %v", err)
+	}
+
+	if finalized.ID != artifact.ID || finalized.Size != 11 || finalized.Digest != digest {
+		t.Errorf("Unexpected finalized artifact %+v", finalized)
+	}
+
+	reader, err := service.OpenArtifact(runID, name)
+	if err != nil {
+		t.Fatalf("Failed to open artifact: %v", err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read artifact: %v", err)
+	}
+
+	if string(content) != "hello world" {
+		t.Errorf("Expected content %q, but got %q", "hello world", string(content))
+	}
+
+	// finalized artifacts are immutable
+	if _, err := service.CreateArtifact(runID, "testJobRunID", name); !errors.Is(err, ErrArtifactExists) {
+		t.Errorf("Expected error %v, but got %v", ErrArtifactExists, err)
+	}
+
+	if err := service.PutBlob(runID, name, strings.NewReader("overwritten")); !errors.Is(err, ErrArtifactExists) {
+		t.Errorf("Expected error %v, but got %v", ErrArtifactExists, err)
+	}
+
+	// overwrite deletes the artifact before creating it again
+	if _, err := service.DeleteArtifact(runID, name); err != nil {
+		t.Fatalf("Failed to delete artifact: %v", err)
+	}
+
+	recreated, err := service.CreateArtifact(runID, "testJobRunID", name)
+	if err != nil {
+		t.Fatalf("Failed to create artifact: %v", err)
+	}
+
+	if recreated.ID == artifact.ID {
+		t.Errorf("Expected a new artifact id, but got %d", recreated.ID)
+	}
+}
+
+func TestLocalServiceV4_FinalizeArtifactVerifiesBlob(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int64
+		digest string
+	}{
+		{name: "size mismatch", size: 5},
+		{name: "digest mismatch", digest: "sha256:0000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewLocalServiceV4(t.TempDir())
+
+			if _, err := service.CreateArtifact("testRunID", "testJobRunID", "test"); err != nil {
+				t.Fatalf("Failed to create artifact: %v", err)
+			}
+
+			if err := service.PutBlob("testRunID", "test", strings.NewReader("test content")); err != nil {
+				t.Fatalf("Failed to put blob: %v", err)
+			}
+
+			if _, err := service.FinalizeArtifact("testRunID", "test", tt.size, tt.digest); !errors.Is(err, ErrInvalidArtifact) {
+				t.Errorf("Expected error %v, but got %v", ErrInvalidArtifact, err)
+			}
+		})
+	}
+}
+
+func TestLocalServiceV4_ListArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	service := NewLocalServiceV4(dir)
+
+	ids := make(map[string]int64)
+
+	for _, name := range []string{"foo", "bar", "pending"} {
+		artifact, err := service.CreateArtifact("testRunID", "testJobRunID", name)
+		if err != nil {
+			t.Fatalf("Failed to create artifact: %v", err)
+		}
+
+		ids[name] = artifact.ID
+
+		if name == "pending" {
+			continue
+		}
+
+		if err := service.PutBlob("testRunID", name, strings.NewReader(name)); err != nil {
+			t.Fatalf("Failed to put blob: %v", err)
+		}
+
+		if _, err := service.FinalizeArtifact("testRunID", name, 0, ""); err != nil {
+			t.Fatalf("Failed to finalize artifact: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		nameFilter string
+		idFilter   int64
+		want       []string
+	}{
+		{name: "all", want: []string{"foo", "bar"}},
+		{name: "name filter", nameFilter: "bar", want: []string{"bar"}},
+		{name: "id filter", idFilter: ids["foo"], want: []string{"foo"}},
+		{name: "pending", nameFilter: "pending", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			artifacts, err := service.ListArtifacts("testRunID", tt.nameFilter, tt.idFilter)
+			if err != nil {
+				t.Fatalf("Failed to list artifacts: %v", err)
+			}
+
+			if len(artifacts) != len(tt.want) {
+				t.Fatalf("Expected %d artifacts, but got %d", len(tt.want), len(artifacts))
+			}
+
+			for idx, artifact := range artifacts {
+				if artifact.Name != tt.want[idx] {
+					t.Errorf("Expected artifact %s, but got %s", tt.want[idx], artifact.Name)
+				}
+			}
+		})
+	}
+
+	// v4 artifacts are not listed as v3 artifacts
+	_, v3, err := NewLocalService(dir).ListArtifacts("testRunID")
+	if err != nil {
+		t.Fatalf("Failed to list v3 artifacts: %v", err)
+	}
+
+	if len(v3) != 0 {
+		t.Errorf("Expected no v3 artifacts, but got %v", v3)
+	}
+}
+
+func TestLocalServiceV4_InvalidName(t *testing.T) {
+	service := NewLocalServiceV4(t.TempDir())
+
+	for _, name := range []string{"", "..", "foo/bar"} {
+		if _, err := service.CreateArtifact("testRunID", "testJobRunID", name); !errors.Is(err, ErrInvalidArtifact) {
+			t.Errorf("Expected error %v for name %q, but got %v", ErrInvalidArtifact, name, err)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aweris/gale/common/model"
+)
+
+const (
+	// sarifVersion is the version of the SARIF format used for the annotations.
+	sarifVersion = "2.1.0"
+
+	// sarifSchema is the JSON schema of the SARIF format used for the annotations.
+	sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// workflowAnnotation is an annotation reported by a step of a job in the workflow run.
+type workflowAnnotation struct {
+	Job string `json:"job"`
+	model.Annotation
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri"`
+}
+
+type sarifResult struct {
+	RuleID     string          `json:"ruleId,omitempty"`
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Locations  []sarifLocation `json:"locations,omitempty"`
+	Properties sarifProperties `json:"properties"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifProperties struct {
+	Job  string `json:"job"`
+	Step string `json:"step"`
+}
+
+// Returns the annotations reported by the steps of the workflow run using error, warning and notice workflow commands.
+func (wr *WorkflowRun) Annotations(
+	// Context to use for the operation
+	ctx context.Context,
+	// Format of the annotations. Possible values are json and sarif.
+	// +optional=true
+	// +default=json
+	format string,
+) (*File, error) {
+	annotations := make([]workflowAnnotation, 0)
+
+	for _, jr := range wr.JobRuns {
+		reports, err := jr.reports(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, report := range reports {
+			for _, annotation := range report.Annotations {
+				annotations = append(annotations, workflowAnnotation{Job: jr.Job.JobID, Annotation: annotation})
+			}
+		}
+	}
+
+	var (
+		data []byte
+		name string
+		err  error
+	)
+
+	switch format {
+	case "json":
+		name = "annotations.json"
+		data, err = json.MarshalIndent(annotations, "", "  ")
+	case "sarif":
+		name = "annotations.sarif"
+		data, err = json.MarshalIndent(newSarifLog(annotations), "", "  ")
+	default:
+		return nil, fmt.Errorf("unsupported annotations format %s, possible values are json and sarif", format)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal annotations: %w", err)
+	}
+
+	return dag.Directory().WithNewFile(name, string(data)).File(name), nil
+}
+
+// newSarifLog converts the given annotations to a SARIF log with a single run.
+func newSarifLog(annotations []workflowAnnotation) sarifLog {
+	results := make([]sarifResult, 0, len(annotations))
+
+	for _, annotation := range annotations {
+		result := sarifResult{
+			RuleID:     annotation.Title,
+			Level:      sarifLevel(annotation.Severity),
+			Message:    sarifMessage{Text: annotation.Message},
+			Properties: sarifProperties{Job: annotation.Job, Step: annotation.Step},
+		}
+
+		if annotation.File != "" {
+			location := sarifLocation{
+				PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: annotation.File}},
+			}
+
+			// SARIF requires startLine in a region, so region is only added when the line is known
+			if annotation.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{
+					StartLine:   annotation.Line,
+					StartColumn: annotation.Col,
+					EndLine:     annotation.EndLine,
+					EndColumn:   annotation.EndColumn,
+				}
+			}
+
+			result.Locations = append(result.Locations, location)
+		}
+
+		results = append(results, result)
+	}
+
+	return sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{
+			{
+				Tool:    sarifTool{Driver: sarifDriver{Name: "gale", InformationURI: "https://github.com/aweris/gale"}},
+				Results: results,
+			},
+		},
+	}
+}
+
+// sarifLevel converts the annotation severity to the SARIF result level.
+func sarifLevel(severity model.AnnotationSeverity) string {
+	switch severity {
+	case model.AnnotationSeverityError:
+		return "error"
+	case model.AnnotationSeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
@@ -0,0 +1,237 @@
+package fs
+
+import (
+	"errors"
+	iofs "io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Glob returns the paths matching the given patterns, same as @actions/glob does. Relative patterns are resolved from
+// the given directory, `~` is expanded to the home directory, `**` matches any number of directories and patterns
+// starting with `!` exclude the matching paths and their descendants. Empty patterns and patterns starting with `#` are
+// ignored. Matched directories are not expanded.
+func Glob(dir string, patterns []string) ([]string, error) {
+	includes, excludes := parseGlobPatterns(dir, patterns)
+
+	var (
+		paths []string
+		seen  = make(map[string]bool)
+	)
+
+	for _, pattern := range includes {
+		err := walkGlob(pattern, func(p string, _ iofs.DirEntry) error {
+			if !seen[p] && !isGlobExcluded(p, excludes) {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return paths, nil
+}
+
+// GlobFiles returns the files matching the given patterns. Unlike Glob, matched directories are expanded to the files
+// they contain and exclude patterns are applied to the expanded files as well.
+func GlobFiles(dir string, patterns []string) ([]string, error) {
+	includes, excludes := parseGlobPatterns(dir, patterns)
+
+	var (
+		files []string
+		seen  = make(map[string]bool)
+	)
+
+	for _, pattern := range includes {
+		err := walkGlob(pattern, func(p string, d iofs.DirEntry) error {
+			err := filepath.WalkDir(p, func(file string, entry iofs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+
+				if entry.IsDir() || seen[file] || isGlobExcluded(file, excludes) {
+					return nil
+				}
+
+				seen[file] = true
+				files = append(files, file)
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			// descendants of a matched directory are already added, no need to match them again
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// GlobBase returns the longest leading directory of the pattern, resolved from the given directory, without any glob
+// characters. If the pattern has no glob characters, the resolved pattern itself is returned.
+func GlobBase(dir, pattern string) string {
+	pattern = resolveGlobPattern(dir, pattern)
+
+	segments := strings.Split(pattern, string(filepath.Separator))
+
+	for idx, segment := range segments {
+		if strings.ContainsAny(segment, "*?[") {
+			base := strings.Join(segments[:idx], string(filepath.Separator))
+			if base == "" {
+				base = string(filepath.Separator)
+			}
+
+			return base
+		}
+	}
+
+	return pattern
+}
+
+// parseGlobPatterns splits the given patterns into resolved include and exclude patterns.
+func parseGlobPatterns(dir string, patterns []string) (includes []string, excludes []string) {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+
+		if exclude, ok := strings.CutPrefix(pattern, "!"); ok {
+			excludes = append(excludes, resolveGlobPattern(dir, exclude))
+			continue
+		}
+
+		includes = append(includes, resolveGlobPattern(dir, pattern))
+	}
+
+	return includes, excludes
+}
+
+// walkGlob calls fn for each existing path matching the given resolved pattern. If fn returns filepath.SkipDir for a
+// directory, descendants of the directory are not matched.
+func walkGlob(pattern string, fn func(p string, d iofs.DirEntry) error) error {
+	base := GlobBase("", pattern)
+
+	// literal paths are matched only if they exist
+	if base == pattern {
+		info, err := os.Lstat(pattern)
+		if err != nil {
+			if errors.Is(err, iofs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		if err := fn(pattern, iofs.FileInfoToDirEntry(info)); err != nil && !errors.Is(err, filepath.SkipDir) {
+			return err
+		}
+
+		return nil
+	}
+
+	return filepath.WalkDir(base, func(p string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, iofs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		if !globMatch(pattern, p) {
+			return nil
+		}
+
+		return fn(p, d)
+	})
+}
+
+// resolveGlobPattern returns the absolute and cleaned version of the given pattern.
+func resolveGlobPattern(dir, pattern string) string {
+	if pattern == "~" || strings.HasPrefix(pattern, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			pattern = home + strings.TrimPrefix(pattern, "~")
+		}
+	}
+
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+
+	return filepath.Clean(pattern)
+}
+
+// globMatch reports whether the given path matches the pattern. Both must be absolute and cleaned.
+func globMatch(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, string(filepath.Separator)), strings.Split(name, string(filepath.Separator)))
+}
+
+// MatchPath reports whether the slash separated name matches the pattern. Same as the glob patterns of the files, `*`
+// doesn't match `/` separators and `**` matches zero or more path segments.
+func MatchPath(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchSegments matches the path segments against the pattern segments. `**` matches zero or more segments.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for idx := 0; idx <= len(name); idx++ {
+				if matchSegments(pattern[1:], name[idx:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// isGlobExcluded reports whether the given path or any of its parent directories matches one of the exclude patterns.
+func isGlobExcluded(p string, excludes []string) bool {
+	if len(excludes) == 0 {
+		return false
+	}
+
+	for current := p; ; current = filepath.Dir(current) {
+		for _, exclude := range excludes {
+			if globMatch(exclude, current) {
+				return true
+			}
+		}
+
+		if parent := filepath.Dir(current); parent == current {
+			return false
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package fs_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aweris/gale/common/fs"
+)
+
+func TestGlob(t *testing.T) {
+	dir := createGlobTestDir(t)
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{name: "literal file", patterns: []string{"a.txt"}, want: []string{"a.txt"}},
+		{name: "literal directory", patterns: []string{"sub"}, want: []string{"sub"}},
+		{name: "missing literal", patterns: []string{"missing.txt"}, want: nil},
+		{name: "wildcard", patterns: []string{"*.txt"}, want: []string{"a.txt"}},
+		{name: "recursive wildcard", patterns: []string{"**/*.txt"}, want: []string{"a.txt", "skip/e.txt", "sub/c.txt", "sub/deep/d.txt"}},
+		{name: "exclude", patterns: []string{"**/*.txt", "!skip", "!**/deep/*"}, want: []string{"a.txt", "sub/c.txt"}},
+		{name: "no duplicates", patterns: []string{"a.txt", "*.txt"}, want: []string{"a.txt"}},
+		{name: "comments and empty lines", patterns: []string{"# comment", "", "  a.txt  "}, want: []string{"a.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths, err := fs.Glob(dir, tt.patterns)
+			if err != nil {
+				t.Fatalf("Glob() error = %v", err)
+			}
+
+			if got := relPaths(t, dir, paths); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Glob() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "a/*", name: "a/b", want: true},
+		{pattern: "a/*", name: "a/b/c", want: false},
+		{pattern: "a/**", name: "a/b/c", want: true},
+		{pattern: "a/**/c", name: "a/c", want: true},
+		{pattern: "*/b-*/**", name: "a/b-c/d/e", want: true},
+		{pattern: "*/b-*/**", name: "a/c/d", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := fs.MatchPath(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchPath(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGlobFiles(t *testing.T) {
+	dir := createGlobTestDir(t)
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{name: "directory is expanded", patterns: []string{"sub"}, want: []string{"sub/c.txt", "sub/deep/d.txt"}},
+		{name: "recursive directory", patterns: []string{"sub/**"}, want: []string{"sub/c.txt", "sub/deep/d.txt"}},
+		{name: "exclude from expanded directory", patterns: []string{"sub", "!sub/deep/*.txt"}, want: []string{"sub/c.txt"}},
+		{name: "files only", patterns: []string{"*"}, want: []string{"a.txt", "b.log", "skip/e.txt", "sub/c.txt", "sub/deep/d.txt"}},
+		{name: "nothing matches", patterns: []string{"**/*.md"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := fs.GlobFiles(dir, tt.patterns)
+			if err != nil {
+				t.Fatalf("GlobFiles() error = %v", err)
+			}
+
+			if got := relPaths(t, dir, files); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GlobFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func createGlobTestDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for _, file := range []string{"a.txt", "b.log", "sub/c.txt", "sub/deep/d.txt", "skip/e.txt"} {
+		path := filepath.Join(dir, file)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(file), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func relPaths(t *testing.T, dir string, paths []string) []string {
+	t.Helper()
+
+	var rels []string
+
+	for _, p := range paths {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rels = append(rels, rel)
+	}
+
+	return rels
+}
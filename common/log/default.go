@@ -1,5 +1,7 @@
 package log
 
+import "io"
+
 // logger is the default global logger.
 var logger = NewLogger()
 
@@ -13,6 +15,16 @@ func EndGroup() {
 	logger.EndGroup()
 }
 
+// AddWriter adds the given writer to the default logger.
+func AddWriter(w io.Writer) {
+	logger.AddWriter(w)
+}
+
+// RemoveWriter removes the given writer from the default logger.
+func RemoveWriter(w io.Writer) {
+	logger.RemoveWriter(w)
+}
+
 // Info logs an info message in the default logger.
 func Info(message string) {
 	logger.Info(message)
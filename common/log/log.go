@@ -2,6 +2,7 @@ package log
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 )
@@ -18,7 +19,8 @@ const (
 )
 
 type Logger struct {
-	groups []string
+	groups  []string
+	writers []io.Writer // writers are the additional writers to write the log lines to, besides stdout
 }
 
 func NewLogger() *Logger {
@@ -38,6 +40,22 @@ func (l *Logger) EndGroup() {
 	l.log(groupEnd, "", "")
 }
 
+// AddWriter adds the given writer to the logger. All log lines are written to the writer as well as stdout until the
+// writer is removed.
+func (l *Logger) AddWriter(w io.Writer) {
+	l.writers = append(l.writers, w)
+}
+
+// RemoveWriter removes the given writer from the logger.
+func (l *Logger) RemoveWriter(w io.Writer) {
+	for i, writer := range l.writers {
+		if writer == w {
+			l.writers = append(l.writers[:i], l.writers[i+1:]...)
+			return
+		}
+	}
+}
+
 func (l *Logger) Info(message string) {
 	l.log("", "", message)
 }
@@ -118,7 +136,14 @@ func (l *Logger) log(prefix, level, message string) {
 
 	sb.WriteString(message)
 
-	fmt.Println(sb.String())
+	line := sb.String()
+
+	fmt.Println(line)
+
+	for _, w := range l.writers {
+		// ignoring error since failing to write additional writers should not affect the main log
+		fmt.Fprintln(w, line)
+	}
 }
 
 // wrapWithQuotesAndEscape wraps value in with `"` if given value is string and not quoted already.
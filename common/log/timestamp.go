@@ -0,0 +1,49 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"time"
+)
+
+// TimestampLayout is the layout of the timestamps prefixed to the lines by TimestampWriter. It's the same RFC3339
+// layout GitHub uses in the raw logs.
+const TimestampLayout = "2006-01-02T15:04:05.0000000Z"
+
+// TimestampWriter is a writer that prefixes every line with the UTC timestamp of the time the line is written.
+type TimestampWriter struct {
+	w           io.Writer
+	now         func() time.Time
+	atLineStart bool
+}
+
+// NewTimestampWriter creates a new TimestampWriter that writes to the given writer.
+func NewTimestampWriter(w io.Writer) *TimestampWriter {
+	return &TimestampWriter{w: w, now: time.Now, atLineStart: true}
+}
+
+// Write writes the given bytes to the underlying writer by prefixing every line with a timestamp.
+func (t *TimestampWriter) Write(p []byte) (int, error) {
+	var buf bytes.Buffer
+
+	for _, b := range p {
+		if t.atLineStart {
+			buf.WriteString(t.now().UTC().Format(TimestampLayout))
+			buf.WriteByte(' ')
+
+			t.atLineStart = false
+		}
+
+		buf.WriteByte(b)
+
+		if b == '\n' {
+			t.atLineStart = true
+		}
+	}
+
+	if _, err := t.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
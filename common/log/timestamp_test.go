@@ -0,0 +1,26 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimestampWriter_Write(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := NewTimestampWriter(&buf)
+	w.now = func() time.Time { return time.Date(2023, 11, 8, 12, 15, 19, 19065000, time.UTC) }
+
+	_, err := w.Write([]byte("first line\nsecond "))
+	assert.NoError(t, err)
+
+	_, err = w.Write([]byte("line\n"))
+	assert.NoError(t, err)
+
+	expected := "2023-11-08T12:15:19.0190650Z first line\n2023-11-08T12:15:19.0190650Z second line\n"
+
+	assert.Equal(t, expected, buf.String())
+}
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/aweris/gale/common/fs"
+)
+
+// ActionOverrides is the list of rules to substitute actions before they are loaded.
+type ActionOverrides struct {
+	Overrides []ActionOverride `yaml:"overrides"` // Overrides is the list of override rules. First matching rule is used.
+}
+
+// ActionOverride is a rule to substitute the actions matching the pattern. Exactly one of Redirect, Noop or Run must be
+// set.
+type ActionOverride struct {
+	Uses     string            `yaml:"uses"`     // Uses is the glob pattern to match the action or docker references. e.g. actions/checkout@*
+	Redirect string            `yaml:"redirect"` // Redirect is the action reference, local path or docker image to load instead.
+	Noop     bool              `yaml:"noop"`     // Noop replaces the action with a no-op returning the configured outputs.
+	Run      string            `yaml:"run"`      // Run replaces the action with the given stub script.
+	Shell    string            `yaml:"shell"`    // Shell is the shell to run the stub script. Defaults to bash.
+	Outputs  map[string]string `yaml:"outputs"`  // Outputs is the outputs of the substituted action. Values could be expressions.
+}
+
+// Validate validates the override rules.
+func (o ActionOverrides) Validate() error {
+	for idx, rule := range o.Overrides {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("invalid override rule #%d: %w", idx, err)
+		}
+	}
+
+	return nil
+}
+
+// Match returns the first rule matching the given action reference. Names of the actions are matched by path
+// segments, `*` doesn't match `/` separators and `**` matches zero or more segments, e.g. my-org/deploy-*/**@* matches
+// the actions in the subdirectories of the deploy repositories as well. Refs are matched as a whole, so `*` matches
+// refs containing `/` too, e.g. release/v1.
+func (o ActionOverrides) Match(uses string) (*ActionOverride, bool) {
+	for idx := range o.Overrides {
+		if matchUses(o.Overrides[idx].Uses, uses) {
+			return &o.Overrides[idx], true
+		}
+	}
+
+	return nil, false
+}
+
+// Unmatched returns the rules not matching any of the given action references.
+func (o ActionOverrides) Unmatched(uses []string) []ActionOverride {
+	var unmatched []ActionOverride
+
+	for _, rule := range o.Overrides {
+		matched := false
+
+		for _, u := range uses {
+			if matchUses(rule.Uses, u) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			unmatched = append(unmatched, rule)
+		}
+	}
+
+	return unmatched
+}
+
+// matchUses reports whether the action reference matches the pattern. References without a ref, e.g. docker images
+// and local actions, are matched by path segments as a whole.
+func matchUses(pattern, uses string) bool {
+	patternName, patternRef, patternHasRef := strings.Cut(pattern, "@")
+	name, ref, hasRef := strings.Cut(uses, "@")
+
+	if !patternHasRef || !hasRef {
+		return fs.MatchPath(pattern, uses)
+	}
+
+	if !fs.MatchPath(patternName, name) {
+		return false
+	}
+
+	// `/` is the only character `*` doesn't match, replacing it in both lets `*` match the whole ref. Ignoring the
+	// error since patterns are validated when the rules are loaded.
+	ok, _ := path.Match(strings.ReplaceAll(patternRef, "/", "\x00"), strings.ReplaceAll(ref, "/", "\x00"))
+
+	return ok
+}
+
+// Validate validates the override rule.
+func (r ActionOverride) Validate() error {
+	if r.Uses == "" {
+		return errors.New("uses pattern is required")
+	}
+
+	if _, err := path.Match(r.Uses, ""); err != nil {
+		return fmt.Errorf("invalid uses pattern %q: %w", r.Uses, err)
+	}
+
+	count := 0
+
+	for _, set := range []bool{r.Redirect != "", r.Noop, r.Run != ""} {
+		if set {
+			count++
+		}
+	}
+
+	if count != 1 {
+		return fmt.Errorf("exactly one of redirect, noop or run must be set for %q", r.Uses)
+	}
+
+	// docker steps can be redirected to actions, but actions can't be redirected to docker images since the inputs of
+	// the action can't be passed to the image
+	if strings.HasPrefix(r.Redirect, "docker://") && !strings.HasPrefix(r.Uses, "docker://") {
+		return fmt.Errorf("actions matching %q can't be redirected to a docker image", r.Uses)
+	}
+
+	return nil
+}
+
+// String returns a short description of the substitution.
+func (r ActionOverride) String() string {
+	switch {
+	case r.Redirect != "":
+		return fmt.Sprintf("redirect to %s", r.Redirect)
+	case r.Noop:
+		return "no-op"
+	default:
+		return "stub run script"
+	}
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionOverrides_Match(t *testing.T) {
+	overrides := ActionOverrides{
+		Overrides: []ActionOverride{
+			{Uses: "actions/checkout@*", Noop: true},
+			{Uses: "my-org/deploy-*@v1", Run: "echo skip"},
+			{Uses: "actions/*@v3", Redirect: "./local/action"},
+			{Uses: "*/release-*/**@*", Noop: true},
+			{Uses: "docker://**", Run: "echo skip"},
+		},
+	}
+
+	tests := []struct {
+		uses    string
+		want    int
+		matched bool
+	}{
+		{uses: "actions/checkout@v4", want: 0, matched: true},
+		{uses: "my-org/deploy-prod@v1", want: 1, matched: true},
+		{uses: "my-org/deploy-prod@v2", matched: false},
+		{uses: "actions/checkout@v3", want: 0, matched: true},
+		{uses: "actions/setup-go@v3", want: 2, matched: true},
+		{uses: "actions/setup-go/sub@v3", matched: false},
+		{uses: "my-org/deploy-prod@release/v1", matched: false},
+		{uses: "my-org/release-tool@release/v1", want: 3, matched: true},
+		{uses: "my-org/release-tool/sub/dir@v1", want: 3, matched: true},
+		{uses: "docker://ghcr.io/my-org/deploy:v1", want: 4, matched: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uses, func(t *testing.T) {
+			rule, ok := overrides.Match(tt.uses)
+
+			assert.Equal(t, tt.matched, ok)
+
+			if tt.matched {
+				assert.Equal(t, &overrides.Overrides[tt.want], rule)
+			}
+		})
+	}
+}
+
+func TestActionOverrides_Unmatched(t *testing.T) {
+	overrides := ActionOverrides{
+		Overrides: []ActionOverride{
+			{Uses: "actions/checkout@*", Noop: true},
+			{Uses: "my-org/deploy-*@*", Run: "echo skip"},
+		},
+	}
+
+	unmatched := overrides.Unmatched([]string{"actions/checkout@v4", "my-org/deploy-prod/sub@v1"})
+
+	assert.Equal(t, []ActionOverride{overrides.Overrides[1]}, unmatched)
+}
+
+func TestActionOverride_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    ActionOverride
+		wantErr bool
+	}{
+		{name: "redirect", rule: ActionOverride{Uses: "a/b@*", Redirect: "a/b@v2"}},
+		{name: "noop", rule: ActionOverride{Uses: "a/b@*", Noop: true}},
+		{name: "run", rule: ActionOverride{Uses: "a/b@*", Run: "echo hi"}},
+		{name: "missing uses", rule: ActionOverride{Noop: true}, wantErr: true},
+		{name: "invalid pattern", rule: ActionOverride{Uses: "a/[b@*", Noop: true}, wantErr: true},
+		{name: "no substitution", rule: ActionOverride{Uses: "a/b@*"}, wantErr: true},
+		{name: "multiple substitutions", rule: ActionOverride{Uses: "a/b@*", Noop: true, Run: "echo hi"}, wantErr: true},
+		{name: "docker redirect", rule: ActionOverride{Uses: "docker://alpine:*", Redirect: "docker://busybox:latest"}},
+		{name: "docker to action", rule: ActionOverride{Uses: "docker://alpine:*", Redirect: "a/b@v1"}},
+		{name: "action to docker", rule: ActionOverride{Uses: "a/b@*", Redirect: "docker://alpine:3"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+
+			assert.Equal(t, tt.wantErr, err != nil, "error: %v", err)
+		})
+	}
+}
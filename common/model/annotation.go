@@ -0,0 +1,17 @@
+package model
+
+// Annotation represents a message reported by a step using error, warning and notice workflow commands, optionally
+// associated with a location in a source file.
+//
+// See: https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#setting-an-error-message
+type Annotation struct {
+	Severity  AnnotationSeverity `json:"severity"`            // Severity is the severity of the annotation. Possible values are: error, warning, notice.
+	Message   string             `json:"message"`             // Message is the message of the annotation.
+	Title     string             `json:"title,omitempty"`     // Title is the custom title of the annotation.
+	File      string             `json:"file,omitempty"`      // File is the path of the file relative to the workspace.
+	Line      int                `json:"line,omitempty"`      // Line is the start line of the annotation, starting at 1.
+	EndLine   int                `json:"endLine,omitempty"`   // EndLine is the end line of the annotation.
+	Col       int                `json:"col,omitempty"`       // Col is the start column of the annotation, starting at 1.
+	EndColumn int                `json:"endColumn,omitempty"` // EndColumn is the end column of the annotation.
+	Step      string             `json:"step,omitempty"`      // Step is the id of the step reported the annotation. Only set in job reports.
+}
@@ -6,8 +6,9 @@ import (
 )
 
 type CustomAction struct {
-	Path string           // Path to the custom action
-	Meta CustomActionMeta // Meta information about the custom action
+	Path     string           // Path to the custom action
+	Meta     CustomActionMeta // Meta information about the custom action
+	Override string           // Override is the description of the override substituting the action, if any
 }
 
 // CustomActionMeta represents a metadata for a GitHub Action. It contains all the information needed to run the action.
@@ -112,7 +113,7 @@ func (c *CustomActionRuns) PreCondition() (bool, string) {
 	switch c.Using {
 	case ActionRunsUsingDocker:
 		pre = c.PreEntrypoint
-	case ActionRunsUsingNode16, ActionRunsUsingNode12:
+	case ActionRunsUsingNode20, ActionRunsUsingNode16, ActionRunsUsingNode12:
 		pre = c.Pre
 	default:
 		pre = "" // all other types of actions do not have a pre-condition
@@ -134,7 +135,7 @@ func (c *CustomActionRuns) PostCondition() (bool, string) {
 	switch c.Using {
 	case ActionRunsUsingDocker:
 		post = c.PostEntrypoint
-	case ActionRunsUsingNode16, ActionRunsUsingNode12:
+	case ActionRunsUsingNode20, ActionRunsUsingNode16, ActionRunsUsingNode12:
 		post = c.Post
 	default:
 		post = "" // all other types of actions do not have a post-condition
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomActionRuns_PreCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		runs      CustomActionRuns
+		wantRun   bool
+		wantCheck string
+	}{
+		{name: "node12", runs: CustomActionRuns{Using: ActionRunsUsingNode12, Pre: "pre.js", PreIf: "always()"}, wantRun: true, wantCheck: "always()"},
+		{name: "node16", runs: CustomActionRuns{Using: ActionRunsUsingNode16, Pre: "pre.js"}, wantRun: true},
+		{name: "node20", runs: CustomActionRuns{Using: ActionRunsUsingNode20, Pre: "pre.js", PreIf: "success()"}, wantRun: true, wantCheck: "success()"},
+		{name: "docker", runs: CustomActionRuns{Using: ActionRunsUsingDocker, PreEntrypoint: "pre.sh"}, wantRun: true},
+		{name: "composite", runs: CustomActionRuns{Using: ActionRunsUsingComposite, Pre: "pre.js"}, wantRun: false},
+		{name: "no pre", runs: CustomActionRuns{Using: ActionRunsUsingNode20}, wantRun: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, condition := tt.runs.PreCondition()
+
+			assert.Equal(t, tt.wantRun, run)
+			assert.Equal(t, tt.wantCheck, condition)
+		})
+	}
+}
+
+func TestCustomActionRuns_PostCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		runs      CustomActionRuns
+		wantRun   bool
+		wantCheck string
+	}{
+		{name: "node12", runs: CustomActionRuns{Using: ActionRunsUsingNode12, Post: "post.js"}, wantRun: true},
+		{name: "node16", runs: CustomActionRuns{Using: ActionRunsUsingNode16, Post: "post.js", PostIf: "always()"}, wantRun: true, wantCheck: "always()"},
+		{name: "node20", runs: CustomActionRuns{Using: ActionRunsUsingNode20, Post: "post.js", PostIf: "always()"}, wantRun: true, wantCheck: "always()"},
+		{name: "docker", runs: CustomActionRuns{Using: ActionRunsUsingDocker, PostEntrypoint: "post.sh"}, wantRun: true},
+		{name: "composite", runs: CustomActionRuns{Using: ActionRunsUsingComposite, Post: "post.js"}, wantRun: false},
+		{name: "no post", runs: CustomActionRuns{Using: ActionRunsUsingNode20}, wantRun: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, condition := tt.runs.PostCondition()
+
+			assert.Equal(t, tt.wantRun, run)
+			assert.Equal(t, tt.wantCheck, condition)
+		})
+	}
+}
@@ -43,3 +43,12 @@ const (
 	StepStageMain StepStage = "main"
 	StepStagePost StepStage = "post"
 )
+
+// AnnotationSeverity is the severity of the annotation reported by a step.
+type AnnotationSeverity string
+
+const (
+	AnnotationSeverityError   AnnotationSeverity = "error"
+	AnnotationSeverityWarning AnnotationSeverity = "warning"
+	AnnotationSeverityNotice  AnnotationSeverity = "notice"
+)
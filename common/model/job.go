@@ -14,6 +14,7 @@ type Job struct {
 	Env      map[string]string `yaml:"env"`      // Env is the environment variables used in the workflow
 	Outputs  map[string]string `yaml:"outputs"`  // Outputs is the list of outputs of the job
 	Steps    []Step            `yaml:"steps"`    // Steps is the list of steps in the job
+	Uses     string            `yaml:"uses"`     // Uses is the reusable workflow to run as the job
 
 	// TBD: add more fields when needed
 }
@@ -50,10 +51,37 @@ func (n *Needs) UnmarshalYAML(value *yaml.Node) error {
 // multiple job runs that are based on the combinations of the variables.
 type Strategy struct {
 	Matrix      Matrix `yaml:"matrix"`       // Matrix is the matrix of different OS versions and other parameters
-	FailFast    bool   `yaml:"fail-fast"`    // FailFast is a boolean to indicate if the job should fail immediately when a job fails.
+	FailFast    *bool  `yaml:"fail-fast"`    // FailFast is a boolean to indicate if the job should fail immediately when a job fails. Defaults to true.
 	MaxParallel int    `yaml:"max-parallel"` // MaxParallel is the maximum number of jobs to run at a time.
 }
 
+// JobRunStrategy returns the strategy of the job run at the given index of the total job runs created from the
+// strategy. Defaults are applied same as GitHub, fail-fast is true and max-parallel is the total number of job runs
+// if they are not set.
+func (s *Strategy) JobRunStrategy(index, total int) JobRunStrategy {
+	strategy := JobRunStrategy{FailFast: true, JobIndex: index, JobTotal: total, MaxParallel: total}
+
+	if s.FailFast != nil {
+		strategy.FailFast = *s.FailFast
+	}
+
+	if s.MaxParallel > 0 && s.MaxParallel < total {
+		strategy.MaxParallel = s.MaxParallel
+	}
+
+	return strategy
+}
+
+// JobRunStrategy represents the strategy of a single job run created from the job strategy.
+//
+// See: https://docs.github.com/en/actions/learn-github-actions/contexts#strategy-context
+type JobRunStrategy struct {
+	FailFast    bool `json:"fail-fast"`    // FailFast is true if all in-progress jobs are canceled when any job fails.
+	JobIndex    int  `json:"job-index"`    // JobIndex is the zero-based index of the job run in the matrix.
+	JobTotal    int  `json:"job-total"`    // JobTotal is the total number of job runs in the matrix.
+	MaxParallel int  `json:"max-parallel"` // MaxParallel is the maximum number of job runs that can run simultaneously.
+}
+
 // JobRun represents a single job run in a GitHub Actions workflow run
 type JobRun struct {
 	RunID      string            `json:"run_id"`     // RunID is the ID of the run
@@ -62,5 +90,6 @@ type JobRun struct {
 	Outcome    Conclusion        `json:"outcome"`    // Outcome is  the result of a completed job before continue-on-error is applied
 	Outputs    map[string]string `json:"outputs"`    // Outputs is the outputs generated by the job
 	Matrix     MatrixCombination `json:"matrix"`     // Matrix is the matrix parameters used to run the job
+	Strategy   JobRunStrategy    `json:"strategy"`   // Strategy is the strategy of the job run
 	Steps      []StepRun         `json:"steps"`      // Steps is the list of steps in the job
 }
@@ -1,7 +1,9 @@
 package model
 
 import (
+	"bytes"
 	"encoding/json"
+	"sort"
 
 	"gopkg.in/yaml.v3"
 )
@@ -40,6 +42,7 @@ func (mc *MatrixCombination) KeysAndValuesMatch(other MatrixCombination, keys []
 
 // Matrix represents a job matrix in a GitHub Actions workflow
 type Matrix struct {
+	Keys       []string                   `json:"-"` // Keys is the list of dimension keys in the order they're declared in the workflow.
 	Dimensions map[string]MatrixDimension // Dimensions is the list of matrix dimensions given in the workflow.
 	Include    []MatrixCombination        // Include is the list of matrix combinations to update or extend the matrix.
 	Exclude    []MatrixCombination        // Exclude is the list of matrix combinations to remove from the matrix.
@@ -52,11 +55,7 @@ func (m *Matrix) GenerateCombinations() []MatrixCombination {
 		return []MatrixCombination{}
 	}
 
-	keys := make([]string, 0, len(m.Dimensions))
-
-	for k := range m.Dimensions {
-		keys = append(keys, k)
-	}
+	keys := m.dimensionKeys()
 
 	combinations := generateCombinations(keys, m.Dimensions)
 
@@ -71,6 +70,34 @@ func (m *Matrix) GenerateCombinations() []MatrixCombination {
 	return combinations
 }
 
+// dimensionKeys returns the keys of the dimensions in the order they're declared, same as GitHub generates the
+// combinations, so the job index of a combination matches with GitHub. Keys without a known order, e.g. the matrix is
+// not unmarshalled from a workflow, are sorted to keep the job index stable between the runs.
+func (m *Matrix) dimensionKeys() []string {
+	var (
+		keys     = make([]string, 0, len(m.Dimensions))
+		seen     = make(map[string]bool, len(m.Dimensions))
+		unsorted = make([]string, 0)
+	)
+
+	for _, k := range m.Keys {
+		if _, ok := m.Dimensions[k]; ok && !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+
+	for k := range m.Dimensions {
+		if !seen[k] {
+			unsorted = append(unsorted, k)
+		}
+	}
+
+	sort.Strings(unsorted)
+
+	return append(keys, unsorted...)
+}
+
 // generateCombinations generates all possible combinations of given dimensions and keys
 func generateCombinations(keys []string, dimensions map[string]MatrixDimension) []MatrixCombination {
 	// get the first key and its dimension
@@ -197,6 +224,13 @@ func (m *Matrix) UnmarshalYAML(node *yaml.Node) error {
 
 	m.populate(raw)
 
+	// maps don't keep the order of the keys, so the order is read from the mapping node itself
+	if node.Kind == yaml.MappingNode {
+		for idx := 0; idx+1 < len(node.Content); idx += 2 {
+			m.addKey(node.Content[idx].Value)
+		}
+	}
+
 	return nil
 }
 
@@ -209,9 +243,42 @@ func (m *Matrix) UnmarshalJSON(data []byte) error {
 	}
 	m.populate(raw)
 
+	// maps don't keep the order of the keys, so the order is read from the top level tokens of the object
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	// skip the opening delimiter of the object, the data is already validated by unmarshal
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		if key, ok := token.(string); ok {
+			m.addKey(key)
+		}
+
+		// skip the value of the key
+		var value json.RawMessage
+
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// addKey records the given key as the next dimension key if it's a dimension.
+func (m *Matrix) addKey(key string) {
+	if _, ok := m.Dimensions[key]; ok {
+		m.Keys = append(m.Keys, key)
+	}
+}
+
 // populate populates the matrix from given raw data
 func (m *Matrix) populate(raw map[string]interface{}) {
 	for key, value := range raw {
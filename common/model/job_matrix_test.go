@@ -43,6 +43,49 @@ func TestMatrix_GenerateCombinations(t *testing.T) {
 	assert.ElementsMatch(t, expected, m.GenerateCombinations())
 }
 
+func TestMatrix_GenerateCombinations_StableOrder(t *testing.T) {
+	m := &Matrix{
+		Dimensions: map[string]MatrixDimension{
+			"version": {Key: "version", Values: []interface{}{"18.04", "20.04"}},
+			"OS":      {Key: "OS", Values: []interface{}{"ubuntu", "windows"}},
+			"arch":    {Key: "arch", Values: []interface{}{"x64"}},
+		},
+	}
+
+	expected := []MatrixCombination{
+		{"OS": "ubuntu", "arch": "x64", "version": "18.04"},
+		{"OS": "ubuntu", "arch": "x64", "version": "20.04"},
+		{"OS": "windows", "arch": "x64", "version": "18.04"},
+		{"OS": "windows", "arch": "x64", "version": "20.04"},
+	}
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, expected, m.GenerateCombinations())
+	}
+}
+
+func TestMatrix_GenerateCombinations_DeclarationOrder(t *testing.T) {
+	yamlStr := `
+  version: ["18.04", "20.04"]
+  os: [windows, ubuntu]
+`
+
+	var m Matrix
+	if err := yaml.Unmarshal([]byte(yamlStr), &m); err != nil {
+		t.Fatalf("Failed to unmarshal YAML: %v", err)
+	}
+
+	// first declared key changes the slowest, same as GitHub
+	expected := []MatrixCombination{
+		{"version": "18.04", "os": "windows"},
+		{"version": "18.04", "os": "ubuntu"},
+		{"version": "20.04", "os": "windows"},
+		{"version": "20.04", "os": "ubuntu"},
+	}
+
+	assert.Equal(t, expected, m.GenerateCombinations())
+}
+
 func TestMatrix_UnmarshalYAML(t *testing.T) {
 	yamlStr := `
   fruit: [apple, pear]
@@ -66,6 +109,7 @@ func TestMatrix_UnmarshalYAML(t *testing.T) {
 	}
 
 	expected := Matrix{
+		Keys: []string{"fruit", "animal"},
 		Dimensions: map[string]MatrixDimension{
 			"fruit":  {Key: "fruit", Values: []interface{}{"apple", "pear"}},
 			"animal": {Key: "animal", Values: []interface{}{"cat", "dog"}},
@@ -108,6 +152,7 @@ func TestMatrix_UnmarshalJSON(t *testing.T) {
 	}
 
 	expected := Matrix{
+		Keys: []string{"fruit", "animal"},
 		Dimensions: map[string]MatrixDimension{
 			"fruit":  {Key: "fruit", Values: []interface{}{"apple", "pear"}},
 			"animal": {Key: "animal", Values: []interface{}{"cat", "dog"}},
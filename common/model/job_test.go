@@ -0,0 +1,50 @@
+package model
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrategy_JobRunStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		index    int
+		total    int
+		expected JobRunStrategy
+	}{
+		{
+			name:     "defaults",
+			yaml:     `matrix: {os: [ubuntu, macos]}`,
+			index:    1,
+			total:    2,
+			expected: JobRunStrategy{FailFast: true, JobIndex: 1, JobTotal: 2, MaxParallel: 2},
+		},
+		{
+			name:     "fail-fast and max-parallel",
+			yaml:     "fail-fast: false\nmax-parallel: 2\nmatrix: {os: [ubuntu, macos, windows]}",
+			index:    0,
+			total:    3,
+			expected: JobRunStrategy{FailFast: false, JobIndex: 0, JobTotal: 3, MaxParallel: 2},
+		},
+		{
+			name:     "max-parallel greater than total",
+			yaml:     "max-parallel: 5",
+			index:    0,
+			total:    1,
+			expected: JobRunStrategy{FailFast: true, JobIndex: 0, JobTotal: 1, MaxParallel: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var strategy Strategy
+
+			assert.NoError(t, yaml.Unmarshal([]byte(tt.yaml), &strategy))
+			assert.Equal(t, tt.expected, strategy.JobRunStrategy(tt.index, tt.total))
+		})
+	}
+}
@@ -24,22 +24,30 @@ type WorkflowRunReport struct {
 }
 
 type JobRunReport struct {
-	Ran        bool              `json:"ran"`               // Ran indicates if the execution ran
-	Duration   string            `json:"duration"`          // Duration of the execution
-	Name       string            `json:"name"`              // Name is the name of the job
-	RunID      string            `json:"run_id"`            // RunID is the ID of the run
-	Conclusion Conclusion        `json:"conclusion"`        // Conclusion is the result of a completed job after continue-on-error is applied
-	Outcome    Conclusion        `json:"outcome"`           // Outcome is  the result of a completed job before continue-on-error is applied
-	Outputs    map[string]string `json:"outputs,omitempty"` // Outputs is the outputs generated by the job
-	Matrix     MatrixCombination `json:"matrix,omitempty"`  // Matrix is the matrix parameters used to run the job
-	Steps      []StepRunSummary  `json:"steps"`             // Steps is the list of steps in the job
+	Ran         bool              `json:"ran"`                   // Ran indicates if the execution ran
+	Duration    string            `json:"duration"`              // Duration of the execution
+	Name        string            `json:"name"`                  // Name is the name of the job
+	RunID       string            `json:"run_id"`                // RunID is the ID of the run
+	Conclusion  Conclusion        `json:"conclusion"`            // Conclusion is the result of a completed job after continue-on-error is applied
+	Outcome     Conclusion        `json:"outcome"`               // Outcome is  the result of a completed job before continue-on-error is applied
+	Outputs     map[string]string `json:"outputs,omitempty"`     // Outputs is the outputs generated by the job
+	Matrix      MatrixCombination `json:"matrix,omitempty"`      // Matrix is the matrix parameters used to run the job
+	Strategy    JobRunStrategy    `json:"strategy"`              // Strategy is the strategy of the job run
+	Steps       []StepRunSummary  `json:"steps"`                 // Steps is the list of steps in the job
+	Annotations []Annotation      `json:"annotations,omitempty"` // Annotations is the annotations reported by the steps of the job
 }
 
 type StepRunSummary struct {
-	ID         string     `json:"id"`             // ID is the unique identifier of the step.
-	Name       string     `json:"name,omitempty"` // Name is the name of the step
-	Stage      StepStage  `json:"stage"`          // Stage is the stage of the step during the execution of the job. Possible values are: setup, pre, main, post, complete.
-	Conclusion Conclusion `json:"conclusion"`     // Conclusion is the result of a completed job after continue-on-error is applied
+	ID          string       `json:"id"`                     // ID is the unique identifier of the step.
+	Name        string       `json:"name,omitempty"`         // Name is the name of the step
+	Stage       StepStage    `json:"stage"`                  // Stage is the stage of the step during the execution of the job. Possible values are: setup, pre, main, post, complete.
+	Conclusion  Conclusion   `json:"conclusion"`             // Conclusion is the result of a completed job after continue-on-error is applied
+	Outcome     Conclusion   `json:"outcome"`                // Outcome is  the result of a completed job before continue-on-error is applied
+	Duration    string       `json:"duration"`               // Duration of the execution
+	Annotations []Annotation `json:"annotations,omitempty"`  // Annotations is the annotations reported by the step
+	Log         []string     `json:"log,omitempty"`          // Log is the excerpt of the step output. Only available for failed steps
+	NodeVersion string       `json:"node_version,omitempty"` // NodeVersion is the version of the Node.js runtime used to run the step
+	Override    string       `json:"override,omitempty"`     // Override is the description of the override substituting the action of the step
 }
 
 // NewJobRunReport creates a new job run report from the given job run.
@@ -53,47 +61,72 @@ func NewJobRunReport(result *RunResult, jr *JobRun) *JobRunReport {
 		Outcome:    jr.Outcome,
 		Outputs:    jr.Outputs,
 		Matrix:     jr.Matrix,
+		Strategy:   jr.Strategy,
 	}
 
 	for _, step := range jr.Steps {
 		summary := StepRunSummary{
-			ID:         step.Step.ID,
-			Name:       step.Step.Name,
-			Stage:      step.Stage,
-			Conclusion: step.Conclusion,
+			ID:          step.Step.ID,
+			Name:        step.Step.Name,
+			Stage:       step.Stage,
+			Conclusion:  step.Conclusion,
+			Outcome:     step.Outcome,
+			Duration:    step.Duration.String(),
+			Annotations: step.Annotations,
+			NodeVersion: step.NodeVersion,
+			Override:    step.Override,
+		}
+
+		// log excerpt is only useful for failed steps, no need to bloat the report with the logs of successful steps
+		if step.Outcome == ConclusionFailure {
+			summary.Log = step.Log
 		}
 
 		report.Steps = append(report.Steps, summary)
+
+		for _, annotation := range step.Annotations {
+			annotation.Step = step.Step.ID
+
+			report.Annotations = append(report.Annotations, annotation)
+		}
 	}
 
 	return report
 }
 
 type StepRunReport struct {
-	Ran        bool              `json:"ran"`               // Ran indicates if the execution ran
-	Duration   string            `json:"duration"`          // Duration of the execution
-	ID         string            `json:"id"`                // ID is the unique identifier of the step.
-	Name       string            `json:"name,omitempty"`    // Name is the name of the step
-	Conclusion Conclusion        `json:"conclusion"`        // Conclusion is the result of a completed job after continue-on-error is applied
-	Outcome    Conclusion        `json:"outcome"`           // Outcome is  the result of a completed job before continue-on-error is applied
-	Outputs    map[string]string `json:"outputs,omitempty"` // Outputs is the outputs generated by the job
-	State      map[string]string `json:"state,omitempty"`   // State is a map of step state variables.
-	Env        map[string]string `json:"env,omitempty"`     // Env is the extra environment variables set by the step.
-	Path       []string          `json:"path,omitempty"`    // Path is extra PATH items set by the step.
+	Ran          bool              `json:"ran"`                     // Ran indicates if the execution ran
+	Duration     string            `json:"duration"`                // Duration of the execution
+	ID           string            `json:"id"`                      // ID is the unique identifier of the step.
+	Name         string            `json:"name,omitempty"`          // Name is the name of the step
+	Conclusion   Conclusion        `json:"conclusion"`              // Conclusion is the result of a completed job after continue-on-error is applied
+	Outcome      Conclusion        `json:"outcome"`                 // Outcome is  the result of a completed job before continue-on-error is applied
+	Outputs      map[string]string `json:"outputs,omitempty"`       // Outputs is the outputs generated by the job
+	State        map[string]string `json:"state,omitempty"`         // State is a map of step state variables.
+	Env          map[string]string `json:"env,omitempty"`           // Env is the extra environment variables set by the step.
+	EffectiveEnv map[string]string `json:"effective_env,omitempty"` // EffectiveEnv is the environment the step is executed with.
+	Path         []string          `json:"path,omitempty"`          // Path is extra PATH items set by the step.
+	Annotations  []Annotation      `json:"annotations,omitempty"`   // Annotations is the annotations reported by the step.
+	NodeVersion  string            `json:"node_version,omitempty"`  // NodeVersion is the version of the Node.js runtime used to run the step.
+	Override     string            `json:"override,omitempty"`      // Override is the description of the override substituting the action of the step.
 }
 
 // NewStepRunReport creates a new step run report from the given step run.
 func NewStepRunReport(result *RunResult, sr *StepRun) *StepRunReport {
 	return &StepRunReport{
-		Ran:        result.Ran,
-		Duration:   result.Duration.String(),
-		ID:         sr.Step.ID,
-		Name:       sr.Step.Name,
-		Conclusion: result.Conclusion,
-		Outcome:    sr.Outcome,
-		Outputs:    sr.Outputs,
-		State:      sr.State,
-		Env:        sr.Environment,
-		Path:       sr.Path,
+		Ran:          result.Ran,
+		Duration:     result.Duration.String(),
+		ID:           sr.Step.ID,
+		Name:         sr.Step.Name,
+		Conclusion:   result.Conclusion,
+		Outcome:      sr.Outcome,
+		Outputs:      sr.Outputs,
+		State:        sr.State,
+		Env:          sr.Environment,
+		EffectiveEnv: sr.EffectiveEnv,
+		Path:         sr.Path,
+		Annotations:  sr.Annotations,
+		NodeVersion:  sr.NodeVersion,
+		Override:     sr.Override,
 	}
 }
@@ -1,6 +1,9 @@
 package model
 
-import "strings"
+import (
+	"strings"
+	"time"
+)
 
 // Step represents a single task in a job context at GitHub Actions workflow
 //
@@ -41,13 +44,19 @@ func (s *Step) Type() StepType {
 
 // StepRun represents a single job run in a GitHub Actions workflow run
 type StepRun struct {
-	Step        Step              `json:"step"`        // Step is the step to run
-	Stage       StepStage         `json:"stage"`       // Stage is the stage of the step during the execution of the job. Possible values are: setup, pre, main, post, complete.
-	Conclusion  Conclusion        `json:"conclusion"`  // Conclusion is the result of a completed job after continue-on-error is applied
-	Outcome     Conclusion        `json:"outcome"`     // Outcome is  the result of a completed job before continue-on-error is applied
-	Outputs     map[string]string `json:"outputs"`     // Outputs is the outputs generated by the job
-	State       map[string]string `json:"state"`       // State is a map of step state variables.
-	Summary     string            `json:"summary"`     // Summary is the summary of the step.
-	Environment map[string]string `json:"environment"` // Environment is the extra environment variables set by the step.
-	Path        []string          `json:"path"`        // Path is extra PATH items set by the step.
+	Step         Step              `json:"step"`          // Step is the step to run
+	Stage        StepStage         `json:"stage"`         // Stage is the stage of the step during the execution of the job. Possible values are: setup, pre, main, post, complete.
+	Conclusion   Conclusion        `json:"conclusion"`    // Conclusion is the result of a completed job after continue-on-error is applied
+	Outcome      Conclusion        `json:"outcome"`       // Outcome is  the result of a completed job before continue-on-error is applied
+	Outputs      map[string]string `json:"outputs"`       // Outputs is the outputs generated by the job
+	State        map[string]string `json:"state"`         // State is a map of step state variables.
+	Summary      string            `json:"summary"`       // Summary is the summary of the step.
+	Environment  map[string]string `json:"environment"`   // Environment is the extra environment variables set by the step.
+	EffectiveEnv map[string]string `json:"effective_env"` // EffectiveEnv is the environment the step is executed with. Secret values are masked.
+	Path         []string          `json:"path"`          // Path is extra PATH items set by the step.
+	Annotations  []Annotation      `json:"annotations"`   // Annotations is the annotations reported by the step.
+	Log          []string          `json:"log"`           // Log is the last lines of the step output to use as log excerpt.
+	Duration     time.Duration     `json:"duration"`      // Duration is the execution duration of the step.
+	NodeVersion  string            `json:"node_version"`  // NodeVersion is the version of the Node.js runtime used to run the step, if any.
+	Override     string            `json:"override"`      // Override is the description of the override substituting the action of the step, if any.
 }
@@ -78,10 +78,14 @@ type JobRunReport struct {
 }
 
 type StepRunSummary struct {
-	StepID     string           // ID is the unique identifier of the step.
-	Name       string           // Name is the name of the step
-	Stage      model.StepStage  // Stage is the stage of the step during the execution of the job. Possible values are: setup, pre, main, post, complete.
-	Conclusion model.Conclusion // Conclusion is the result of a completed job after continue-on-error is applied
+	StepID      string           // ID is the unique identifier of the step.
+	Name        string           // Name is the name of the step
+	Stage       model.StepStage  // Stage is the stage of the step during the execution of the job. Possible values are: setup, pre, main, post, complete.
+	Conclusion  model.Conclusion // Conclusion is the result of a completed job after continue-on-error is applied
+	Outcome     model.Conclusion // Outcome is  the result of a completed job before continue-on-error is applied
+	Duration    string           // Duration of the execution
+	NodeVersion string           // NodeVersion is the version of the Node.js runtime used to run the step
+	Override    string           // Override is the description of the override substituting the action of the step
 }
 
 // parseJobRunReport converts the report file to JobRunReport struct
@@ -124,9 +128,13 @@ func parseJobRunReport(ctx context.Context, file *File) (*JobRunReport, error) {
 // convertStepRunSummary converts model.StepRunSummary to StepRunSummary
 func convertStepRunSummary(srs model.StepRunSummary) StepRunSummary {
 	return StepRunSummary{
-		StepID:     srs.ID,
-		Name:       srs.Name,
-		Stage:      srs.Stage,
-		Conclusion: srs.Conclusion,
+		StepID:      srs.ID,
+		Name:        srs.Name,
+		Stage:       srs.Stage,
+		Conclusion:  srs.Conclusion,
+		Outcome:     srs.Outcome,
+		Duration:    srs.Duration,
+		NodeVersion: srs.NodeVersion,
+		Override:    srs.Override,
 	}
 }
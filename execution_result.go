@@ -2,9 +2,15 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/aweris/gale/common/model"
 )
 
 type WorkflowRun struct {
@@ -39,6 +45,124 @@ type JobRun struct {
 
 	// the log file for this job run.
 	LogFile *File
+
+	// true if the job run is paused at a failing step. Only available when interactive mode is enabled.
+	Paused bool
+}
+
+// jobRunLeg is a single run of a job. Jobs with a matrix have a leg for each matrix combination, others a single leg.
+type jobRunLeg struct {
+	Data   *Directory         // Data is the directory containing the data of the leg, e.g. the step directories.
+	Report model.JobRunReport // Report is the job run report of the leg.
+}
+
+// legs returns the runs of the job in execution order. Data of each matrix leg is kept under matrix/<job run id>.
+func (jr *JobRun) legs(ctx context.Context) ([]jobRunLeg, error) {
+	entries, err := jr.Data.Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(entries, "matrix") {
+		report, err := readJobRunReport(ctx, jr.Report.File)
+		if err != nil {
+			return nil, err
+		}
+
+		return []jobRunLeg{{Data: jr.Data, Report: report}}, nil
+	}
+
+	dirs, err := jr.Data.Directory("matrix").Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// directories are named after the job run id, which is a sequential number
+	sort.Slice(dirs, func(i, j int) bool {
+		a, _ := strconv.Atoi(strings.TrimSuffix(dirs[i], "/"))
+		b, _ := strconv.Atoi(strings.TrimSuffix(dirs[j], "/"))
+
+		return a < b
+	})
+
+	legs := make([]jobRunLeg, 0, len(dirs))
+
+	for _, dir := range dirs {
+		data := jr.Data.Directory(filepath.Join("matrix", dir))
+
+		report, err := readJobRunReport(ctx, data.File("job_run.json"))
+		if err != nil {
+			return nil, err
+		}
+
+		legs = append(legs, jobRunLeg{Data: data, Report: report})
+	}
+
+	return legs, nil
+}
+
+// getLeg returns the leg of the job run with the given strategy.job-index. If the job has only one leg, then job index
+// is not required.
+func (jr *JobRun) getLeg(ctx context.Context, jobIndex string) (*jobRunLeg, error) {
+	legs, err := jr.legs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if jobIndex == "" {
+		if len(legs) != 1 {
+			return nil, fmt.Errorf("job %s has %d matrix legs, please specify a job index", jr.Job.JobID, len(legs))
+		}
+
+		return &legs[0], nil
+	}
+
+	index, err := strconv.Atoi(jobIndex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid job index %s: %w", jobIndex, err)
+	}
+
+	for idx := range legs {
+		if legs[idx].Report.Strategy.JobIndex == index {
+			return &legs[idx], nil
+		}
+	}
+
+	return nil, fmt.Errorf("matrix leg with job index %d not found in job %s", index, jr.Job.JobID)
+}
+
+// readJobRunReport reads the job run report from the given file.
+func readJobRunReport(ctx context.Context, file *File) (model.JobRunReport, error) {
+	var report model.JobRunReport
+
+	contents, err := file.Contents(ctx)
+	if err != nil {
+		return report, fmt.Errorf("failed to read report file: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(contents), &report); err != nil {
+		return report, fmt.Errorf("failed to unmarshal report file: %w", err)
+	}
+
+	return report, nil
+}
+
+// jobRunName returns the display name of the job run. Matrix legs are named with their matrix values like GitHub,
+// e.g. test (os:linux, version:18).
+func jobRunName(report model.JobRunReport) string {
+	if len(report.Matrix) == 0 {
+		return report.Name
+	}
+
+	values := make([]string, 0, len(report.Matrix))
+
+	for k, v := range report.Matrix {
+		values = append(values, fmt.Sprintf("%s:%v", k, v))
+	}
+
+	sort.Strings(values)
+
+	return fmt.Sprintf("%s (%s)", report.Name, strings.Join(values, ", "))
 }
 
 // Returns all job run logs as a single file.
@@ -68,12 +192,101 @@ func (wr *WorkflowRun) Log(ctx context.Context) (*File, error) {
 	return dag.Directory().WithNewFile("logs", logs.String()).File("logs"), nil
 }
 
+// Returns the log of the given step with a timestamp on every line. If there is only one job in the workflow run, then
+// job id is not required.
+func (wr *WorkflowRun) StepLog(
+	// Context to use for the operation
+	ctx context.Context,
+	// job id of the step. Only required if there is more than one job in the workflow.
+	// +optional=true
+	jobID string,
+	// strategy.job-index of the matrix leg to return the log for. Only required if the job has more than one matrix leg.
+	// +optional=true
+	jobIndex string,
+	// id of the step. If the step doesn't have an id, it's the index of the step in the job.
+	stepID string,
+	// stage of the step to return the log for. Possible values are pre, main and post.
+	// +optional=true
+	// +default=main
+	stage string,
+) (*File, error) {
+	jr, err := wr.getJobRun(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	leg, err := jr.getLeg(ctx, jobIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := leg.Data.Directory("steps").Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// step directories are named as <index>.<id>
+	for _, step := range steps {
+		_, id, _ := strings.Cut(strings.TrimSuffix(step, "/"), ".")
+		if id != stepID {
+			continue
+		}
+
+		dir := leg.Data.Directory(filepath.Join("steps", step))
+
+		files, err := dir.Entries(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		name := fmt.Sprintf("%s.log", stage)
+
+		if !slices.Contains(files, name) {
+			return nil, fmt.Errorf("no %s log found for step %s in %s", stage, stepID, jobRunName(leg.Report))
+		}
+
+		return dir.File(name), nil
+	}
+
+	return nil, fmt.Errorf("step with id %s not found in %s", stepID, jobRunName(leg.Report))
+}
+
 // Returns the container for the given job id. If there is only one job in the workflow run, then job id is not required.
 func (wr *WorkflowRun) Sync(
 	// job id to return the container for. Only required if there is more than one job in the workflow.
 	// +optional=true
 	jobID string,
 ) (*Container, error) {
+	jr, err := wr.getJobRun(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	return jr.Ctr, nil
+}
+
+// Returns a container to open an interactive shell with the exact environment of the failing step of the paused job.
+// After the shell exits, the job can be continued, the step can be retried or the job can be aborted.
+func (wr *WorkflowRun) Shell(
+	// job id to open the shell for. Only required if there is more than one job in the workflow.
+	// +optional=true
+	jobID string,
+) (*Container, error) {
+	jr, err := wr.getJobRun(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !jr.Paused {
+		return nil, fmt.Errorf("job %s is not paused, please run the workflow with interactive-on-failure", jr.Job.JobID)
+	}
+
+	return jr.Ctr.WithEntrypoint([]string{"ghx", "shell"}), nil
+}
+
+// getJobRun returns the job run for the given job id. If there is only one job in the workflow run, then job id is not
+// required.
+func (wr *WorkflowRun) getJobRun(jobID string) (*JobRun, error) {
 	jobCount := len(wr.JobRuns)
 
 	// decide what to do based on the number of jobs in the workflow run
@@ -83,12 +296,12 @@ func (wr *WorkflowRun) Sync(
 		return nil, fmt.Errorf("no job runs found")
 	}
 
-	// if there is only one job in the workflow run, return the container for that job. No need to specify job id.
+	// if there is only one job in the workflow run, return that job run. No need to specify job id.
 	if jobCount == 1 {
-		return wr.JobRuns[0].Ctr, nil
+		return wr.JobRuns[0], nil
 	}
 
-	// if there is more than one job in the workflow run, job id is required to pick the right container
+	// if there is more than one job in the workflow run, job id is required to pick the right job run
 	if jobID == "" {
 		return nil, fmt.Errorf("there are %v job runs in this workflow, please specify a job id", len(wr.JobRuns))
 	}
@@ -96,7 +309,7 @@ func (wr *WorkflowRun) Sync(
 	// since map type is not supported yet, we have to iterate over the job runs to find the right one
 	for _, jr := range wr.JobRuns {
 		if jr.Job.JobID == jobID {
-			return jr.Ctr, nil
+			return jr, nil
 		}
 	}
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/aweris/gale/common/log"
 	"github.com/aweris/gale/common/model"
 )
 
@@ -39,7 +40,7 @@ func (we *WorkflowExecutor) Execute(ctx context.Context) (*WorkflowRun, error) {
 	)
 
 	// get the container instance to run the jobs
-	rc, err := we.runner.Container(we.runID)
+	rc, err := we.runner.Container(ctx, we.runID)
 	if err != nil {
 		return nil, err
 	}
@@ -75,6 +76,12 @@ func (we *WorkflowExecutor) Execute(ctx context.Context) (*WorkflowRun, error) {
 
 		// add job run to the list of job runs since WorkflowRun is a public type and dagger doesn't support maps yet
 		runs = append(runs, jr)
+
+		// rest of the jobs can't run until the paused job is resumed from the interactive shell
+		if jr.Paused {
+			log.Warnf("Job paused at the failing step, use shell to debug the job", "job", job.JobID)
+			break
+		}
 	}
 
 	// create the workflow run report
@@ -46,6 +46,8 @@ func (m *Source) Binary(ctx context.Context, container *Container) (*Container,
 	source, err := goBase(version).
 		With(m.MountedCode).
 		WithExec([]string{"go", "mod", "download"}).
+		// static binary, it's mounted to the containers of the steps to run their processes as well
+		WithEnvVariable("CGO_ENABLED", "0").
 		WithExec([]string{"go", "build", "-o", "bin/ghx", "."}).
 		Sync(ctx)
 	if err != nil {
@@ -0,0 +1,40 @@
+package context
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// ActionsStepEnv returns the ACTIONS_* environment variables specific to the current step. The runtime token is scoped
+// to the current job run, since the v4 artifact actions read the workflow run and job run ids from the token.
+func (c *Context) ActionsStepEnv() map[string]string {
+	env := map[string]string{"ACTIONS_RUNTIME_TOKEN": c.runtimeToken()}
+
+	if c.Actions.ResultsURL != "" {
+		env["ACTIONS_RESULTS_URL"] = c.Actions.ResultsURL
+	} else if c.Actions.RuntimeURL != "" {
+		env["ACTIONS_RESULTS_URL"] = c.Actions.RuntimeURL
+	}
+
+	return env
+}
+
+// runtimeToken returns an unsigned JWT with the `Actions.Results:<workflow run id>:<job run id>` scope, same format as
+// the runtime token on GitHub. The `repository` claim is used by the cache service to apply the repository size
+// limits. Services in scope of gale don't verify the token, so it's only a carrier of the ids.
+//
+// See: https://github.com/actions/toolkit/blob/main/packages/artifact/src/internal/shared/util.ts
+func (c *Context) runtimeToken() string {
+	if c.Execution.JobRun == nil {
+		return c.Actions.Token
+	}
+
+	scope := fmt.Sprintf("Actions.GenericRead:%s Actions.Results:%s:%s", c.Github.RunID, c.Github.RunID, c.Execution.JobRun.RunID)
+
+	// marshalling maps of strings can't fail, ignoring the errors
+	header, _ := json.Marshal(map[string]string{"alg": "none", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]string{"scp": scope, "iss": "gale", "repository": c.Github.Repository})
+
+	return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims) + "."
+}
@@ -0,0 +1,47 @@
+package context
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aweris/gale/common/model"
+)
+
+func TestContext_ActionsStepEnv(t *testing.T) {
+	ctx := &Context{
+		Actions: ActionsContext{RuntimeURL: "http://artifact-service/", Token: "dummy-token"},
+		Github:  GithubContext{RunID: "123", Repository: "aweris/gale"},
+	}
+
+	if env := ctx.ActionsStepEnv(); env["ACTIONS_RUNTIME_TOKEN"] != "dummy-token" || env["ACTIONS_RESULTS_URL"] != "http://artifact-service/" {
+		t.Errorf("Expected configured token and runtime url as results url without a job, but got %v", env)
+	}
+
+	ctx.Execution.JobRun = &model.JobRun{RunID: "456"}
+
+	parts := strings.Split(ctx.ActionsStepEnv()["ACTIONS_RUNTIME_TOKEN"], ".")
+	if len(parts) != 3 {
+		t.Fatalf("Expected a JWT with 3 parts, but got %d", len(parts))
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	var claims map[string]string
+
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if !strings.Contains(claims["scp"], "Actions.Results:123:456") {
+		t.Errorf("Expected results scope for the job run, but got %s", claims["scp"])
+	}
+
+	if claims["repository"] != "aweris/gale" {
+		t.Errorf("Expected repository claim aweris/gale, but got %s", claims["repository"])
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"dagger.io/dagger"
 
 	"github.com/aweris/gale/common/model"
+
+	"ghx/matcher"
 )
 
 type GhxConfig struct {
@@ -19,8 +21,29 @@ type GhxConfig struct {
 	// ActionsDir is the directory to look for actions.
 	ActionsDir string `env:"GHX_ACTIONS_DIR" envDefault:"/home/runner/_temp/gale/actions"`
 
+	// ActionsOffline resolves the actions only from the actions directory without downloading them.
+	ActionsOffline bool `env:"GHX_ACTIONS_OFFLINE" envDefault:"false"`
+
+	// ActionOverrides is the path of the file containing the action override rules. Overrides are not applied if empty.
+	ActionOverrides string `env:"GHX_ACTION_OVERRIDES"`
+
+	// NativeActions runs the well-known actions like actions/checkout and actions/cache with their native
+	// implementations instead of downloading and running the actions.
+	NativeActions bool `env:"GHX_NATIVE_ACTIONS" envDefault:"false"`
+
+	// ToolsDir is the directory to provision the tools required to run the actions. e.g. Node.js runtimes.
+	ToolsDir string `env:"GHX_TOOLS_DIR" envDefault:"/home/runner/_temp/gale/tools"`
+
 	// MetadataDir is the directory to look for metadata.
 	MetadataDir string `env:"GHX_METADATA_DIR" envDefault:"/home/runner/_temp/gale/metadata"`
+
+	// StrictExpressions fails the step when an expression can't be parsed, evaluated or refers to an undefined property
+	// instead of evaluating the expression to an empty string.
+	StrictExpressions bool `env:"GHX_STRICT_EXPRESSIONS" envDefault:"true"`
+
+	// InteractiveOnFailure pauses the job at the failing step to be able to open an interactive shell with the exact
+	// step environment.
+	InteractiveOnFailure bool `env:"GHX_INTERACTIVE_ON_FAILURE" envDefault:"false"`
 }
 
 // DaggerContext is the context holding the dagger client.
@@ -33,17 +56,46 @@ type ExecutionContext struct {
 	// Workflow is the workflow to be executed.
 	Workflow *model.Workflow
 
+	// ActionNames is the generated `github.action` names of the steps without an id in the current job. Names are
+	// generated once per step, so all stages of the step use the same name.
+	ActionNames map[string]string
+
+	// ActionOverrides is the rules to substitute the actions before they are loaded.
+	ActionOverrides model.ActionOverrides
+
 	// Conclusion is the conclusion of the workflow. Possible values are success, failure, cancelled, skipped.
 	WorkflowConclusion model.Conclusion `env:"GHX_WORKFLOW_CONCLUSION" envDefault:"success"`
 
 	// Job is the current job that is being executed.
 	JobRun *model.JobRun
 
+	// Env is the layers of the env context of the current job.
+	Env EnvLayers
+
 	// Step is the current step that is being executed.
 	StepRun *model.StepRun
 
 	// CurrentAction is the current action that is being executed. This is only available on step level if the step is uses a custom action.
 	CurrentAction *model.CustomAction
+
+	// Composite is the scope of the composite action being executed. This is only available while the steps of a
+	// composite action are executed.
+	Composite *CompositeScope
+
+	// Matchers is the problem matchers registered for the current job.
+	Matchers *matcher.Registry
+
+	// StepLog is the log file of the current step stage.
+	StepLog *StepLog
+
+	// StepShell is the environment snapshot of the current step. This is only available if interactive mode is enabled.
+	StepShell *StepShell
+
+	// Pause is the paused state of the job. This is only available if interactive mode is enabled and a step fails.
+	Pause *Pause
+
+	// Resume is the paused state to resume the job from. This is only available if the job is resumed from a pause.
+	Resume *Pause
 }
 
 // ActionsContext is the context for the internal services configuration for used by GitHub Actions.
@@ -51,6 +103,10 @@ type ActionsContext struct {
 	// RuntimeURL is the URL for the actions runtime. In scope of gale, this is the URL of the artifact service.
 	RuntimeURL string `env:"ACTIONS_RUNTIME_URL"`
 
+	// ResultsURL is the URL for the actions results service used by the v4 artifact actions. In scope of gale, this is
+	// the URL of the artifact service. If not set, RuntimeURL is used.
+	ResultsURL string `env:"ACTIONS_RESULTS_URL"`
+
 	// CacheURL is the URL for the actions cache service.
 	CacheURL string `env:"ACTIONS_CACHE_URL"`
 
@@ -81,6 +137,33 @@ type GithubContext struct {
 	// Workspace is the path of a directory that contains a checkout of the repository.
 	Workspace string `json:"workspace" env:"GITHUB_WORKSPACE"`
 
+	// Actor is the username of the user that triggered the initial workflow run.
+	Actor string `json:"actor" env:"GITHUB_ACTOR"`
+
+	// ActorID is the account ID of the user that triggered the initial workflow run.
+	ActorID string `json:"actor_id" env:"GITHUB_ACTOR_ID"`
+
+	// TriggeringActor is the username of the user that initiated the workflow run. If the workflow run is a re-run,
+	// this value may differ from Actor.
+	TriggeringActor string `json:"triggering_actor" env:"GITHUB_TRIGGERING_ACTOR"`
+
+	// Action is the name of the action currently running, or the id of a step. For steps without an id, the name is
+	// generated, e.g. __run for scripts or __actions_checkout for actions/checkout.
+	Action string `json:"action" env:"GITHUB_ACTION"`
+
+	// ActionPath is the path where an action is located. This property is only supported in composite actions.
+	ActionPath string `json:"action_path" env:"GITHUB_ACTION_PATH"`
+
+	// ActionRef is the ref of the action being executed. e.g. v4. Not available for local actions.
+	ActionRef string `json:"action_ref" env:"GITHUB_ACTION_REF"`
+
+	// ActionRepository is the owner and repository name of the action being executed. e.g. actions/checkout. Not
+	// available for local actions.
+	ActionRepository string `json:"action_repository" env:"GITHUB_ACTION_REPOSITORY"`
+
+	// ActionStatus is the current result of the composite action. This property is only supported in composite actions.
+	ActionStatus string `json:"action_status"`
+
 	// ApiURL is the CloneURL of the Github API. e.g. https://api.github.com
 	APIURL string `json:"api_url" env:"GITHUB_API_URL" envDefault:"https://api.github.com"`
 
@@ -96,6 +179,15 @@ type GithubContext struct {
 	// Path is the path to a temporary file that sets the system PATH variable from workflow commands.
 	Path string `json:"path" env:"GITHUB_PATH"`
 
+	// Output is the path to a temporary file that sets the current step's outputs from workflow commands.
+	Output string `json:"output" env:"GITHUB_OUTPUT"`
+
+	// State is the path to a temporary file that sets the current step's state from workflow commands.
+	State string `json:"state" env:"GITHUB_STATE"`
+
+	// StepSummary is the path to a temporary file that sets the job summary from workflow commands.
+	StepSummary string `json:"step_summary" env:"GITHUB_STEP_SUMMARY"`
+
 	// Workflow is the name of the workflow. If the workflow file doesn't specify a name, the value of this property
 	// is the full path of the workflow file in the repository.
 	Workflow string `json:"workflow" env:"GITHUB_WORKFLOW"`
@@ -225,6 +317,9 @@ type SecretsContext struct {
 
 	// Data is the secrets data.
 	Data map[string]string
+
+	// Masks is the set of the values masked in the logs in addition to the secrets, added by the add-mask command.
+	Masks map[string]bool
 }
 
 // StepsContext is a context that contains information about the steps.
@@ -257,6 +352,11 @@ type StepContext struct {
 // See: https://docs.github.com/en/actions/learn-github-actions/contexts#env-context
 type EnvContext map[string]string
 
+// StrategyContext is a context that contains information about the matrix execution strategy of the current job.
+//
+// See: https://docs.github.com/en/actions/learn-github-actions/contexts#strategy-context
+type StrategyContext model.JobRunStrategy
+
 // MatrixContext is a context that contains matrix information.
 //
 // See: https://docs.github.com/en/actions/learn-github-actions/contexts#matrix-context
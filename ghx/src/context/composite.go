@@ -0,0 +1,138 @@
+package context
+
+import (
+	"errors"
+
+	"github.com/aweris/gale/common/model"
+)
+
+// CompositeScope is the execution scope of a composite action. Steps of the composite action run with their own steps
+// context, inputs and env. The scope keeps the state of the calling step to restore it once the composite action is
+// completed.
+type CompositeScope struct {
+	Parent   *CompositeScope     // Parent is the scope of the composite action calling this one, if any.
+	Depth    int                 // Depth is the nesting level of the composite action. Top level composite is 1.
+	Action   *model.CustomAction // Action is the composite action being executed.
+	Inputs   InputsContext       // Inputs is the evaluated inputs of the composite action.
+	Dir      string              // Dir is the step run path of the calling step. Steps of the composite stored under it.
+	Env      EnvContext          // Env is the env context steps of the composite action start with.
+	StepRuns []model.StepRun     // StepRuns is the step runs executed in the composite action.
+
+	// state of the calling step, restored when the scope exits
+	steps   StepsContext
+	env     EnvContext
+	status  model.Conclusion
+	stepRun *model.StepRun
+	stepLog *StepLog
+	action  *model.CustomAction
+}
+
+// EnterComposite enters the scope of the given composite action for the current step. Until ExitComposite is called,
+// steps are executed with a fresh steps context, the inputs of the composite action and the env of the calling step.
+func (c *Context) EnterComposite(action *model.CustomAction) error {
+	if c.Execution.StepRun == nil {
+		return errors.New("no step is set")
+	}
+
+	dir, err := c.GetStepRunPath()
+	if err != nil {
+		return err
+	}
+
+	inputs, err := c.GetActionInputs()
+	if err != nil {
+		return err
+	}
+
+	scope := &CompositeScope{
+		Parent:  c.Execution.Composite,
+		Depth:   1,
+		Action:  action,
+		Inputs:  inputs,
+		Dir:     dir,
+		Env:     copyEnv(c.Env),
+		steps:   c.Steps,
+		env:     c.Env,
+		status:  c.Job.Status,
+		stepRun: c.Execution.StepRun,
+		stepLog: c.Execution.StepLog,
+		action:  c.Execution.CurrentAction,
+	}
+
+	if scope.Parent != nil {
+		scope.Depth = scope.Parent.Depth + 1
+	}
+
+	c.Execution.Composite = scope
+	c.Execution.StepRun = nil
+	c.Execution.StepLog = nil
+	c.Execution.CurrentAction = nil
+
+	c.Steps = make(StepsContext)
+	c.Env = copyEnv(scope.Env)
+	c.Github.ActionPath = action.Path
+
+	// steps of the composite action have their own status, failures of the job before the composite action should
+	// not skip them
+	c.Job.Status = model.ConclusionSuccess
+
+	return nil
+}
+
+// ExitComposite exits the current composite action scope and restores the state of the calling step. Environment
+// variables, paths, annotations, summaries and log excerpts of the composite steps are added to the calling step.
+func (c *Context) ExitComposite() error {
+	scope := c.Execution.Composite
+	if scope == nil {
+		return errors.New("no composite action is set")
+	}
+
+	sr := scope.stepRun
+
+	for _, run := range scope.StepRuns {
+		for k, v := range run.Environment {
+			if sr.Environment == nil {
+				sr.Environment = make(map[string]string)
+			}
+
+			sr.Environment[k] = v
+		}
+
+		sr.Path = append(sr.Path, run.Path...)
+		sr.Annotations = append(sr.Annotations, run.Annotations...)
+		sr.Log = append(sr.Log, run.Log...)
+
+		if run.Stage == model.StepStageMain && run.Summary != "" {
+			sr.Summary += run.Summary
+		}
+	}
+
+	if over := len(sr.Log) - StepLogExcerptSize; over > 0 {
+		sr.Log = sr.Log[over:]
+	}
+
+	c.Execution.Composite = scope.Parent
+	c.Execution.StepRun = sr
+	c.Execution.StepLog = scope.stepLog
+	c.Execution.CurrentAction = scope.action
+
+	c.Steps = scope.steps
+	c.Env = scope.env
+	c.Job.Status = scope.status
+	c.Github.ActionPath = scope.Action.Path
+
+	c.setGithubAction(sr.Step)
+
+	return nil
+}
+
+// copyEnv returns a copy of the given env context.
+func copyEnv(env EnvContext) EnvContext {
+	cp := make(EnvContext, len(env))
+
+	for k, v := range env {
+		cp[k] = v
+	}
+
+	return cp
+}
@@ -25,6 +25,7 @@ type Context struct {
 	Secrets   SecretsContext
 	Steps     StepsContext
 	Env       EnvContext
+	Strategy  StrategyContext
 	Matrix    MatrixContext
 }
 
@@ -73,6 +74,9 @@ func New(std context.Context, client *dagger.Client) (*Context, error) {
 		ctx.Github.Event = make(map[string]interface{})
 	}
 
+	// fill the github context properties not provided by the environment from the event
+	populateGithubFromEvent(&ctx.Github)
+
 	// set secrets ctx
 	secretsMountPath, err := ctx.GetSecretsPath()
 
@@ -0,0 +1,197 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aweris/gale/common/model"
+
+	"ghx/expression"
+)
+
+// EnvLayers is the layers the env context is built from. Same as GitHub, each layer is evaluated once when its scope
+// starts, and the env context is the merge of the layers in the order of workflow, job, GITHUB_ENV and step. Later
+// layers take precedence.
+type EnvLayers struct {
+	Workflow  EnvContext // Workflow is the evaluated env of the workflow.
+	Job       EnvContext // Job is the evaluated env of the job.
+	GithubEnv EnvContext // GithubEnv is the env variables added by the steps of the job using GITHUB_ENV so far.
+	Step      EnvContext // Step is the evaluated env of the current step.
+	Path      []string   // Path is the directories added by the steps of the job using GITHUB_PATH so far, latest first.
+}
+
+// setJobEnv evaluates the workflow and job env for the current job and resets the rest of the layers.
+func (c *Context) setJobEnv(job model.Job) error {
+	c.Execution.Env = EnvLayers{}
+	c.Env = make(EnvContext)
+
+	if wf := c.Execution.Workflow; wf != nil {
+		env, err := c.evalEnv(c, wf.Env)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate workflow env: %w", err)
+		}
+
+		c.Execution.Env.Workflow = env
+	}
+
+	env, err := c.evalEnv(c, job.Env)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate job env: %w", err)
+	}
+
+	c.Execution.Env.Job = env
+
+	c.Env = c.baseEnv()
+
+	return nil
+}
+
+// unsetJobEnv removes all env layers of the current job, so nothing leaks to the next job run, e.g. matrix legs.
+func (c *Context) unsetJobEnv() {
+	c.Execution.Env = EnvLayers{}
+	c.Env = make(EnvContext)
+}
+
+// setStepEnv evaluates the env of the given step on top of the env of the current scope. The env of the step can refer
+// to the env of the job and the values added by the previous steps.
+func (c *Context) setStepEnv(step model.Step) error {
+	c.Env = c.baseEnv()
+
+	env, err := c.evalEnv(c.GetStepVariableProvider(), step.Environment)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate step env: %w", err)
+	}
+
+	c.Execution.Env.Step = env
+
+	for k, v := range env {
+		c.Env[k] = v
+	}
+
+	return nil
+}
+
+// unsetStepEnv removes the env of the current step from the env context.
+func (c *Context) unsetStepEnv() {
+	c.Execution.Env.Step = nil
+	c.Env = c.baseEnv()
+}
+
+// addGithubEnv adds the given variable to the GITHUB_ENV layer. The variable is available to the following steps,
+// including the following steps of the composite actions being executed.
+func (c *Context) addGithubEnv(key, value string) {
+	if c.Execution.Env.GithubEnv == nil {
+		c.Execution.Env.GithubEnv = make(EnvContext)
+	}
+
+	c.Execution.Env.GithubEnv[key] = value
+
+	for scope := c.Execution.Composite; scope != nil; scope = scope.Parent {
+		scope.Env[key] = value
+	}
+}
+
+// PrependPath prepends the given directory to the PATH of the following steps of the job, same as GITHUB_PATH. The
+// directories are kept with the env layers of the job, so they don't leak to the next job runs, e.g. matrix legs.
+func (c *Context) PrependPath(dir string) {
+	c.Execution.Env.Path = append([]string{dir}, c.Execution.Env.Path...)
+}
+
+// StepPath returns the given PATH with the directories added by the steps of the job prepended to it.
+func (c *Context) StepPath(path string) string {
+	if len(c.Execution.Env.Path) == 0 {
+		return path
+	}
+
+	dirs := append([]string{}, c.Execution.Env.Path...)
+
+	if path != "" {
+		dirs = append(dirs, path)
+	}
+
+	return strings.Join(dirs, string(os.PathListSeparator))
+}
+
+// baseEnv returns the env context of the current scope without the step env. Steps of a composite action start with
+// the env of the calling step instead of the job env.
+func (c *Context) baseEnv() EnvContext {
+	if scope := c.Execution.Composite; scope != nil {
+		return copyEnv(scope.Env)
+	}
+
+	env := make(EnvContext)
+
+	for _, layer := range []EnvContext{c.Execution.Env.Workflow, c.Execution.Env.Job, c.Execution.Env.GithubEnv} {
+		for k, v := range layer {
+			env[k] = v
+		}
+	}
+
+	return env
+}
+
+// evalEnv evaluates the expressions in the values of the given env with the given variable provider.
+func (c *Context) evalEnv(vp expression.VariableProvider, env map[string]string) (EnvContext, error) {
+	evaluated := make(EnvContext, len(env))
+
+	for k, v := range env {
+		val, err := c.EvalString(vp, v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate %s: %w", k, err)
+		}
+
+		evaluated[k] = val
+	}
+
+	return evaluated, nil
+}
+
+// maskSecrets returns a copy of the given env with the secret values replaced with `***`, same as the logs on GitHub.
+func (c *Context) maskSecrets(env map[string]string) map[string]string {
+	masked := make(map[string]string, len(env))
+
+	for k, v := range env {
+		masked[k] = c.MaskValues(v)
+	}
+
+	return masked
+}
+
+// AddMask adds the given value to the values masked in the logs. Empty values are ignored to not mask every line.
+func (c *Context) AddMask(value string) {
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+
+	if c.Secrets.Masks == nil {
+		c.Secrets.Masks = make(map[string]bool)
+	}
+
+	c.Secrets.Masks[value] = true
+}
+
+// MaskValues returns the given text with the secret values and the values added by AddMask replaced with `***`.
+func (c *Context) MaskValues(text string) string {
+	values := make([]string, 0, len(c.Secrets.Data)+len(c.Secrets.Masks))
+
+	for _, secret := range c.Secrets.Data {
+		if secret != "" {
+			values = append(values, secret)
+		}
+	}
+
+	for value := range c.Secrets.Masks {
+		values = append(values, value)
+	}
+
+	// longer values first, otherwise a value containing another one would be revealed partially
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	for _, value := range values {
+		text = strings.ReplaceAll(text, value, "***")
+	}
+
+	return text
+}
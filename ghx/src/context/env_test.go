@@ -0,0 +1,92 @@
+package context
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/common/model"
+)
+
+func TestContext_EnvLayers(t *testing.T) {
+	wf := &model.Workflow{Env: map[string]string{"LEVEL": "workflow", "WORKFLOW": "${{ github.repository }}"}}
+
+	ctx := &Context{
+		GhxConfig: GhxConfig{HomeDir: t.TempDir()},
+		Github:    GithubContext{Repository: "aweris/gale"},
+		Secrets:   SecretsContext{Data: map[string]string{"TOKEN": "s3cr3t"}},
+		Execution: ExecutionContext{Workflow: wf},
+	}
+
+	job := model.Job{
+		ID:  "build",
+		Env: map[string]string{"LEVEL": "job", "OS": "${{ matrix.os }}"},
+		Steps: []model.Step{
+			{Index: "0", ID: "0", Environment: map[string]string{"LEVEL": "step", "TOKEN": "${{ secrets.TOKEN }}"}},
+			{Index: "1", ID: "1", Environment: map[string]string{"COMBINED": "${{ env.OS }}-${{ env.ADDED }}"}},
+			{Index: "2", ID: "2", Environment: map[string]string{"ADDED": "step"}},
+		},
+	}
+
+	if err := ctx.SetJob(&model.JobRun{Job: job, Matrix: model.MatrixCombination{"os": "linux"}}); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	expectedEnvs := []EnvContext{
+		{"LEVEL": "step", "WORKFLOW": "aweris/gale", "OS": "linux", "TOKEN": "s3cr3t"},
+		{"LEVEL": "job", "WORKFLOW": "aweris/gale", "OS": "linux", "ADDED": "github-env", "COMBINED": "linux-github-env"},
+		{"LEVEL": "job", "WORKFLOW": "aweris/gale", "OS": "linux", "ADDED": "step"},
+	}
+
+	for idx, step := range job.Steps {
+		if err := ctx.SetStep(&model.StepRun{Step: step, Stage: model.StepStageMain, Outputs: map[string]string{}, State: map[string]string{}}); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if !reflect.DeepEqual(ctx.Env, expectedEnvs[idx]) {
+			t.Errorf("Expected env %v, but got %v for step %d", expectedEnvs[idx], ctx.Env, idx)
+		}
+
+		// variables added with GITHUB_ENV are available to the following steps only
+		if idx == 0 {
+			if err := ctx.SetStepEnv("ADDED", "github-env"); err != nil {
+				t.Fatalf("Expected no error, but got %v", err)
+			}
+
+			if _, ok := ctx.Env["ADDED"]; ok {
+				t.Errorf("Expected GITHUB_ENV variable not to be available to the current step")
+			}
+
+			ctx.PrependPath("/opt/bin")
+		}
+
+		dir, _ := ctx.GetStepRunPath()
+
+		ctx.UnsetStep(model.RunResult{Ran: true, Conclusion: model.ConclusionSuccess})
+
+		var report model.StepRunReport
+
+		if err := fs.ReadJSONFile(filepath.Join(dir, "step_run.json"), &report); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if idx == 0 && report.EffectiveEnv["TOKEN"] != "***" {
+			t.Errorf("Expected secret to be masked in the effective env, but got %s", report.EffectiveEnv["TOKEN"])
+		}
+	}
+
+	if !reflect.DeepEqual(wf.Env, map[string]string{"LEVEL": "workflow", "WORKFLOW": "${{ github.repository }}"}) {
+		t.Errorf("Expected workflow env not to be modified, but got %v", wf.Env)
+	}
+
+	ctx.UnsetJob(model.RunResult{Ran: true, Conclusion: model.ConclusionSuccess})
+
+	if len(ctx.Env) != 0 || len(ctx.Execution.Env.GithubEnv) != 0 {
+		t.Errorf("Expected env not to leak to the next job run, but got %v and %v", ctx.Env, ctx.Execution.Env.GithubEnv)
+	}
+
+	if path := ctx.StepPath("/usr/bin"); path != "/usr/bin" {
+		t.Errorf("Expected paths not to leak to the next job run, but got %s", path)
+	}
+}
@@ -3,10 +3,13 @@ package context
 import (
 	"errors"
 	"path/filepath"
+	"strings"
 
 	"github.com/aweris/gale/common/fs"
 	"github.com/aweris/gale/common/log"
 	"github.com/aweris/gale/common/model"
+
+	"ghx/matcher"
 )
 
 // SetJob sets the given job to the execution context.
@@ -17,22 +20,26 @@ func (c *Context) SetJob(jr *model.JobRun) error {
 	// set the job run to the github context
 	c.Github.Job = jr.Job.ID
 
-	// set env context
-	for k, v := range jr.Job.Env {
-		c.Env[k] = v
-	}
-
 	// set matrix context if matrix has any values
 	if len(jr.Matrix) > 0 {
 		c.Matrix = MatrixContext(jr.Matrix)
 	}
 
+	// set strategy context
+	c.Strategy = StrategyContext(jr.Strategy)
+
 	// load the job context with workflow conclusion as the job status
 	c.Job = JobContext{Status: c.Execution.WorkflowConclusion}
 
 	// load the steps context
 	c.Steps = make(StepsContext)
 
+	// problem matchers are registered per job
+	c.Execution.Matchers = matcher.NewRegistry()
+
+	// action names are unique per job
+	c.Execution.ActionNames = make(map[string]string)
+
 	c.Needs = make(NeedsContext)
 
 	// ignoring error since directory must exist at this point of execution
@@ -50,7 +57,8 @@ func (c *Context) SetJob(jr *model.JobRun) error {
 		}
 	}
 
-	return nil
+	// set env context, evaluated last since job env can refer to the matrix, strategy and needs contexts
+	return c.setJobEnv(jr.Job)
 }
 
 // UnsetJob unsets the job from the execution context.
@@ -58,8 +66,11 @@ func (c *Context) UnsetJob(result model.RunResult) {
 	// unset the job run from the github context
 	c.Github.Job = ""
 
-	// reset matrix context
+	// reset matrix, strategy and env contexts
 	c.Matrix = make(MatrixContext)
+	c.Strategy = StrategyContext{}
+
+	c.unsetJobEnv()
 
 	// write the job run result to the file system
 	// ignoring error since directory must be exist at this point of execution
@@ -71,6 +82,16 @@ func (c *Context) UnsetJob(result model.RunResult) {
 		log.Errorf("failed to write job run", "error", err)
 	}
 
+	// job_run.json is overwritten by each matrix leg, so keep the report of each leg separately as well
+	if len(c.Execution.JobRun.Matrix) > 0 {
+		// ignoring error since directory must be exist at this point of execution
+		legDir, _ := c.GetJobRunLegPath()
+
+		if err := fs.WriteJSONFile(filepath.Join(legDir, "job_run.json"), report); err != nil {
+			log.Errorf("failed to write matrix job run", "error", err)
+		}
+	}
+
 	// unset the job run from the execution context
 	c.Execution.JobRun = nil
 }
@@ -100,11 +121,37 @@ func (c *Context) SetStep(sr *model.StepRun) error {
 
 	c.Execution.StepRun = sr
 
-	// set the step env context
-	for k, v := range sr.Step.Environment {
-		c.Env[k] = v
+	// reset the environment snapshot of the previous step
+	c.Execution.StepShell = nil
+
+	// set the step env context before anything else, a step failing to evaluate its env doesn't run
+	if err := c.setStepEnv(sr.Step); err != nil {
+		c.Execution.StepRun = nil
+		c.Env = c.baseEnv()
+
+		return err
+	}
+
+	// steps running a process replace it with the environment of the process
+	sr.EffectiveEnv = c.maskSecrets(c.Env)
+
+	dir, err := c.GetStepRunPath()
+	if err != nil {
+		return err
 	}
 
+	// write all logs of the step stage to its own log file as well
+	stepLog, err := openStepLog(dir, string(sr.Stage))
+	if err != nil {
+		return err
+	}
+
+	c.Execution.StepLog = stepLog
+
+	log.AddWriter(stepLog)
+
+	c.setGithubAction(sr.Step)
+
 	return nil
 }
 
@@ -114,20 +161,24 @@ func (c *Context) UnsetStep(result model.RunResult) {
 		return
 	}
 
-	// TODO: improve this logic
+	c.unsetStepEnv()
 
-	// unset the step run from the execution context
+	sr := c.Execution.StepRun
 
-	c.Env = c.Execution.Workflow.Env
+	sr.Duration = result.Duration
 
-	for k, v := range c.Execution.JobRun.Job.Env {
-		c.Env[k] = v
+	// step results are not set when the step is not executed. e.g. skipped by the condition
+	if sr.Conclusion == "" {
+		sr.Conclusion = result.Conclusion
+		sr.Outcome = result.Conclusion
 	}
 
-	sr := c.Execution.StepRun
-
-	// update the step run in the job run
-	c.Execution.JobRun.Steps = append(c.Execution.JobRun.Steps, *sr)
+	// update the step run in the job run, steps of a composite action are reported by the calling step
+	if scope := c.Execution.Composite; scope != nil {
+		scope.StepRuns = append(scope.StepRuns, *sr)
+	} else {
+		c.Execution.JobRun.Steps = append(c.Execution.JobRun.Steps, *sr)
+	}
 
 	sc, ok := c.Steps[sr.Step.ID]
 	if !ok {
@@ -162,6 +213,18 @@ func (c *Context) UnsetStep(result model.RunResult) {
 		}
 	}
 
+	if c.Execution.StepLog != nil {
+		log.RemoveWriter(c.Execution.StepLog)
+
+		if err := c.Execution.StepLog.Close(); err != nil {
+			log.Errorf("failed to close step log", "error", err)
+		}
+
+		c.Execution.StepLog = nil
+	}
+
+	c.unsetGithubAction()
+
 	c.Execution.StepRun = nil
 }
 
@@ -220,20 +283,82 @@ func (c *Context) AddStepPath(path string) error {
 	return nil
 }
 
+// AddStepAnnotation adds the given annotation to the step annotations. If the annotation file is an absolute path in
+// the workspace, it's converted to a path relative to the workspace like GitHub does.
+func (c *Context) AddStepAnnotation(annotation model.Annotation) error {
+	if c.Execution.StepRun == nil {
+		return errors.New("no step is set")
+	}
+
+	if filepath.IsAbs(annotation.File) && c.Github.Workspace != "" {
+		if rel, err := filepath.Rel(c.Github.Workspace, annotation.File); err == nil && !strings.HasPrefix(rel, "..") {
+			annotation.File = rel
+		}
+	}
+
+	c.Execution.StepRun.Annotations = append(c.Execution.StepRun.Annotations, annotation)
+
+	return nil
+}
+
+// StepLogExcerptSize is the maximum number of the last output lines kept for the step as log excerpt.
+const StepLogExcerptSize = 50
+
+// AddStepLog adds the given output line to the step log excerpt. Only the last StepLogExcerptSize lines are kept.
+func (c *Context) AddStepLog(line string) error {
+	if c.Execution.StepRun == nil {
+		return errors.New("no step is set")
+	}
+
+	c.Execution.StepRun.Log = append(c.Execution.StepRun.Log, line)
+
+	if over := len(c.Execution.StepRun.Log) - StepLogExcerptSize; over > 0 {
+		c.Execution.StepRun.Log = c.Execution.StepRun.Log[over:]
+	}
+
+	return nil
+}
+
+// SetStepEnv sets the given environment variable for the following steps of the job, same as GITHUB_ENV. The env
+// context of the current step is not changed.
 func (c *Context) SetStepEnv(key, value string) error {
 	if c.Execution.StepRun == nil {
 		return errors.New("no step is set")
 	}
 
+	if c.Execution.StepRun.Environment == nil {
+		c.Execution.StepRun.Environment = make(map[string]string)
+	}
+
 	c.Execution.StepRun.Environment[key] = value
 
+	c.addGithubEnv(key, value)
+
+	return nil
+}
+
+// SetStepEffectiveEnv sets the environment the current step is executed with. Secret values are masked.
+func (c *Context) SetStepEffectiveEnv(env map[string]string) error {
+	if c.Execution.StepRun == nil {
+		return errors.New("no step is set")
+	}
+
+	c.Execution.StepRun.EffectiveEnv = c.maskSecrets(env)
+
 	return nil
 }
 
 func (c *Context) SetAction(action *model.CustomAction) {
 	c.Execution.CurrentAction = action
+	c.Github.ActionPath = action.Path
 }
 
 func (c *Context) UnsetAction() {
 	c.Execution.CurrentAction = nil
+	c.Github.ActionPath = ""
+
+	// steps of a composite action still have access to the path of the composite action
+	if c.Execution.Composite != nil {
+		c.Github.ActionPath = c.Execution.Composite.Action.Path
+	}
 }
@@ -13,7 +13,14 @@ var _ expression.VariableProvider = new(Context)
 func (c *Context) GetVariable(name string) (interface{}, error) {
 	switch name {
 	case "github":
-		return c.Github, nil
+		github := c.Github
+
+		// status of the composite action is the status of its steps so far
+		if c.Execution.Composite != nil {
+			github.ActionStatus = string(c.Job.Status)
+		}
+
+		return github, nil
 	case "runner":
 		return c.Runner, nil
 	case "env":
@@ -27,7 +34,7 @@ func (c *Context) GetVariable(name string) (interface{}, error) {
 	case "secrets":
 		return c.Secrets.Data, nil
 	case "strategy":
-		return map[string]string{}, nil
+		return c.Strategy, nil
 	case "matrix":
 		return c.Matrix, nil
 	case "needs":
@@ -53,20 +60,53 @@ type ActionsVariableProvider struct {
 }
 
 // GetVariableProvider returns a variable provider for the current action. If the current action or step run is nil,
-// it returns the main context as the variable provider.
-func (c *Context) GetVariableProvider() expression.VariableProvider {
+// it returns the variable provider of the step scope.
+func (c *Context) GetVariableProvider() (expression.VariableProvider, error) {
 	if c.Execution.StepRun == nil || c.Execution.CurrentAction == nil {
+		return c.GetStepVariableProvider(), nil
+	}
+
+	inputs, err := c.GetActionInputs()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ActionsVariableProvider{main: c, inputs: inputs}, nil
+}
+
+// GetStepVariableProvider returns a variable provider for the scope the current step is defined in. If the step is
+// part of a composite action, the provider contains the inputs of the composite action. Otherwise, it returns the main
+// context as the variable provider.
+func (c *Context) GetStepVariableProvider() expression.VariableProvider {
+	if c.Execution.Composite == nil {
 		return c
 	}
 
+	return &ActionsVariableProvider{main: c, inputs: c.Execution.Composite.Inputs}
+}
+
+// GetActionInputs returns the inputs of the current action. Input values are evaluated in the scope of the step
+// using the action and default values are used for the inputs not defined in the step config.
+func (c *Context) GetActionInputs() (InputsContext, error) {
+	inputs := make(InputsContext)
+
+	if c.Execution.StepRun == nil || c.Execution.CurrentAction == nil {
+		return inputs, nil
+	}
+
 	var (
-		inputs = make(InputsContext)
+		vp     = c.GetStepVariableProvider()
 		step   = c.Execution.StepRun.Step
 		action = c.Execution.CurrentAction
 	)
 
 	for k, v := range step.With {
-		inputs[k] = v
+		val, err := c.EvalString(vp, v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate input %s: %w", k, err)
+		}
+
+		inputs[k] = val
 	}
 
 	// add default values for inputs that are not defined in the step config
@@ -75,14 +115,33 @@ func (c *Context) GetVariableProvider() expression.VariableProvider {
 			continue
 		}
 
+		// declared inputs are always defined in the inputs context, same as GitHub
 		if v.Default == "" {
+			inputs[k] = ""
 			continue
 		}
 
-		inputs[k] = v.Default
+		val, err := c.EvalString(vp, v.Default)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate default value of input %s: %w", k, err)
+		}
+
+		inputs[k] = val
+	}
+
+	return inputs, nil
+}
+
+// EvalString evaluates the expressions in the given value with the given variable provider. If strict expressions are
+// enabled, evaluation errors are returned. Otherwise, errors are logged and the value is evaluated to an empty string.
+func (c *Context) EvalString(vp expression.VariableProvider, value string) (string, error) {
+	str := expression.NewString(value)
+
+	if c.GhxConfig.StrictExpressions {
+		return str.EvalStrict(vp)
 	}
 
-	return &ActionsVariableProvider{main: c, inputs: inputs}
+	return str.Eval(vp), nil
 }
 
 func (p *ActionsVariableProvider) GetVariable(name string) (interface{}, error) {
@@ -0,0 +1,175 @@
+package context
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/aweris/gale/common/model"
+)
+
+// populateGithubFromEvent fills the properties of the github context that are not set explicitly from the event
+// payload. Actor falls back to the repository owner, since there is always an actor for a workflow run on GitHub.
+func populateGithubFromEvent(github *GithubContext) {
+	event := github.Event
+
+	setIfEmpty(&github.Actor, eventValue(event, "sender", "login"))
+	setIfEmpty(&github.Actor, github.RepositoryOwner)
+	setIfEmpty(&github.ActorID, eventValue(event, "sender", "id"))
+	setIfEmpty(&github.TriggeringActor, github.Actor)
+	setIfEmpty(&github.RepositoryID, eventValue(event, "repository", "id"))
+	setIfEmpty(&github.RepositoryOwnerID, eventValue(event, "repository", "owner", "id"))
+
+	// base and head refs are only available for the pull request events
+	if strings.HasPrefix(github.EventName, "pull_request") {
+		setIfEmpty(&github.BaseRef, eventValue(event, "pull_request", "base", "ref"))
+		setIfEmpty(&github.HeadRef, eventValue(event, "pull_request", "head", "ref"))
+	}
+}
+
+// setIfEmpty sets the given value to the field if the field is empty.
+func setIfEmpty(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+// eventValue returns the value of the given property path in the event payload as string. Numbers are formatted without
+// exponent, e.g. ids. If the property doesn't exist or not a scalar value, it returns an empty string.
+func eventValue(event map[string]interface{}, keys ...string) string {
+	var current interface{} = event
+
+	for _, key := range keys {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+
+		current = obj[key]
+	}
+
+	switch val := current.(type) {
+	case string:
+		return val
+	case float64:
+		return fmt.Sprintf("%.0f", val)
+	case bool:
+		return fmt.Sprintf("%t", val)
+	default:
+		return ""
+	}
+}
+
+// setGithubAction sets the action related properties of the github context for the given step.
+func (c *Context) setGithubAction(step model.Step) {
+	c.Github.Action = c.actionName(step)
+	c.Github.ActionRepository, c.Github.ActionRef = actionRepositoryAndRef(step.Uses)
+}
+
+// unsetGithubAction removes the action related properties of the github context set for the current step.
+func (c *Context) unsetGithubAction() {
+	c.Github.Action = ""
+	c.Github.ActionRepository = ""
+	c.Github.ActionRef = ""
+}
+
+// GithubStepEnv returns the GITHUB_* environment variables of the github context for the current step. Properties
+// filled by ghx, e.g. the actor or the ids taken from the event payload, are not in the environment of the runner, so
+// all non-empty properties with an env tag are returned, including the action specific ones of the current step.
+func (c *Context) GithubStepEnv() map[string]string {
+	// GITHUB_ACTION is always set, even if the step has no name yet
+	env := map[string]string{"GITHUB_ACTION": c.Github.Action}
+
+	value := reflect.ValueOf(c.Github)
+
+	for i := 0; i < value.NumField(); i++ {
+		name := value.Type().Field(i).Tag.Get("env")
+		if name == "" {
+			continue
+		}
+
+		if val := fmt.Sprint(value.Field(i).Interface()); val != "" {
+			env[name] = val
+		}
+	}
+
+	return env
+}
+
+// actionName returns the `github.action` name of the given step. Same as GitHub, it's the id of the step if the step
+// has one, otherwise the name is generated from the step type, e.g. __run or __actions_checkout. Repeated names in the
+// same job get a suffix with the sequence number, e.g. __run_2.
+func (c *Context) actionName(step model.Step) string {
+	// steps without an explicit id use the index of the step as id
+	if step.ID != step.Index {
+		return step.ID
+	}
+
+	key := step.ID
+
+	if scope := c.Execution.Composite; scope != nil {
+		key = scope.Dir + "/" + key
+	}
+
+	if c.Execution.ActionNames == nil {
+		c.Execution.ActionNames = make(map[string]string)
+	}
+
+	if name, ok := c.Execution.ActionNames[key]; ok {
+		return name
+	}
+
+	var base string
+
+	switch {
+	case step.Uses == "":
+		base = "__run"
+	case strings.HasPrefix(step.Uses, "docker://"):
+		base = "__docker"
+	case strings.HasPrefix(step.Uses, "./"):
+		base = "__self"
+	default:
+		repo, _ := actionRepositoryAndRef(step.Uses)
+		base = "__" + strings.ReplaceAll(repo, "/", "_")
+	}
+
+	name := base
+
+	for i := 2; c.hasActionName(name); i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+
+	c.Execution.ActionNames[key] = name
+
+	return name
+}
+
+// hasActionName returns true if the given name is already used by a step of the current job.
+func (c *Context) hasActionName(name string) bool {
+	for _, n := range c.Execution.ActionNames {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// actionRepositoryAndRef returns the owner and repository name and the ref of the given action reference. e.g.
+// actions/checkout and v4 for actions/checkout@v4. Local and docker actions don't have a repository.
+func actionRepositoryAndRef(uses string) (string, string) {
+	if uses == "" || strings.HasPrefix(uses, "./") || strings.HasPrefix(uses, "docker://") {
+		return "", ""
+	}
+
+	name, ref, _ := strings.Cut(uses, "@")
+
+	// actions in a subdirectory of the repository, e.g. github/codeql-action/init@v2
+	parts := strings.SplitN(name, "/", 3)
+	if len(parts) < 2 {
+		return "", ""
+	}
+
+	return path.Join(parts[0], parts[1]), ref
+}
@@ -0,0 +1,163 @@
+package context
+
+import (
+	"testing"
+
+	"github.com/aweris/gale/common/model"
+)
+
+func TestPopulateGithubFromEvent(t *testing.T) {
+	github := GithubContext{
+		RepositoryOwner: "aweris",
+		EventName:       "pull_request",
+		Event: map[string]interface{}{
+			"sender":       map[string]interface{}{"login": "octocat", "id": float64(583231)},
+			"repository":   map[string]interface{}{"id": float64(1296269), "owner": map[string]interface{}{"id": float64(1234567)}},
+			"pull_request": map[string]interface{}{"base": map[string]interface{}{"ref": "main"}, "head": map[string]interface{}{"ref": "feature"}},
+		},
+	}
+
+	populateGithubFromEvent(&github)
+
+	actual := map[string]string{
+		"actor":               github.Actor,
+		"actor_id":            github.ActorID,
+		"triggering_actor":    github.TriggeringActor,
+		"repository_id":       github.RepositoryID,
+		"repository_owner_id": github.RepositoryOwnerID,
+		"base_ref":            github.BaseRef,
+		"head_ref":            github.HeadRef,
+	}
+
+	expected := map[string]string{
+		"actor":               "octocat",
+		"actor_id":            "583231",
+		"triggering_actor":    "octocat",
+		"repository_id":       "1296269",
+		"repository_owner_id": "1234567",
+		"base_ref":            "main",
+		"head_ref":            "feature",
+	}
+
+	for k, v := range expected {
+		if actual[k] != v {
+			t.Errorf("Expected %s to be %s, but got %s", k, v, actual[k])
+		}
+	}
+}
+
+func TestPopulateGithubFromEvent_ExplicitValues(t *testing.T) {
+	github := GithubContext{
+		Actor:           "gale",
+		RepositoryOwner: "aweris",
+		EventName:       "push",
+		Event: map[string]interface{}{
+			"sender":       map[string]interface{}{"login": "octocat"},
+			"pull_request": map[string]interface{}{"base": map[string]interface{}{"ref": "main"}},
+		},
+	}
+
+	populateGithubFromEvent(&github)
+
+	if github.Actor != "gale" || github.TriggeringActor != "gale" {
+		t.Errorf("Expected explicit actor to be kept, but got %s and %s", github.Actor, github.TriggeringActor)
+	}
+
+	if github.BaseRef != "" {
+		t.Errorf("Expected base ref to be empty for push events, but got %s", github.BaseRef)
+	}
+
+	github = GithubContext{RepositoryOwner: "aweris", Event: map[string]interface{}{}}
+
+	populateGithubFromEvent(&github)
+
+	if github.Actor != "aweris" {
+		t.Errorf("Expected actor to fall back to repository owner, but got %s", github.Actor)
+	}
+}
+
+func TestContext_GithubStepEnv(t *testing.T) {
+	ctx := Context{
+		Github: GithubContext{
+			Repository:      "aweris/gale",
+			RepositoryOwner: "aweris",
+			EventName:       "pull_request",
+			Event: map[string]interface{}{
+				"sender":       map[string]interface{}{"login": "octocat", "id": float64(583231)},
+				"repository":   map[string]interface{}{"id": float64(1296269), "owner": map[string]interface{}{"id": float64(1234567)}},
+				"pull_request": map[string]interface{}{"base": map[string]interface{}{"ref": "main"}, "head": map[string]interface{}{"ref": "feature"}},
+			},
+		},
+	}
+
+	populateGithubFromEvent(&ctx.Github)
+
+	ctx.setGithubAction(model.Step{Index: "0", ID: "0", Uses: "actions/checkout@v4"})
+
+	env := ctx.GithubStepEnv()
+
+	expected := map[string]string{
+		"GITHUB_REPOSITORY":          "aweris/gale",
+		"GITHUB_ACTOR":               "octocat",
+		"GITHUB_ACTOR_ID":            "583231",
+		"GITHUB_TRIGGERING_ACTOR":    "octocat",
+		"GITHUB_REPOSITORY_ID":       "1296269",
+		"GITHUB_REPOSITORY_OWNER_ID": "1234567",
+		"GITHUB_BASE_REF":            "main",
+		"GITHUB_HEAD_REF":            "feature",
+		"GITHUB_ACTION":              "__actions_checkout",
+		"GITHUB_ACTION_REPOSITORY":   "actions/checkout",
+		"GITHUB_ACTION_REF":          "v4",
+	}
+
+	for k, v := range expected {
+		if env[k] != v {
+			t.Errorf("Expected %s to be %s, but got %s", k, v, env[k])
+		}
+	}
+
+	if _, ok := env["GITHUB_ACTION_PATH"]; ok {
+		t.Errorf("Expected empty GITHUB_ACTION_PATH to be omitted, but got %s", env["GITHUB_ACTION_PATH"])
+	}
+
+	ctx.unsetGithubAction()
+
+	if env := ctx.GithubStepEnv(); env["GITHUB_ACTION_REPOSITORY"] != "" {
+		t.Errorf("Expected GITHUB_ACTION_REPOSITORY to be removed after the step, but got %s", env["GITHUB_ACTION_REPOSITORY"])
+	}
+}
+
+func TestContext_ActionName(t *testing.T) {
+	var ctx Context
+
+	tests := []struct {
+		step       model.Step
+		name       string
+		repository string
+		ref        string
+	}{
+		{model.Step{Index: "0", ID: "0", Uses: "actions/checkout@v4"}, "__actions_checkout", "actions/checkout", "v4"},
+		{model.Step{Index: "1", ID: "1", Run: "echo 1"}, "__run", "", ""},
+		{model.Step{Index: "2", ID: "build", Run: "echo 2"}, "build", "", ""},
+		{model.Step{Index: "3", ID: "3", Run: "echo 3"}, "__run_2", "", ""},
+		{model.Step{Index: "1", ID: "1", Run: "echo 1"}, "__run", "", ""}, // same step in another stage keeps its name
+		{model.Step{Index: "4", ID: "4", Uses: "github/codeql-action/init@v2"}, "__github_codeql-action", "github/codeql-action", "v2"},
+		{model.Step{Index: "5", ID: "5", Uses: "./.github/actions/setup"}, "__self", "", ""},
+		{model.Step{Index: "6", ID: "6", Uses: "docker://alpine:3.18"}, "__docker", "", ""},
+		{model.Step{Index: "7", ID: "7", Uses: "actions/checkout@main"}, "__actions_checkout_2", "actions/checkout", "main"},
+	}
+
+	for _, tt := range tests {
+		ctx.setGithubAction(tt.step)
+
+		if ctx.Github.Action != tt.name {
+			t.Errorf("Expected action name %s, but got %s for step %+v", tt.name, ctx.Github.Action, tt.step)
+		}
+
+		if ctx.Github.ActionRepository != tt.repository || ctx.Github.ActionRef != tt.ref {
+			t.Errorf("Expected action %s@%s, but got %s@%s", tt.repository, tt.ref, ctx.Github.ActionRepository, ctx.Github.ActionRef)
+		}
+
+		ctx.unsetGithubAction()
+	}
+}
@@ -20,6 +20,11 @@ func (c *Context) GetActionsPath() (string, error) {
 	return EnsureDir(c.GhxConfig.ActionsDir)
 }
 
+// GetToolsPath returns the path of the tools provisioned to run the actions. If the path does not exist, it creates it.
+func (c *Context) GetToolsPath() (string, error) {
+	return EnsureDir(c.GhxConfig.ToolsDir)
+}
+
 // GetSecretsPath returns the path of the secrets.json file containing the secrets. If the path does not exist, it
 // creates it.
 func (c *Context) GetSecretsPath() (string, error) {
@@ -59,6 +64,21 @@ func (c *Context) GetJobRunPath() (string, error) {
 	return EnsureDir(c.GhxConfig.HomeDir, "run", "jobs", c.Execution.JobRun.Job.ID)
 }
 
+// GetJobRunLegPath returns the path of the data of the current run of the job. Matrix legs of a job share the job run
+// path, so the data of each leg is kept under matrix/<job run id> to not overwrite each other. If the path does not
+// exist, it creates it. If the job run is not set, it returns an error.
+func (c *Context) GetJobRunLegPath() (string, error) {
+	if c.Execution.JobRun == nil {
+		return "", errors.New("no job is set")
+	}
+
+	if len(c.Execution.JobRun.Matrix) == 0 {
+		return c.GetJobRunPath()
+	}
+
+	return EnsureDir(c.GhxConfig.HomeDir, "run", "jobs", c.Execution.JobRun.Job.ID, "matrix", c.Execution.JobRun.RunID)
+}
+
 // GetStepRunPath returns the path of the current step run path. If the path does not exist, it creates it. If the step
 // run is not set, it returns an error.
 func (c *Context) GetStepRunPath() (string, error) {
@@ -66,7 +86,19 @@ func (c *Context) GetStepRunPath() (string, error) {
 		return "", errors.New("no step is set")
 	}
 
-	return EnsureDir(c.GhxConfig.HomeDir, "run", "jobs", c.Execution.JobRun.Job.ID, "steps", c.Execution.StepRun.Step.Index+"."+c.Execution.StepRun.Step.ID)
+	name := c.Execution.StepRun.Step.Index + "." + c.Execution.StepRun.Step.ID
+
+	// steps of a composite action are stored under the calling step
+	if c.Execution.Composite != nil {
+		return EnsureDir(c.Execution.Composite.Dir, "steps", name)
+	}
+
+	dir, err := c.GetJobRunLegPath()
+	if err != nil {
+		return "", err
+	}
+
+	return EnsureDir(dir, "steps", name)
 }
 
 // EnsureDir return the joined path and ensures that the directory exists. and returns the joined path.
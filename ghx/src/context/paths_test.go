@@ -0,0 +1,42 @@
+package context
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/aweris/gale/common/model"
+)
+
+func TestContext_GetStepRunPath_MatrixLegs(t *testing.T) {
+	home := t.TempDir()
+
+	ctx := &Context{GhxConfig: GhxConfig{HomeDir: home}}
+
+	step := model.Step{Index: "0", ID: "build"}
+
+	tests := []struct {
+		name string
+		jr   *model.JobRun
+		want string
+	}{
+		{name: "no matrix", jr: &model.JobRun{RunID: "1", Job: model.Job{ID: "test"}}, want: filepath.Join(home, "run", "jobs", "test", "steps", "0.build")},
+		{name: "first leg", jr: &model.JobRun{RunID: "2", Job: model.Job{ID: "test"}, Matrix: model.MatrixCombination{"os": "linux"}}, want: filepath.Join(home, "run", "jobs", "test", "matrix", "2", "steps", "0.build")},
+		{name: "second leg", jr: &model.JobRun{RunID: "3", Job: model.Job{ID: "test"}, Matrix: model.MatrixCombination{"os": "macos"}}, want: filepath.Join(home, "run", "jobs", "test", "matrix", "3", "steps", "0.build")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx.Execution.JobRun = tt.jr
+			ctx.Execution.StepRun = &model.StepRun{Step: step}
+
+			got, err := ctx.GetStepRunPath()
+			if err != nil {
+				t.Fatalf("Expected no error, but got %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Expected step run path %s, but got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package context
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/common/model"
+)
+
+// StepShell is the snapshot of the environment a step is executed with. It's used to re-create the exact step
+// environment in an interactive shell when the step fails.
+type StepShell struct {
+	Env     []string `json:"env"`     // Env is the environment variables of the step in KEY=VALUE format.
+	Workdir string   `json:"workdir"` // Workdir is the working directory of the step.
+}
+
+// Pause is the state of a job execution paused at a failing step. It contains everything needed to open a shell with
+// the step environment and to resume the job execution from the paused step.
+type Pause struct {
+	Runner   int                          `json:"runner"`    // Runner is the index of the paused job runner. e.g. matrix leg
+	Task     int                          `json:"task"`      // Task is the index of the failed task in the job plan.
+	Name     string                       `json:"name"`      // Name is the name of the failed task.
+	Shell    StepShell                    `json:"shell"`     // Shell is the environment of the failed step.
+	Status   model.Conclusion             `json:"status"`    // Status is the job status before the failed task.
+	Steps    StepsContext                 `json:"steps"`     // Steps is the steps context of the job.
+	States   map[string]map[string]string `json:"states"`    // States is the states of the steps, not part of the steps context json.
+	StepRuns []model.StepRun              `json:"step_runs"` // StepRuns is the step runs executed before the pause.
+}
+
+// SetStepShell sets the environment snapshot of the current step.
+func (c *Context) SetStepShell(env []string, workdir string) error {
+	if c.Execution.StepRun == nil {
+		return errors.New("no step is set")
+	}
+
+	c.Execution.StepShell = &StepShell{Env: env, Workdir: workdir}
+
+	return nil
+}
+
+// PauseJob pauses the current job at the given task. The pause requires the environment snapshot of the failed step,
+// otherwise it returns an error.
+func (c *Context) PauseJob(task int, name string) error {
+	if c.Execution.JobRun == nil {
+		return errors.New("no job is set")
+	}
+
+	if c.Execution.StepShell == nil {
+		return fmt.Errorf("no step environment available to pause the job at %s", name)
+	}
+
+	states := make(map[string]map[string]string, len(c.Steps))
+
+	for id, sc := range c.Steps {
+		states[id] = sc.State
+	}
+
+	c.Execution.Pause = &Pause{
+		Task:     task,
+		Name:     name,
+		Shell:    *c.Execution.StepShell,
+		Status:   c.Job.Status,
+		Steps:    c.Steps,
+		States:   states,
+		StepRuns: c.Execution.JobRun.Steps,
+	}
+
+	return nil
+}
+
+// ResumeJob restores the state of the current job from the given pause. Environment variables added by the steps
+// executed before the pause are restored to the env context and paths to the env layers of the job.
+func (c *Context) ResumeJob(pause *Pause) error {
+	if c.Execution.JobRun == nil {
+		return errors.New("no job is set")
+	}
+
+	c.Job.Status = pause.Status
+
+	for id, sc := range pause.Steps {
+		sc.State = pause.States[id]
+
+		c.Steps[id] = sc
+	}
+
+	for _, sr := range pause.StepRuns {
+		for k, v := range sr.Environment {
+			c.addGithubEnv(k, v)
+		}
+
+		// paths are prepended in the order they are added, same as the steps did before the pause
+		for _, p := range sr.Path {
+			c.PrependPath(p)
+		}
+	}
+
+	c.Env = c.baseEnv()
+
+	c.Execution.JobRun.Steps = append(c.Execution.JobRun.Steps, pause.StepRuns...)
+
+	return nil
+}
+
+// GetPausePath returns the path of the pause file of the job. If the directory of the file does not exist, it
+// creates it.
+func (c *Context) GetPausePath() (string, error) {
+	dir, err := EnsureDir(c.GhxConfig.HomeDir, "run", "jobs", c.GhxConfig.Job)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "pause.json"), nil
+}
+
+// WritePause writes the pause state of the job to the pause file.
+func (c *Context) WritePause() error {
+	if c.Execution.Pause == nil {
+		return errors.New("job is not paused")
+	}
+
+	path, err := c.GetPausePath()
+	if err != nil {
+		return err
+	}
+
+	return fs.WriteJSONFile(path, c.Execution.Pause)
+}
+
+// ReadPause reads the pause state of the job from the pause file.
+func (c *Context) ReadPause() (*Pause, error) {
+	path, err := c.GetPausePath()
+	if err != nil {
+		return nil, err
+	}
+
+	var pause Pause
+
+	if err := fs.ReadJSONFile(path, &pause); err != nil {
+		return nil, err
+	}
+
+	return &pause, nil
+}
+
+// RemovePause removes the pause file of the job if it exists.
+func (c *Context) RemovePause() error {
+	path, err := c.GetPausePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package context
+
+import (
+	"os"
+	"testing"
+
+	"github.com/aweris/gale/common/model"
+)
+
+func TestContext_ResumeJob(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin")
+
+	ctx := &Context{GhxConfig: GhxConfig{HomeDir: t.TempDir()}}
+
+	if err := ctx.SetJob(&model.JobRun{Job: model.Job{ID: "build"}}); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	pause := &Pause{
+		Steps:  StepsContext{"0": {Outputs: map[string]string{}}},
+		States: map[string]map[string]string{"0": {"KEY": "value"}},
+		StepRuns: []model.StepRun{
+			{Environment: map[string]string{"ADDED": "github-env"}, Path: []string{"/opt/first"}},
+			{Path: []string{"/opt/second"}},
+		},
+	}
+
+	if err := ctx.ResumeJob(pause); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	// paths are prepended, so the last added path takes precedence
+	if path := ctx.StepPath(os.Getenv("PATH")); path != "/opt/second:/opt/first:/usr/bin" {
+		t.Errorf("Expected restored paths to be prepended to PATH, but got %s", path)
+	}
+
+	// paths are kept in the job, the PATH of the runner is not changed
+	if path := os.Getenv("PATH"); path != "/usr/bin" {
+		t.Errorf("Expected PATH of the runner to stay /usr/bin, but got %s", path)
+	}
+
+	if ctx.Env["ADDED"] != "github-env" || ctx.Steps["0"].State["KEY"] != "value" {
+		t.Errorf("Expected env and state to be restored, but got %v and %v", ctx.Env, ctx.Steps["0"].State)
+	}
+}
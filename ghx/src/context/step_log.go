@@ -0,0 +1,75 @@
+package context
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aweris/gale/common/log"
+)
+
+const (
+	// StreamStdout is the standard output stream of the step process.
+	StreamStdout = "stdout"
+
+	// StreamStderr is the standard error stream of the step process.
+	StreamStderr = "stderr"
+
+	// stderrMark is the mark added to the lines of the stderr stream in the step log.
+	stderrMark = "[stderr] "
+)
+
+// StepLog is the log file of a step stage. Every line written to the log is prefixed with a timestamp like GitHub raw
+// logs.
+type StepLog struct {
+	file   *os.File
+	writer *log.TimestampWriter
+	stream string // stream is the output stream of the step process currently written to the log
+}
+
+// openStepLog creates the log file of the given stage in the given step run directory. If the file already exists, it's
+// truncated.
+func openStepLog(dir, stage string) (*StepLog, error) {
+	file, err := os.Create(filepath.Join(dir, fmt.Sprintf("%s.log", stage)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &StepLog{file: file, writer: log.NewTimestampWriter(file)}, nil
+}
+
+// SetStream sets the output stream of the step process the next lines belong to. Lines of the stderr stream are marked
+// with [stderr] in the log file. Empty stream resets the stream.
+func (l *StepLog) SetStream(stream string) {
+	l.stream = stream
+}
+
+// Write writes the given bytes to the step log file.
+func (l *StepLog) Write(p []byte) (int, error) {
+	if l.stream != StreamStderr {
+		return l.writer.Write(p)
+	}
+
+	marked := make([]byte, 0, len(p)+len(stderrMark))
+
+	for _, line := range bytes.SplitAfter(p, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		marked = append(marked, stderrMark...)
+		marked = append(marked, line...)
+	}
+
+	if _, err := l.writer.Write(marked); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Close closes the step log file.
+func (l *StepLog) Close() error {
+	return l.file.Close()
+}
@@ -29,3 +29,48 @@ func (c *Context) WithoutGithubPath() *Context {
 	c.Github.Path = ""
 	return c
 }
+
+// WithGithubOutput sets `github.output` from the given environment file. This is path of the temporary file that holds
+// the outputs of the step.
+func (c *Context) WithGithubOutput(path string) *Context {
+	c.Github.Output = path
+
+	return c
+}
+
+// WithoutGithubOutput removes `github.output` from the context.
+func (c *Context) WithoutGithubOutput() *Context {
+	c.Github.Output = ""
+
+	return c
+}
+
+// WithGithubStepSummary sets `github.step_summary` from the given environment file. This is path of the temporary file
+// that holds the summary of the step.
+func (c *Context) WithGithubStepSummary(path string) *Context {
+	c.Github.StepSummary = path
+
+	return c
+}
+
+// WithoutGithubStepSummary removes `github.step_summary` from the context.
+func (c *Context) WithoutGithubStepSummary() *Context {
+	c.Github.StepSummary = ""
+
+	return c
+}
+
+// WithGithubState sets `github.state` from the given environment file. This is path of the temporary file that holds
+// the state of the step.
+func (c *Context) WithGithubState(path string) *Context {
+	c.Github.State = path
+
+	return c
+}
+
+// WithoutGithubState removes `github.state` from the context.
+func (c *Context) WithoutGithubState() *Context {
+	c.Github.State = ""
+
+	return c
+}
@@ -20,25 +20,42 @@ import (
 	"github.com/aweris/gale/common/model"
 )
 
+// LoadActionOpts is the options to load an action.
+type LoadActionOpts struct {
+	Offline   bool                  // Offline prevents downloading remote actions, they must exist in the target directory.
+	Overrides model.ActionOverrides // Overrides is the rules consulted before loading the action.
+}
+
 // LoadActionFromSource loads an action from given source to the target directory. If the source is a local action,
 // the target directory will be the same as the source. If the source is a remote action, the action will be downloaded
-// to the target directory using the source as the reference(e.g. {target}/{owner}/{repo}/{path}@{ref}).
-func LoadActionFromSource(ctx context.Context, client *dagger.Client, source, targetDir string) (*model.CustomAction, error) {
-	var target string
-
-	repo, path, ref, err := parseRepoRef(source)
-	if err != nil {
-		return nil, err
+// to the target directory using the source as the reference(e.g. {target}/{owner}/{repo}/{path}@{ref}). If the source
+// matches one of the override rules, the action is substituted according to the rule before anything is downloaded.
+func LoadActionFromSource(ctx context.Context, client *dagger.Client, source, targetDir string, opts LoadActionOpts) (*model.CustomAction, error) {
+	if rule, ok := opts.Overrides.Match(source); ok {
+		return loadOverrideAction(ctx, client, source, targetDir, opts, rule)
 	}
 
+	var target, path string
+
 	// no need to load action if it is a local action
 	if isLocalAction(source) {
-		target = source
+		abs, err := filepath.Abs(source)
+		if err != nil {
+			return nil, err
+		}
+
+		target = abs
 	} else {
+		repo, p, ref, err := parseRepoRef(source)
+		if err != nil {
+			return nil, err
+		}
+
+		path = p
 		target = filepath.Join(targetDir, source)
 
 		// ensure action exists locally -- FIXME: source just passed for logging purposes, should be refactored
-		if err := ensureActionExistsLocally(source, repo, ref, target); err != nil {
+		if err := ensureActionExistsLocally(source, repo, ref, target, opts.Offline); err != nil {
 			return nil, err
 		}
 	}
@@ -54,7 +71,50 @@ func LoadActionFromSource(ctx context.Context, client *dagger.Client, source, ta
 		return nil, err
 	}
 
-	return &model.CustomAction{Meta: meta, Path: target}, nil
+	// path of the action is the directory containing the action metadata file, not the repository root
+	return &model.CustomAction{Meta: meta, Path: filepath.Join(target, path)}, nil
+}
+
+// loadOverrideAction loads the action substituting the given source according to the override rule. Redirected actions
+// are loaded from the new source, no-op and stub run rules are replaced with a composite action returning the
+// configured outputs.
+func loadOverrideAction(ctx context.Context, client *dagger.Client, source, targetDir string, opts LoadActionOpts, rule *model.ActionOverride) (*model.CustomAction, error) {
+	if rule.Redirect != "" {
+		// overrides are not applied to the redirected source to avoid redirect loops
+		ca, err := LoadActionFromSource(ctx, client, rule.Redirect, targetDir, LoadActionOpts{Offline: opts.Offline})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load redirected action %s: %w", rule.Redirect, err)
+		}
+
+		ca.Override = rule.String()
+
+		return ca, nil
+	}
+
+	outputs := make(map[string]model.CustomActionOutput, len(rule.Outputs))
+
+	for k, v := range rule.Outputs {
+		outputs[k] = model.CustomActionOutput{Value: v}
+	}
+
+	var steps []model.Step
+
+	if rule.Run != "" {
+		shell := rule.Shell
+		if shell == "" {
+			shell = "bash"
+		}
+
+		steps = append(steps, model.Step{ID: "stub", Name: "Run stub script", Run: rule.Run, Shell: shell})
+	}
+
+	meta := model.CustomActionMeta{
+		Name:    source,
+		Outputs: outputs,
+		Runs:    model.CustomActionRuns{Using: model.ActionRunsUsingComposite, Steps: steps},
+	}
+
+	return &model.CustomAction{Meta: meta, Override: rule.String()}, nil
 }
 
 // isLocalAction checks if the given source is a local action
@@ -63,8 +123,8 @@ func isLocalAction(source string) bool {
 }
 
 // ensureActionExistsLocally ensures that the action exists locally. If the action does not exist locally, it will be
-// downloaded from the source to the target directory.
-func ensureActionExistsLocally(source, repo, ref, target string) error {
+// downloaded from the source to the target directory unless offline is true.
+func ensureActionExistsLocally(source, repo, ref, target string, offline bool) error {
 	// check if action exists locally
 	exist, err := fs.Exists(target)
 	if err != nil {
@@ -77,6 +137,10 @@ func ensureActionExistsLocally(source, repo, ref, target string) error {
 		return nil
 	}
 
+	if offline {
+		return fmt.Errorf("action %s not found in the actions directory, vendor the actions before running offline", source)
+	}
+
 	log.Debugf("action does not exist locally, downloading...", "source", source, "target", target)
 
 	url := fmt.Sprintf("https://github.com/%s.git", repo)
@@ -24,7 +24,7 @@ func TestCustomActionManager_GetCustomAction(t *testing.T) {
 	}
 
 	t.Run("download missing action", func(t *testing.T) {
-		ca, err := LoadActionFromSource(ctx, client, "actions/checkout@v2", filepath.Join(dir, "actions"))
+		ca, err := LoadActionFromSource(ctx, client, "actions/checkout@v2", filepath.Join(dir, "actions"), LoadActionOpts{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -56,7 +56,7 @@ func TestCustomActionManager_GetCustomAction(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		ca, err := LoadActionFromSource(ctx, client, "some/action@v1", filepath.Join(dir, "actions"))
+		ca, err := LoadActionFromSource(ctx, client, "some/action@v1", filepath.Join(dir, "actions"), LoadActionOpts{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -69,4 +69,14 @@ func TestCustomActionManager_GetCustomAction(t *testing.T) {
 			t.Fatal("action name mismatch")
 		}
 	})
+	t.Run("fail missing action in offline mode", func(t *testing.T) {
+		_, err := LoadActionFromSource(ctx, client, "some/missing-action@v1", filepath.Join(dir, "actions"), LoadActionOpts{Offline: true})
+		if err == nil {
+			t.Fatal("expected error for missing action in offline mode")
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, "actions", "some/missing-action@v1")); !os.IsNotExist(err) {
+			t.Fatal("action should not be downloaded in offline mode")
+		}
+	})
 }
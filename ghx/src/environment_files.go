@@ -5,7 +5,6 @@ import (
 	stdContext "context"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 
 	"ghx/context"
@@ -18,6 +17,7 @@ const (
 	EnvFileNameGithubPath        = "GITHUB_PATH"
 	EnvFileNameGithubStepSummary = "GITHUB_STEP_SUMMARY"
 	EnvFileNameGithubOutput      = "GITHUB_OUTPUT"
+	EnvFileNameGithubState       = "GITHUB_STATE"
 )
 
 // EnvironmentFile represents a generated temporary file that can be used to perform certain actions. This struct is
@@ -43,53 +43,53 @@ type EnvironmentFiles struct {
 	Path        EnvironmentFile // Path is the environment file that holds the path variables
 	Outputs     EnvironmentFile // Outputs is the environment file that holds the outputs
 	StepSummary EnvironmentFile // StepSummary is the environment file that holds the step summary
+	State       EnvironmentFile // State is the environment file that holds the state of the step
 }
 
 func (ef *EnvironmentFiles) Process(ctx *context.Context) error {
 	env, err := ef.Env.ReadData(ctx.Context)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to process %s: %w", EnvFileNameGithubEnv, err)
 	}
 
 	for k, v := range env {
-		if err := os.Setenv(k, v); err != nil {
-			return err
-		}
-		// FIXME: for now it's just reporting but we should use this as source of truth for step extra env
 		if err := ctx.SetStepEnv(k, v); err != nil {
 			return err
 		}
 	}
 
-	paths, err := ef.Path.ReadData(ctx.Context)
+	rawPaths, err := ef.Path.RawData(ctx.Context)
 	if err != nil {
 		return err
 	}
 
-	path := os.Getenv("PATH")
-
-	for p := range paths {
-		path = fmt.Sprintf("%s:%s", path, p)
-
-		// FIXME: for now it's just reporting but we should use this as source of truth for step path
-		if err := ctx.AddStepPath(p); err != nil {
+	// paths are prepended in the order they are written, so the last written path takes precedence
+	for _, p := range readLines(rawPaths) {
+		if err := prependPath(ctx, p); err != nil {
 			return err
 		}
 	}
 
-	if err := os.Setenv("PATH", path); err != nil {
-		return err
-	}
-
 	outputs, err := ef.Outputs.ReadData(ctx.Context)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to process %s: %w", EnvFileNameGithubOutput, err)
 	}
 
 	for k, v := range outputs {
 		ctx.SetStepOutput(k, v)
 	}
 
+	state, err := ef.State.ReadData(ctx.Context)
+	if err != nil {
+		return fmt.Errorf("failed to process %s: %w", EnvFileNameGithubState, err)
+	}
+
+	for k, v := range state {
+		if err := ctx.SetStepState(k, v); err != nil {
+			return err
+		}
+	}
+
 	stepSummary, err := ef.StepSummary.RawData(ctx.Context)
 	if err != nil {
 		return err
@@ -100,69 +100,108 @@ func (ef *EnvironmentFiles) Process(ctx *context.Context) error {
 	return nil
 }
 
+// prependPath prepends the given directory to the PATH of the following steps and records it as a path added by the
+// step.
+func prependPath(ctx *context.Context, dir string) error {
+	if err := ctx.AddStepPath(dir); err != nil {
+		return err
+	}
+
+	ctx.PrependPath(dir)
+
+	return nil
+}
+
+// readLines returns the non-empty lines of the given data in order. Leading and trailing whitespaces are removed.
+func readLines(data string) []string {
+	var lines []string
+
+	for _, line := range strings.Split(data, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// read reads the key value pairs from the given environment file content. Values are either in `{name}={value}` or
+// in heredoc `{name}<<{delimiter}` format. Lines of heredoc values are kept as they are, including the empty lines.
+//
+// Same as GitHub, names and heredoc delimiters must not be empty and heredoc values must be terminated with the
+// delimiter. Lines without a value, e.g. `{name}`, are read as keys with empty values.
 func read(r io.Reader) (map[string]string, error) {
 	keyValues := make(map[string]string)
 
 	scanner := bufio.NewScanner(r)
 
-	var (
-		inMultiLineValue bool            // indicates if the scanner is currently processing a multi-line value
-		currentKey       string          // current key of the multi-line value
-		endMarker        string          // end marker of the multi-line value
-		valueBuilder     strings.Builder // builder to build the multi-line value
-	)
+	// values could be long, e.g. json outputs, so increase the max line size
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxOutputLineSize)
 
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+		line := strings.TrimSuffix(scanner.Text(), "\r")
 
 		// Skip empty lines
-		if line == "" {
+		if strings.TrimSpace(line) == "" {
 			continue
 		}
 
-		// Check if the line contains "<<" indicating the start of a multi-line value
-		if strings.Contains(line, "<<") {
-			if inMultiLineValue {
-				return nil, fmt.Errorf("unexpected '<<' in line: %s", line)
+		equalsIndex := strings.Index(line, "=")
+		heredocIndex := strings.Index(line, "<<")
+
+		// line is a key value pair, if "=" is before "<<" like `key=value<<EOF`
+		if equalsIndex >= 0 && (heredocIndex < 0 || equalsIndex < heredocIndex) {
+			key := strings.TrimSpace(line[:equalsIndex])
+			if key == "" {
+				return nil, fmt.Errorf("invalid format '%s', name must not be empty", line)
 			}
 
-			parts := strings.SplitN(line, "<<", 2)
-			currentKey = strings.TrimSpace(parts[0])
-			valueBuilder.Reset()
-			inMultiLineValue = true
+			keyValues[key] = strings.TrimSpace(line[equalsIndex+1:])
 
-			// Extract the end marker from the line
-			endMarker = strings.TrimSpace(parts[1])
+			continue
+		}
 
+		// line has no value, e.g. "path" values in GITHUB_PATH
+		if heredocIndex < 0 {
+			keyValues[strings.TrimSpace(line)] = ""
 			continue
 		}
 
-		// Check if there is active multi-line value processing
-		if inMultiLineValue {
-			// Check if the line is the end of the multi-line value
-			if strings.TrimSpace(line) == endMarker {
-				inMultiLineValue = false
-				value := strings.TrimSpace(strings.TrimSuffix(valueBuilder.String(), "\n"))
-				keyValues[currentKey] = value
-			} else {
-				valueBuilder.WriteString(line + "\n") // scanner removes the new line character, so we need to add it back
+		key := strings.TrimSpace(line[:heredocIndex])
+		if key == "" {
+			return nil, fmt.Errorf("invalid format '%s', name must not be empty", line)
+		}
+
+		delimiter := strings.TrimSpace(line[heredocIndex+2:])
+		if delimiter == "" {
+			return nil, fmt.Errorf("invalid format '%s', delimiter must not be empty", line)
+		}
+
+		var (
+			lines      []string
+			terminated bool
+		)
+
+		for scanner.Scan() {
+			valueLine := strings.TrimSuffix(scanner.Text(), "\r")
+
+			if valueLine == delimiter {
+				terminated = true
+				break
 			}
 
-			continue
+			lines = append(lines, valueLine)
 		}
 
-		// split key and value by "="
-		parts := strings.SplitN(line, "=", 2)
-
-		// if there is no "=" in the line, then it is a key without value (e.g. "path" values in GITHUB_PATH)
-		if len(parts) == 2 {
-			key := strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
-			keyValues[key] = value
-		} else if len(parts) == 1 {
-			key := strings.TrimSpace(parts[0])
-			keyValues[key] = ""
+		if !terminated {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+
+			return nil, fmt.Errorf("invalid value for '%s', matching delimiter not found '%s'", key, delimiter)
 		}
+
+		keyValues[key] = strings.Join(lines, "\n")
 	}
 
 	if err := scanner.Err(); err != nil {
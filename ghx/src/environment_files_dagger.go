@@ -15,7 +15,8 @@ func NewDaggerEnvironmentFiles(dir string, client *dagger.Client) (*dagger.Direc
 		WithNewFile("env", "").
 		WithNewFile("path", "").
 		WithNewFile("outputs", "").
-		WithNewFile("step_summary", "")
+		WithNewFile("step_summary", "").
+		WithNewFile("state", "")
 
 	// create environment files using the empty files
 	files := &EnvironmentFiles{
@@ -23,6 +24,7 @@ func NewDaggerEnvironmentFiles(dir string, client *dagger.Client) (*dagger.Direc
 		Path:        NewDaggerEnvironmentFile(filepath.Join(dir, "path"), emptyDir.File("path")),
 		Outputs:     NewDaggerEnvironmentFile(filepath.Join(dir, "outputs"), emptyDir.File("outputs")),
 		StepSummary: NewDaggerEnvironmentFile(filepath.Join(dir, "step_summary"), emptyDir.File("step_summary")),
+		State:       NewDaggerEnvironmentFile(filepath.Join(dir, "state"), emptyDir.File("state")),
 	}
 
 	return emptyDir, files
@@ -8,11 +8,17 @@ import (
 	"github.com/aweris/gale/common/fs"
 )
 
-// NewLocalEnvironmentFiles creates a new environment files in the given directory path.
-func NewLocalEnvironmentFiles(dir string) (*EnvironmentFiles, error) {
+// NewLocalEnvironmentFiles creates a new environment files in a unique directory under the given directory path. Each
+// execution gets its own files, so values written by a step are not processed again for the following steps.
+func NewLocalEnvironmentFiles(parent string) (*EnvironmentFiles, error) {
 	files := &EnvironmentFiles{}
 
-	if err := fs.EnsureDir(dir); err != nil {
+	if err := fs.EnsureDir(parent); err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp(parent, "step-")
+	if err != nil {
 		return nil, err
 	}
 
@@ -44,6 +50,13 @@ func NewLocalEnvironmentFiles(dir string) (*EnvironmentFiles, error) {
 
 	files.StepSummary = summary
 
+	state, err := NewLocalEnvironmentFile(filepath.Join(dir, "state"))
+	if err != nil {
+		return nil, err
+	}
+
+	files.State = state
+
 	return files, nil
 }
 
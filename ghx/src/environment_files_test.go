@@ -4,6 +4,8 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"dagger.io/dagger"
@@ -206,3 +208,74 @@ More text here`
 		t.Errorf("Expected raw data to be '%s', but got '%s'", testData, rawData)
 	}
 }
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]string
+		wantErr  string
+	}{
+		{
+			name:     "heredoc keeps empty and indented lines",
+			input:    "json<<EOF\n{\n\n  \"foo\": \"bar\"\n}\nEOF\n",
+			expected: map[string]string{"json": "{\n\n  \"foo\": \"bar\"\n}"},
+		},
+		{
+			name:     "value containing heredoc marker",
+			input:    "key=a<<b\n",
+			expected: map[string]string{"key": "a<<b"},
+		},
+		{
+			name:     "windows line endings",
+			input:    "key1=value1\r\nkey2<<EOF\r\nvalue2\r\nEOF\r\n",
+			expected: map[string]string{"key1": "value1", "key2": "value2"},
+		},
+		{
+			name:    "empty name",
+			input:   "=value\n",
+			wantErr: "name must not be empty",
+		},
+		{
+			name:    "empty delimiter",
+			input:   "key<<\nvalue\n",
+			wantErr: "delimiter must not be empty",
+		},
+		{
+			name:    "missing delimiter",
+			input:   "key<<EOF\nvalue\n",
+			wantErr: "matching delimiter not found 'EOF'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := read(strings.NewReader(tt.input))
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing '%s', but got %v", tt.wantErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, but got %v", err)
+			}
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %q, but got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestReadLines(t *testing.T) {
+	result := readLines("/opt/first\n\n  /opt/second  \n/opt/first\n")
+	expected := []string{"/opt/first", "/opt/second", "/opt/first"}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, but got %v", expected, result)
+	}
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/common/model"
+
+	"ghx/context"
+	"ghx/expression"
+)
+
+// maxTraceValueSize is the maximum length of the values printed in the evaluation trace.
+const maxTraceValueSize = 120
+
+// evalOpts are the options of the eval command.
+type evalOpts struct {
+	data     string     // data is the directory of a recorded workflow run
+	job      string     // job is the id of the job in the recorded run to load the contexts from
+	contexts string     // contexts is the path of the JSON file containing the contexts
+	event    string     // event is the path of the event payload
+	env      stringList // env is the list of environment variables in KEY=VALUE format
+	strict   bool       // strict enables strict expression evaluation
+}
+
+// stringList is a flag value collecting the values of a repeated flag.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(val string) error {
+	*s = append(*s, val)
+	return nil
+}
+
+// runEval evaluates the expression given in the args and prints the result and the evaluation trace of the expression
+// to the given writer. Contexts are loaded from a recorded workflow run and the JSON file given with the flags.
+func runEval(args []string, out io.Writer) error {
+	var opts evalOpts
+
+	flags := flag.NewFlagSet("eval", flag.ContinueOnError)
+	flags.SetOutput(out)
+	flags.Usage = func() {
+		fmt.Fprintln(out, "Usage: ghx eval [flags] <expression>")
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&opts.data, "data", "", "Directory of a recorded workflow run to load the contexts from.")
+	flags.StringVar(&opts.job, "job", "", "Job of the recorded workflow run. Only required if the run has more than one job.")
+	flags.StringVar(&opts.contexts, "context", "", "JSON file with the contexts, e.g. {\"steps\": {...}}. Overrides the recorded values.")
+	flags.StringVar(&opts.event, "event", "", "File with the event payload. Overrides the recorded event.")
+	flags.Var(&opts.env, "env", "Environment variable in KEY=VALUE format. Can be repeated.")
+	flags.BoolVar(&opts.strict, "strict", true, "Fails the evaluation if an expression in ${{ }} refers to an undefined property.")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	input := strings.TrimSpace(strings.Join(flags.Args(), " "))
+	if input == "" {
+		flags.Usage()
+		return errors.New("expression is required")
+	}
+
+	contexts, err := loadEvalContexts(opts)
+	if err != nil {
+		return err
+	}
+
+	return evalExpression(out, input, contexts, opts.strict)
+}
+
+// evalExpression evaluates the given input and prints the result and the evaluation trace. Inputs containing ${{ }}
+// are evaluated as string, otherwise the input is evaluated as a single expression, same as if conditions.
+func evalExpression(out io.Writer, input string, vp expression.VariableProvider, strict bool) error {
+	var (
+		result interface{}
+		exprs  []*expression.Expression
+		err    error
+	)
+
+	if strings.Contains(input, "${{") {
+		if exprs, err = expression.ParseExpressions(input); err != nil {
+			return err
+		}
+
+		str := expression.NewString(input)
+
+		if strict {
+			result, err = str.EvalStrict(vp)
+		} else {
+			result = str.Eval(vp)
+		}
+	} else {
+		var expr *expression.Expression
+
+		if expr, err = expression.NewExpression(input); err != nil {
+			return err
+		}
+
+		exprs = append(exprs, expr)
+
+		result, err = expr.Evaluate(vp)
+	}
+
+	for _, expr := range exprs {
+		printTrace(out, expr.Trace(vp), "", "")
+		fmt.Fprintln(out)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Result: %s (%s)\n", formatTraceValue(result, 0), expression.TypeOf(result))
+
+	return nil
+}
+
+// printTrace prints the given trace node and its children as a tree.
+func printTrace(out io.Writer, trace *expression.TraceNode, prefix, childPrefix string) {
+	if trace.Err != nil {
+		fmt.Fprintf(out, "%s%s => error: %v\n", prefix, trace.Expr, trace.Err)
+	} else {
+		fmt.Fprintf(out, "%s%s => %s (%s)\n", prefix, trace.Expr, formatTraceValue(trace.Value, maxTraceValueSize), trace.Type)
+	}
+
+	for idx, child := range trace.Children {
+		if idx == len(trace.Children)-1 {
+			printTrace(out, child, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			printTrace(out, child, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
+
+// formatTraceValue formats the value as JSON. Values longer than the given size are truncated, zero means no limit.
+func formatTraceValue(val interface{}, size int) string {
+	var str string
+
+	if data, err := json.Marshal(val); err == nil {
+		str = string(data)
+	} else {
+		str = fmt.Sprintf("%v", val)
+	}
+
+	if size > 0 && len(str) > size {
+		str = str[:size] + "..."
+	}
+
+	return str
+}
+
+var _ expression.VariableProvider = new(evalVariableProvider)
+
+// evalVariableProvider provides the contexts loaded for the eval command to the expressions.
+type evalVariableProvider map[string]interface{}
+
+func (p evalVariableProvider) GetVariable(name string) (interface{}, error) {
+	switch name {
+	case "infinity":
+		return math.Inf(1), nil
+	case "nan":
+		return math.NaN(), nil
+	}
+
+	val, ok := p[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown variable: %s", name)
+	}
+
+	return val, nil
+}
+
+// object returns the context with the given name as an object. If the context is not an object, it's replaced with
+// an empty one.
+func (p evalVariableProvider) object(name string) map[string]interface{} {
+	obj, ok := p[name].(map[string]interface{})
+	if !ok {
+		obj = make(map[string]interface{})
+		p[name] = obj
+	}
+
+	return obj
+}
+
+// loadEvalContexts loads the contexts for the eval command. Contexts are loaded from the recorded workflow run first,
+// then the contexts file, the event and the environment variables are applied on top of them.
+func loadEvalContexts(opts evalOpts) (evalVariableProvider, error) {
+	contexts := evalVariableProvider{
+		"github":   map[string]interface{}{"event": map[string]interface{}{}},
+		"env":      map[string]interface{}{},
+		"vars":     map[string]interface{}{},
+		"job":      map[string]interface{}{"status": string(model.ConclusionSuccess)},
+		"steps":    map[string]interface{}{},
+		"runner":   map[string]interface{}{},
+		"secrets":  map[string]interface{}{},
+		"strategy": map[string]interface{}{},
+		"matrix":   map[string]interface{}{},
+		"needs":    map[string]interface{}{},
+		"inputs":   map[string]interface{}{},
+	}
+
+	if opts.data != "" {
+		recorded, err := loadRecordedContexts(opts.data, opts.job)
+		if err != nil {
+			return nil, err
+		}
+
+		mergeContexts(contexts, recorded)
+	}
+
+	if opts.contexts != "" {
+		var custom map[string]interface{}
+
+		if err := fs.ReadJSONFile(opts.contexts, &custom); err != nil {
+			return nil, fmt.Errorf("failed to read contexts: %w", err)
+		}
+
+		mergeContexts(contexts, custom)
+	}
+
+	if opts.event != "" {
+		var event map[string]interface{}
+
+		if err := fs.ReadJSONFile(opts.event, &event); err != nil {
+			return nil, fmt.Errorf("failed to read event: %w", err)
+		}
+
+		contexts.object("github")["event"] = event
+	}
+
+	for _, env := range opts.env {
+		key, val, ok := strings.Cut(env, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid env %s, expected KEY=VALUE format", env)
+		}
+
+		contexts.object("env")[key] = val
+	}
+
+	return contexts, nil
+}
+
+// loadRecordedContexts loads the contexts of the given job from the recorded workflow run data. The directory could be
+// the exported data of the workflow run or the run directory itself.
+func loadRecordedContexts(dir, job string) (map[string]interface{}, error) {
+	if exist, _ := fs.Exists(filepath.Join(dir, "run", "workflow.yaml")); exist {
+		dir = filepath.Join(dir, "run")
+	}
+
+	wf, err := LoadWorkflow(context.GhxConfig{}, filepath.Join(dir, "workflow.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow: %w", err)
+	}
+
+	if job == "" {
+		entries, err := os.ReadDir(filepath.Join(dir, "jobs"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jobs: %w", err)
+		}
+
+		var jobs []string
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				jobs = append(jobs, entry.Name())
+			}
+		}
+
+		if len(jobs) != 1 {
+			return nil, fmt.Errorf("job is required, available jobs: %s", strings.Join(jobs, ", "))
+		}
+
+		job = jobs[0]
+	}
+
+	jm, ok := wf.Jobs[job]
+	if !ok {
+		return nil, fmt.Errorf("job %s not found in workflow", job)
+	}
+
+	var report model.JobRunReport
+
+	if err := fs.ReadJSONFile(filepath.Join(dir, "jobs", job, "job_run.json"), &report); err != nil {
+		return nil, fmt.Errorf("failed to read job run: %w", err)
+	}
+
+	event := make(map[string]interface{})
+
+	if exist, _ := fs.Exists(filepath.Join(dir, "event.json")); exist {
+		if err := fs.ReadJSONFile(filepath.Join(dir, "event.json"), &event); err != nil {
+			return nil, fmt.Errorf("failed to read event: %w", err)
+		}
+	}
+
+	env := make(map[string]interface{})
+
+	for k, v := range wf.Env {
+		env[k] = v
+	}
+
+	for k, v := range jm.Env {
+		env[k] = v
+	}
+
+	stepsDir := filepath.Join(dir, "jobs", job, "steps")
+
+	// steps of the matrix jobs are kept per leg, job_run.json is the report of the last leg
+	if len(report.Matrix) > 0 {
+		stepsDir = filepath.Join(dir, "jobs", job, "matrix", report.RunID, "steps")
+	}
+
+	steps, err := loadRecordedSteps(stepsDir, env)
+	if err != nil {
+		return nil, err
+	}
+
+	needs := make(map[string]interface{})
+
+	for _, need := range jm.Needs {
+		var nr model.JobRunReport
+
+		if err := fs.ReadJSONFile(filepath.Join(dir, "jobs", need, "job_run.json"), &nr); err != nil {
+			return nil, fmt.Errorf("failed to read job run of %s: %w", need, err)
+		}
+
+		needs[need] = map[string]interface{}{"result": string(nr.Conclusion), "outputs": toContextMap(nr.Outputs)}
+	}
+
+	matrix := make(map[string]interface{})
+
+	for k, v := range report.Matrix {
+		matrix[k] = v
+	}
+
+	strategy := map[string]interface{}{
+		"fail-fast":    report.Strategy.FailFast,
+		"job-index":    report.Strategy.JobIndex,
+		"job-total":    report.Strategy.JobTotal,
+		"max-parallel": report.Strategy.MaxParallel,
+	}
+
+	return map[string]interface{}{
+		"github":   map[string]interface{}{"event": event, "job": job, "workflow": wf.Name},
+		"env":      env,
+		"job":      map[string]interface{}{"status": string(report.Conclusion)},
+		"steps":    steps,
+		"strategy": strategy,
+		"matrix":   matrix,
+		"needs":    needs,
+	}, nil
+}
+
+// loadRecordedSteps loads the steps context from the step run reports in the given directory. Environment variables
+// exported by the steps are added to the given env in execution order.
+func loadRecordedSteps(dir string, env map[string]interface{}) (map[string]interface{}, error) {
+	steps := make(map[string]interface{})
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return steps, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read steps: %w", err)
+	}
+
+	var reports []model.StepRunReport
+
+	// step directories are named as <index>.<id>, sort them by the index to apply the env in execution order
+	sort.Slice(entries, func(i, j int) bool {
+		return stepDirIndex(entries[i].Name()) < stepDirIndex(entries[j].Name())
+	})
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name(), "step_run.json")
+
+		if exist, _ := fs.Exists(path); !exist {
+			continue
+		}
+
+		var report model.StepRunReport
+
+		if err := fs.ReadJSONFile(path, &report); err != nil {
+			return nil, fmt.Errorf("failed to read step run %s: %w", entry.Name(), err)
+		}
+
+		reports = append(reports, report)
+	}
+
+	for _, report := range reports {
+		for k, v := range report.Env {
+			env[k] = v
+		}
+
+		steps[report.ID] = map[string]interface{}{
+			"outputs":    toContextMap(report.Outputs),
+			"outcome":    string(report.Outcome),
+			"conclusion": string(report.Conclusion),
+		}
+	}
+
+	return steps, nil
+}
+
+// stepDirIndex returns the index of the step from the step directory name.
+func stepDirIndex(name string) int {
+	index, _, _ := strings.Cut(name, ".")
+
+	idx, err := strconv.Atoi(index)
+	if err != nil {
+		return math.MaxInt
+	}
+
+	return idx
+}
+
+// toContextMap converts the given string map to a context map.
+func toContextMap(m map[string]string) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+
+	for k, v := range m {
+		result[k] = v
+	}
+
+	return result
+}
+
+// mergeContexts merges the src contexts into dst. Nested objects are merged recursively, other values are replaced.
+func mergeContexts(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcMap, srcOK := v.(map[string]interface{})
+		dstMap, dstOK := dst[k].(map[string]interface{})
+
+		if srcOK && dstOK {
+			mergeContexts(dstMap, srcMap)
+			continue
+		}
+
+		dst[k] = v
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunEval_RecordedRun(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(dir, "run", "workflow.yaml"), `
+name: build
+env:
+  GLOBAL: workflow
+jobs:
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo lint
+  build:
+    runs-on: ubuntu-latest
+    needs: lint
+    env:
+      JOB: build
+    steps:
+      - id: version
+        run: echo version
+      - run: echo build
+`)
+	writeTestFile(t, filepath.Join(dir, "run", "event.json"), `{"ref": "refs/heads/main"}`)
+	writeTestFile(t, filepath.Join(dir, "run", "jobs", "lint", "job_run.json"), `{"conclusion": "success", "outputs": {"report": "ok"}}`)
+	writeTestFile(t, filepath.Join(dir, "run", "jobs", "build", "job_run.json"), `{"run_id": "2", "conclusion": "failure", "matrix": {"os": "linux"}, "strategy": {"fail-fast": true, "job-index": 1, "job-total": 2, "max-parallel": 2}}`)
+	writeTestFile(t, filepath.Join(dir, "run", "jobs", "build", "matrix", "2", "steps", "0.version", "step_run.json"), `{"id": "version", "conclusion": "success", "outcome": "success", "outputs": {"version": "1.2.3"}, "env": {"STEP": "first"}}`)
+	writeTestFile(t, filepath.Join(dir, "run", "jobs", "build", "matrix", "2", "steps", "10.1", "step_run.json"), `{"id": "1", "conclusion": "failure", "outcome": "failure", "env": {"STEP": "second"}}`)
+
+	contexts := filepath.Join(dir, "contexts.json")
+
+	writeTestFile(t, contexts, `{"steps": {"version": {"outputs": {"channel": "stable"}}}}`)
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+		wantErr  string
+	}{
+		{
+			name:     "steps outputs",
+			args:     []string{"--data", dir, "--job", "build", "steps.version.outputs.version == '1.2.3'"},
+			expected: "Result: true (boolean)",
+		},
+		{
+			name:     "env in execution order",
+			args:     []string{"--data", dir, "--job", "build", "format('{0}-{1}-{2}', env.GLOBAL, env.JOB, env.STEP)"},
+			expected: `Result: "workflow-build-second" (string)`,
+		},
+		{
+			name:     "needs, matrix, event and job status",
+			args:     []string{"--data", filepath.Join(dir, "run"), "--job", "build", "${{ needs.lint.outputs.report }}/${{ matrix.os }}/${{ github.event.ref }}/${{ job.status }}"},
+			expected: `Result: "ok/linux/refs/heads/main/failure" (string)`,
+		},
+		{
+			name:     "strategy",
+			args:     []string{"--data", dir, "--job", "build", "format('{0}/{1}', strategy.job-index, strategy.job-total)"},
+			expected: `Result: "1/2" (string)`,
+		},
+		{
+			name:     "contexts file and env flags override recorded values",
+			args:     []string{"--data", dir, "--job", "build", "--context", contexts, "--env", "STEP=flag", "${{ steps.version.outputs.channel }}-${{ steps.version.outputs.version }}-${{ env.STEP }}"},
+			expected: `Result: "stable-1.2.3-flag" (string)`,
+		},
+		{
+			name:    "job required for multiple jobs",
+			args:    []string{"--data", dir, "true"},
+			wantErr: "job is required, available jobs: build, lint",
+		},
+		{
+			name:    "undefined property in strict mode",
+			args:    []string{"${{ steps.build.outputs.version }}"},
+			wantErr: "steps.build.outputs.version is undefined",
+		},
+		{
+			name:     "undefined property without strict mode",
+			args:     []string{"--strict=false", "v${{ steps.build.outputs.version }}"},
+			expected: `Result: "v" (string)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			err := runEval(tt.args, &out)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("runEval() error = %v, want %s", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("runEval() unexpected error = %v, output:\n%s", err, out.String())
+			}
+
+			if !strings.Contains(out.String(), tt.expected) {
+				t.Errorf("runEval() output = %s, want %s", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunEval_Trace(t *testing.T) {
+	var out bytes.Buffer
+
+	if err := runEval([]string{"--env", "NAME=gale", "startsWith(env.NAME, 'ga') || false"}, &out); err != nil {
+		t.Fatalf("runEval() unexpected error = %v", err)
+	}
+
+	expected := `startsWith(env.name, 'ga') || false => true (boolean)
+├── startsWith(env.name, 'ga') => true (boolean)
+│   ├── env.name => "gale" (string)
+│   │   └── env => {"NAME":"gale"} (object)
+│   └── 'ga' => "ga" (string)
+└── false => false (boolean)
+
+Result: true (boolean)
+`
+
+	if out.String() != expected {
+		t.Errorf("runEval() output =\n%s\nwant\n%s", out.String(), expected)
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"os/exec"
@@ -11,20 +10,19 @@ import (
 	"github.com/aweris/gale/common/log"
 
 	"ghx/context"
-	"ghx/expression"
 )
 
 var _ Executor = new(CmdExecutor)
 
 type CmdExecutor struct {
 	args []string          // args to pass to the command
+	dir  string            // dir is the working directory of the command. If empty, current directory is used.
 	cp   *CommandProcessor // cp is the command processor to process workflow commands
-
 }
 
 func NewCmdExecutorFromStepAction(sa *StepAction, entrypoint string) *CmdExecutor {
 	return &CmdExecutor{
-		args: []string{"node", fmt.Sprintf("%s/%s", sa.Action.Path, entrypoint)},
+		args: []string{sa.node.Path, fmt.Sprintf("%s/%s", sa.Action.Path, entrypoint)},
 		cp:   NewCommandProcessor(),
 	}
 }
@@ -32,19 +30,12 @@ func NewCmdExecutorFromStepAction(sa *StepAction, entrypoint string) *CmdExecuto
 func NewCmdExecutorFromStepRun(sr *StepRun) *CmdExecutor {
 	return &CmdExecutor{
 		args: append([]string{sr.Shell}, sr.ShellArgs...),
+		dir:  sr.Dir,
 		cp:   NewCommandProcessor(),
 	}
 }
 
 func (c *CmdExecutor) Execute(ctx *context.Context) error {
-	// variable provider for the container executor. It returns expression.VariableProvider for the container executor
-	// based on step being executed. If the step is an action, it returns a variable provider that contains the inputs
-	// of the action. Otherwise, it returns the main context as the variable provider.
-	vp := ctx.GetVariableProvider()
-
-	//nolint:gosec // this is a command executor, we need to execute the command as it is
-	cmd := exec.Command(c.args[0], c.args[1:]...)
-
 	envMap := make(map[string]string)
 
 	// load environment files - this will create env files and load it to the environment. That's why we need to do this
@@ -58,32 +49,37 @@ func (c *CmdExecutor) Execute(ctx *context.Context) error {
 	envMap[EnvFileNameGithubPath] = efs.Path.Path()
 	envMap[EnvFileNameGithubOutput] = efs.Outputs.Path()
 	envMap[EnvFileNameGithubStepSummary] = efs.StepSummary.Path()
+	envMap[EnvFileNameGithubState] = efs.State.Path()
 
 	// update the expression context with the environment files
-	ctx.WithGithubEnv(efs.Env.Path()).WithGithubPath(efs.Path.Path())
+	ctx.WithGithubEnv(efs.Env.Path()).
+		WithGithubPath(efs.Path.Path()).
+		WithGithubOutput(efs.Outputs.Path()).
+		WithGithubStepSummary(efs.StepSummary.Path()).
+		WithGithubState(efs.State.Path())
 	defer func() {
-		ctx.WithoutGithubEnv().WithoutGithubPath()
+		ctx.WithoutGithubEnv().WithoutGithubPath().WithoutGithubOutput().WithoutGithubStepSummary().WithoutGithubState()
 	}()
 
 	// add environment variables
 
-	if ctx.Execution.CurrentAction != nil {
-		for k, v := range ctx.Execution.StepRun.Step.With {
-			envMap[fmt.Sprintf("INPUT_%s", strings.ToUpper(k))] = v
-		}
+	inputs, err := ctx.GetActionInputs()
+	if err != nil {
+		return err
+	}
 
-		// add default values for inputs that are not defined in the step config
-		for k, v := range ctx.Execution.CurrentAction.Meta.Inputs {
-			if _, ok := ctx.Execution.StepRun.Step.With[k]; ok {
-				continue
-			}
+	for k, v := range inputs {
+		envMap[fmt.Sprintf("INPUT_%s", strings.ToUpper(k))] = v
+	}
 
-			if v.Default == "" {
-				continue
-			}
+	// github context of the step, e.g. GITHUB_ACTION
+	for k, v := range ctx.GithubStepEnv() {
+		envMap[k] = v
+	}
 
-			envMap[fmt.Sprintf("INPUT_%s", strings.ToUpper(k))] = v.Default
-		}
+	// actions runtime of the step, e.g. job scoped ACTIONS_RUNTIME_TOKEN
+	for k, v := range ctx.ActionsStepEnv() {
+		envMap[k] = v
 	}
 
 	// add step state to the environment
@@ -91,53 +87,80 @@ func (c *CmdExecutor) Execute(ctx *context.Context) error {
 		envMap[fmt.Sprintf("STATE_%s", k)] = v
 	}
 
-	// add step level environment variables
+	// add the env context, values are already evaluated when the workflow, job and step scopes are entered
 	for k, v := range ctx.Env {
 		envMap[k] = v
 	}
 
-	env := os.Environ()
+	// directories added by the previous steps using GITHUB_PATH are prepended to the PATH of the process
+	path, ok := envMap["PATH"]
+	if !ok {
+		path = os.Getenv("PATH")
+	}
 
-	for k, v := range envMap {
-		// convert value to Evaluable String type
-		str := expression.NewString(v)
+	if stepPath := ctx.StepPath(path); stepPath != path {
+		envMap["PATH"] = stepPath
+	}
 
-		// evaluate the expression
-		res := str.Eval(vp)
+	if err := ctx.SetStepEffectiveEnv(envMap); err != nil {
+		return err
+	}
 
-		log.Debugf("Environment variable evaluated", "key", k, "value", v, "evaluated", res)
+	name := c.args[0]
 
-		env = append(env, fmt.Sprintf("%s=%s", k, res))
+	// the command is resolved with the PATH of the step, e.g. a shell installed by a previous step
+	if resolved, err := lookPath(name, ctx.StepPath(path)); err == nil {
+		name = resolved
 	}
 
-	cmd.Env = env
+	//nolint:gosec // this is a command executor, we need to execute the command as it is
+	cmd := exec.Command(name, c.args[1:]...)
+	cmd.Dir = c.dir
 
-	stdoutPipe, err := cmd.StdoutPipe()
-	if err != nil {
-		return err
-	}
+	cmd.Env = stepShellEnv(envMap)
 
-	err = cmd.Start()
-	if err != nil {
+	// both streams are handled by the same ordered output to keep the order of the lines between the streams
+	output := newOrderedOutput(func(line outputLine) {
+		if err := c.cp.ProcessStreamOutput(ctx, line.stream, line.text); err != nil {
+			log.Errorf("failed to process output", "output", line.text, "error", err)
+		}
+	})
+
+	cmd.Stdout = output.Writer(context.StreamStdout)
+	cmd.Stderr = output.Writer(context.StreamStderr)
+
+	if err := cmd.Start(); err != nil {
 		return err
 	}
 
-	go func() {
-		scanner := bufio.NewScanner(stdoutPipe)
-		for scanner.Scan() {
-			output := scanner.Text()
+	// wait returns after the streams are read completely
+	waitErr := cmd.Wait()
+
+	output.Flush()
+
+	c.cp.EndGroups()
 
-			if err := c.cp.ProcessOutput(ctx, output); err != nil {
-				log.Errorf("failed to process output", "output", output, "error", err)
+	// keep the exact environment of the failed step to be able to re-create it in an interactive shell
+	if waitErr != nil && ctx.GhxConfig.InteractiveOnFailure {
+		dir := cmd.Dir
+		if dir == "" {
+			if dir, err = os.Getwd(); err != nil {
+				return err
 			}
 		}
-	}()
 
-	waitErr := cmd.Wait()
+		if err := ctx.SetStepShell(cmd.Env, dir); err != nil {
+			return err
+		}
+	}
 
 	if err := efs.Process(ctx); err != nil {
 		return err
 	}
 
-	return waitErr
+	if waitErr != nil {
+		return waitErr
+	}
+
+	return c.cp.Err()
 }
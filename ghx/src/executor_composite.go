@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/common/model"
+	"github.com/aweris/gale/common/task"
+
+	"ghx/context"
+)
+
+var _ Executor = new(CompositeExecutor)
+
+// maxCompositeDepth is the maximum nesting level of composite actions. It prevents infinite recursion when composite
+// actions use each other.
+const maxCompositeDepth = 10
+
+// CompositeExecutor executes the steps of a composite action in the scope of the calling step.
+type CompositeExecutor struct {
+	action *model.CustomAction // action is the composite action to execute
+}
+
+func NewCompositeExecutorFromStepAction(sa *StepAction) *CompositeExecutor {
+	return &CompositeExecutor{action: &sa.Action}
+}
+
+func (c *CompositeExecutor) Execute(ctx *context.Context) error {
+	if scope := ctx.Execution.Composite; scope != nil && scope.Depth >= maxCompositeDepth {
+		return fmt.Errorf("composite actions can be nested up to %d levels", maxCompositeDepth)
+	}
+
+	setupFns, main, post, err := planCompositeSteps(c.action.Meta.Runs.Steps, NewStepOpts(ctx))
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.EnterComposite(c.action); err != nil {
+		return err
+	}
+
+	runErr := runCompositeSteps(ctx, setupFns, main, post)
+
+	// outputs are evaluated in the scope of the composite action since they refer to the steps of the composite action
+	var (
+		vp      = ctx.GetStepVariableProvider()
+		outputs = make(map[string]string, len(c.action.Meta.Outputs))
+	)
+
+	var outputErr error
+
+	for k, v := range c.action.Meta.Outputs {
+		val, err := ctx.EvalString(vp, v.Value)
+		if err != nil {
+			outputErr = errors.Join(outputErr, fmt.Errorf("failed to evaluate output %s: %w", k, err))
+			continue
+		}
+
+		outputs[k] = val
+	}
+
+	if err := ctx.ExitComposite(); err != nil {
+		return err
+	}
+
+	if outputErr != nil {
+		return errors.Join(runErr, outputErr)
+	}
+
+	for k, v := range outputs {
+		if err := ctx.SetStepOutput(k, v); err != nil {
+			return err
+		}
+	}
+
+	return runErr
+}
+
+// planCompositeSteps plans the steps of a composite action. Unlike jobs, pre hooks of the actions used in composite
+// actions are not executed and post hooks are executed right after the steps of the composite action, same as GitHub
+// does.
+func planCompositeSteps(steps []model.Step, opts StepOpts) ([]task.RunFn[context.Context], []task.Runner[context.Context], []task.Runner[context.Context], error) {
+	var (
+		setupFns = make([]task.RunFn[context.Context], 0)
+		main     = make([]task.Runner[context.Context], 0)
+		post     = make([]task.Runner[context.Context], 0)
+	)
+
+	for idx, step := range steps {
+		step.Index = fmt.Sprintf("%d", idx)
+
+		if step.ID == "" {
+			step.ID = step.Index
+		}
+
+		sr, err := NewStep(step, opts)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		if setup, ok := sr.(SetupHook); ok {
+			setupFns = append(setupFns, setup.setup())
+		}
+
+		prefix := ""
+		if step.Name == "" {
+			prefix = "Run"
+		}
+
+		main = append(main, task.New(getStepName(prefix, step), sr.main(), newTaskOptsForStep(sr, step, model.StepStageMain, sr.condition())))
+
+		// post hooks are executed in reverse order of the steps
+		if hook, ok := sr.(PostHook); ok {
+			opt := newTaskOptsForStep(sr, step, model.StepStagePost, hook.postCondition())
+			post = append([]task.Runner[context.Context]{task.New(getStepName("Post", step), hook.post(), opt)}, post...)
+		}
+	}
+
+	return setupFns, main, post, nil
+}
+
+// runCompositeSteps runs the planned steps of the composite action and returns an error if any of the steps fails.
+func runCompositeSteps(ctx *context.Context, setupFns []task.RunFn[context.Context], tasks ...[]task.Runner[context.Context]) error {
+	// load the actions used by the steps before running any step, same as the job setup
+	for _, setupFn := range setupFns {
+		if _, err := setupFn(ctx); err != nil {
+			return err
+		}
+	}
+
+	var stepErr error
+
+	for _, runners := range tasks {
+		for _, te := range runners {
+			result, err := te.Run(ctx)
+
+			// no need to continue if the task taskRunner did not run.
+			if !result.Ran {
+				continue
+			}
+
+			if err != nil {
+				log.Errorf(te.Name, "error", err)
+
+				if stepErr == nil {
+					stepErr = fmt.Errorf("%s: %w", te.Name, err)
+				}
+			}
+
+			// status of the composite action is used to evaluate the conditions of the following steps
+			if ctx.Job.Status == model.ConclusionSuccess && result.Conclusion != ctx.Job.Status {
+				ctx.Job.Status = result.Conclusion
+			}
+		}
+	}
+
+	if ctx.Job.Status != model.ConclusionFailure {
+		return nil
+	}
+
+	if stepErr == nil {
+		stepErr = errors.New("one or more steps failed")
+	}
+
+	return fmt.Errorf("composite action failed: %w", stepErr)
+}
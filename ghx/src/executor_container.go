@@ -4,23 +4,39 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"dagger.io/dagger"
 
+	"github.com/aweris/gale/common/fs"
 	"github.com/aweris/gale/common/log"
 
 	"ghx/context"
-	"ghx/expression"
 )
 
 var _ Executor = new(ContainerExecutor)
 
+// containerGhxPath is the path of the ghx binary mounted to the containers to run the processes with `ghx stream`.
+const containerGhxPath = "/usr/local/gale/ghx"
+
+// containerExitCodePath is the path of the file `ghx stream` records the exit code of the process in the container.
+const containerExitCodePath = containerWorkflowPath + "/.ghx_exit_code"
+
+// Paths of the directories mounted to the containers of docker actions. Same paths are used by GitHub.
+const (
+	containerWorkspacePath = "/github/workspace"
+	containerHomePath      = "/github/home"
+	containerWorkflowPath  = "/github/workflow"
+)
+
 type ContainerExecutor struct {
 	container  *dagger.Container // container is the container to execute
 	entrypoint string            // entrypoint is the entrypoint of the container
 	args       []string          // args is the arguments of the container
+	splitArgs  bool              // splitArgs indicates that args are command lines to split into arguments
 	cp         *CommandProcessor // cp is the command processor to process workflow commands
 }
 
@@ -28,6 +44,7 @@ func NewContainerExecutorFromStepDocker(sd *StepDocker) *ContainerExecutor {
 	return &ContainerExecutor{
 		entrypoint: sd.Step.With["entrypoint"],
 		args:       []string{sd.Step.With["args"]},
+		splitArgs:  true,
 		cp:         NewCommandProcessor(),
 		container:  sd.container,
 	}
@@ -46,33 +63,53 @@ func (c *ContainerExecutor) Execute(ctx *context.Context) error {
 	// variable provider for the container executor. It returns expression.VariableProvider for the container executor
 	// based on step being executed. If the step is an action, it returns a variable provider that contains the inputs
 	// of the action. Otherwise, it returns the main context as the variable provider.
-	vp := ctx.GetVariableProvider()
+	vp, err := ctx.GetVariableProvider()
+	if err != nil {
+		return err
+	}
+
+	// default environment of the runner, paths are replaced with the paths in the container
+	for k, v := range defaultContainerEnv() {
+		c.container = c.container.WithEnvVariable(k, v)
+	}
 
-	// load environment files - this will create env files and load it to the environment. That's why we need to do this
-	// before setting the environment variables
 	// load environment files - this will create env files and load it to the environment. That's why we need to do this
 	// before setting the environment variables
 	dir, efs := NewDaggerEnvironmentFiles(filepath.Join(ctx.Runner.Temp, "env_files"), ctx.Dagger.Client)
 
-	c.container = c.container.WithMountedDirectory(filepath.Join(ctx.Runner.Temp, "env_files"), dir).
-		WithEnvVariable(EnvFileNameGithubEnv, efs.Env.Path()).
-		WithEnvVariable(EnvFileNameGithubPath, efs.Path.Path()).
-		WithEnvVariable(EnvFileNameGithubOutput, efs.Outputs.Path()).
-		WithEnvVariable(EnvFileNameGithubStepSummary, efs.StepSummary.Path())
+	c.container = c.container.WithMountedDirectory(filepath.Join(ctx.Runner.Temp, "env_files"), dir)
 
 	// update the expression context with the environment files
-	ctx.WithGithubEnv(efs.Env.Path()).WithGithubPath(efs.Path.Path())
+	ctx.WithGithubEnv(efs.Env.Path()).
+		WithGithubPath(efs.Path.Path()).
+		WithGithubOutput(efs.Outputs.Path()).
+		WithGithubStepSummary(efs.StepSummary.Path()).
+		WithGithubState(efs.State.Path())
 	defer func() {
-		ctx.WithoutGithubEnv().WithoutGithubPath()
+		ctx.WithoutGithubEnv().WithoutGithubPath().WithoutGithubOutput().WithoutGithubStepSummary().WithoutGithubState()
 	}()
 
+	home, workflow, err := ensureContainerDirs(ctx)
+	if err != nil {
+		return err
+	}
+
+	// mount the directories at execution time instead of the setup, otherwise the container would not see the changes
+	// made by the previous steps
+	c.container = c.container.
+		WithMountedDirectory(containerWorkspacePath, ctx.Dagger.Client.Host().Directory(ctx.Github.Workspace)).
+		WithMountedDirectory(containerHomePath, ctx.Dagger.Client.Host().Directory(home)).
+		WithMountedDirectory(containerWorkflowPath, ctx.Dagger.Client.Host().Directory(workflow)).
+		WithWorkdir(containerWorkspacePath)
+
 	entrypoint := c.entrypoint
 
 	if entrypoint != "" {
-		res := expression.NewString(entrypoint)
-
 		// evaluate the expression
-		entrypoint := res.Eval(ctx)
+		entrypoint, err := ctx.EvalString(vp, entrypoint)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate entrypoint: %w", err)
+		}
 
 		log.Debugf("entrypoint evaluated", "original", c.entrypoint, "evaluated", entrypoint)
 
@@ -82,48 +119,73 @@ func (c *ContainerExecutor) Execute(ctx *context.Context) error {
 	var args []string
 
 	for _, arg := range c.args {
-		str := expression.NewString(arg)
-
 		// evaluate the expression
-		res := str.Eval(vp)
+		res, err := ctx.EvalString(vp, arg)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate args: %w", err)
+		}
 
 		log.Debugf("arg evaluated", "original", arg, "evaluated", res)
 
-		args = append(args, strings.Split(res, " ")...) // TODO: this is not correct, we need to parse the string as shell does
-	}
+		// args of the action metadata are already separate arguments, only command lines need to be split
+		if !c.splitArgs {
+			args = append(args, res)
+			continue
+		}
 
-	if entrypoint != "" {
-		c.container = c.container.WithEntrypoint([]string{entrypoint})
+		split, err := splitArgs(res)
+		if err != nil {
+			return fmt.Errorf("invalid args %q: %w", res, err)
+		}
+
+		args = append(args, split...)
 	}
 
-	if len(args) > 0 {
-		c.container = c.container.WithExec(args, dagger.ContainerWithExecOpts{ExperimentalPrivilegedNesting: true})
-	} else {
-		c.container = c.container.WithExec(nil, dagger.ContainerWithExecOpts{ExperimentalPrivilegedNesting: true})
+	if c.entrypoint != "" {
+		c.container = c.container.WithEntrypoint([]string{c.entrypoint})
 	}
 
 	env := make(map[string]string)
 
+	// github context of the step, e.g. GITHUB_ACTION
+	for k, v := range ctx.GithubStepEnv() {
+		env[k] = v
+	}
+
+	// paths of the github context are replaced with the paths in the container
+	env["GITHUB_WORKSPACE"] = containerWorkspacePath
+	env["GITHUB_EVENT_PATH"] = filepath.Join(containerWorkflowPath, "event.json")
+	env[EnvFileNameGithubEnv] = efs.Env.Path()
+	env[EnvFileNameGithubPath] = efs.Path.Path()
+	env[EnvFileNameGithubOutput] = efs.Outputs.Path()
+	env[EnvFileNameGithubStepSummary] = efs.StepSummary.Path()
+	env[EnvFileNameGithubState] = efs.State.Path()
+
+	// actions runtime of the step, e.g. job scoped ACTIONS_RUNTIME_TOKEN
+	for k, v := range ctx.ActionsStepEnv() {
+		env[k] = v
+	}
+
 	if ctx.Execution.CurrentAction != nil {
+		// env of the action metadata is evaluated in the scope of the action, unlike the env context
 		for k, v := range ctx.Execution.CurrentAction.Meta.Runs.Env {
-			env[k] = v
-		}
+			res, err := ctx.EvalString(vp, v)
+			if err != nil {
+				return fmt.Errorf("failed to evaluate environment variable %s: %w", k, err)
+			}
 
-		for k, v := range ctx.Execution.StepRun.Step.With {
-			env[fmt.Sprintf("INPUT_%s", strings.ToUpper(k))] = v
-		}
+			log.Debugf("Environment variable evaluated", "key", k, "value", v, "evaluated", res)
 
-		// add default values for inputs that are not defined in the step config
-		for k, v := range ctx.Execution.CurrentAction.Meta.Inputs {
-			if _, ok := ctx.Execution.StepRun.Step.With[k]; ok {
-				continue
-			}
+			env[k] = res
+		}
 
-			if v.Default == "" {
-				continue
-			}
+		inputs, err := ctx.GetActionInputs()
+		if err != nil {
+			return err
+		}
 
-			env[fmt.Sprintf("INPUT_%s", strings.ToUpper(k))] = v.Default
+		for k, v := range inputs {
+			env[fmt.Sprintf("INPUT_%s", strings.ToUpper(k))] = v
 		}
 	}
 
@@ -132,51 +194,236 @@ func (c *ContainerExecutor) Execute(ctx *context.Context) error {
 		env[fmt.Sprintf("STATE_%s", k)] = v
 	}
 
-	// add step level environment variables
+	// add the env context, values are already evaluated when the workflow, job and step scopes are entered
 	for k, v := range ctx.Env {
 		env[k] = v
 	}
 
+	if err := ctx.SetStepEffectiveEnv(env); err != nil {
+		return err
+	}
+
 	for k, v := range env {
 		c.container = c.container.WithEnvVariable(k, v)
 	}
 
+	// containers return the streams separately, so the process is run by `ghx stream` to get both streams in a single
+	// output in order, each line tagged with its stream
+	exec, err := c.streamExec(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	// exec is added after the environment variables, otherwise they are not available to the process
+	c.container = c.container.WithExec(exec, dagger.ContainerWithExecOpts{SkipEntrypoint: true, ExperimentalPrivilegedNesting: true})
+
 	stdout, _ := c.container.Stdout(ctx.Context)
 	stderr, err := c.container.Stderr(ctx.Context)
 
-	out := strings.TrimSpace(strings.Join([]string{strings.TrimSpace(stdout), strings.TrimSpace(stderr)}, ""))
+	// `ghx stream` records the exit code of the process and exits successfully, so the container only fails if the
+	// process couldn't be run at all. Otherwise, the step fails with the recorded exit code.
+	failed := err != nil
+
+	var exitCode int
+
+	if !failed {
+		if exitCode, err = containerExitCode(ctx, c.container); err != nil {
+			return err
+		}
+	}
 
-	failed := false
+	// keep the environment of the failed step to be able to re-create it in an interactive shell. The shell runs on the
+	// runner with the step environment, not in the container of the step, so the tools of the image are not available.
+	if (failed || exitCode != 0) && ctx.GhxConfig.InteractiveOnFailure {
+		if shellErr := ctx.SetStepShell(stepShellEnv(env), ctx.Github.Workspace); shellErr != nil {
+			return shellErr
+		}
+	}
 
 	// it seems that dagger no longer returns the stdout or stderr when the container fails. However, same information
 	// is available in the error message. So, we extract the stdout and stderr from the error message.
-	if out == "" && err != nil {
-		failed = true
-		out = extractLogFromError(err)
+	if strings.TrimSpace(stdout) == "" && strings.TrimSpace(stderr) == "" && failed {
+		stdout = extractLogFromError(err)
 	}
 
-	scanner := bufio.NewScanner(strings.NewReader(out))
-	for scanner.Scan() {
-		output := scanner.Text()
+	// stderr only contains the errors of the `ghx stream` itself, output of the process is in stdout
+	for _, out := range []outputLine{{stream: context.StreamStdout, text: stdout}, {stream: context.StreamStderr, text: stderr}} {
+		scanner := bufio.NewScanner(strings.NewReader(strings.TrimSpace(out.text)))
+		scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 2*maxOutputLineSize)
+
+		for scanner.Scan() {
+			line := parseOutputLine(scanner.Text(), out.stream)
 
-		if err := c.cp.ProcessOutput(ctx, output); err != nil {
-			log.Errorf("failed to process output", "output", output, "error", err)
+			if err := c.cp.ProcessStreamOutput(ctx, line.stream, line.text); err != nil {
+				log.Errorf("failed to process output", "output", line.text, "error", err)
+			}
 		}
 	}
 
+	c.cp.EndGroups()
+
 	if err := efs.Process(ctx); err != nil {
 		return err
 	}
 
 	// if the container failed, return a simple error. Dagger error contains dag information which is not useful for
-	// the user.
+	// the user. Failed containers have no result to export.
 	if failed {
 		return errors.New("step execution encountered an error")
 	}
 
+	// sync the changes made by the process back to the job even if the process failed, e.g. reports of a linter
+	// running with continue-on-error, so the following steps can see them
+	if err := syncContainerDirs(ctx, c.container, home); err != nil {
+		return err
+	}
+
+	if exitCode != 0 {
+		return fmt.Errorf("exit status %d", exitCode)
+	}
+
+	return c.cp.Err()
+}
+
+// containerExitCode returns the exit code of the process recorded by `ghx stream` in the given container.
+func containerExitCode(ctx *context.Context, container *dagger.Container) (int, error) {
+	contents, err := container.File(containerExitCodePath).Contents(ctx.Context)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read exit code of the container: %w", err)
+	}
+
+	code, err := strconv.Atoi(strings.TrimSpace(contents))
+	if err != nil {
+		return 0, fmt.Errorf("invalid exit code of the container %q: %w", contents, err)
+	}
+
+	return code, nil
+}
+
+// defaultContainerEnv returns the default environment of the runner for the containers. GITHUB_*, RUNNER_* and ACTIONS_*
+// variables are passed as they are, except the paths replaced with the paths in the container like GitHub does.
+func defaultContainerEnv() map[string]string {
+	env := map[string]string{"CI": "true"}
+
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+
+		// token is only available to the action if the workflow passes it explicitly
+		if k == "GITHUB_TOKEN" {
+			continue
+		}
+
+		if strings.HasPrefix(k, "GITHUB_") || strings.HasPrefix(k, "RUNNER_") || strings.HasPrefix(k, "ACTIONS_") {
+			env[k] = v
+		}
+	}
+
+	env["HOME"] = containerHomePath
+	env["GITHUB_WORKSPACE"] = containerWorkspacePath
+	env["GITHUB_EVENT_PATH"] = filepath.Join(containerWorkflowPath, "event.json")
+
+	return env
+}
+
+// ensureContainerDirs ensures that the directories mounted to the containers as home and workflow directories exist
+// in the runner temp directory. The home directory is shared between the docker steps of the job like GitHub does.
+func ensureContainerDirs(ctx *context.Context) (home string, workflow string, err error) {
+	home, err = context.EnsureDir(ctx.Runner.Temp, "_github_home")
+	if err != nil {
+		return "", "", err
+	}
+
+	workflow, err = context.EnsureDir(ctx.Runner.Temp, "_github_workflow")
+	if err != nil {
+		return "", "", err
+	}
+
+	if ctx.Github.EventPath != "" {
+		if err := fs.CopyFile(ctx.Github.EventPath, filepath.Join(workflow, "event.json")); err != nil {
+			return "", "", fmt.Errorf("failed to copy event file: %w", err)
+		}
+	}
+
+	return home, workflow, nil
+}
+
+// syncContainerDirs replaces the workspace and the home directory of the job with the ones of the container, so the
+// files added, changed and removed by the container are visible to the following steps like the bind mounts on GitHub.
+func syncContainerDirs(ctx *context.Context, container *dagger.Container, home string) error {
+	if err := replaceDir(ctx, container.Directory(containerWorkspacePath), ctx.Github.Workspace); err != nil {
+		return fmt.Errorf("failed to sync workspace: %w", err)
+	}
+
+	if err := replaceDir(ctx, container.Directory(containerHomePath), home); err != nil {
+		return fmt.Errorf("failed to sync home directory: %w", err)
+	}
+
 	return nil
 }
 
+// replaceDir replaces the content of the dst directory with the content of the given directory. Export only adds and
+// updates files, so the directory is exported to a staging directory first and copied to the emptied dst directory.
+// The dst directory itself is kept since it might be a mount point, e.g. the workspace.
+func replaceDir(ctx *context.Context, dir *dagger.Directory, dst string) error {
+	staging := filepath.Join(ctx.Runner.Temp, "_github_sync")
+
+	if err := os.RemoveAll(staging); err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(staging)
+
+	if _, err := dir.Export(ctx.Context, staging); err != nil {
+		return err
+	}
+
+	return replaceDirContent(staging, dst)
+}
+
+// replaceDirContent removes the content of the dst directory and copies the content of the src directory to it.
+func replaceDirContent(src, dst string) error {
+	entries, err := os.ReadDir(dst)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return copyDir(src, dst)
+}
+
+// streamExec returns the exec of the container running the entrypoint of the container with the given args by the
+// ghx binary mounted to the container. Default args of the image are used if no args are given.
+func (c *ContainerExecutor) streamExec(ctx *context.Context, args []string) ([]string, error) {
+	entrypoint, err := c.container.Entrypoint(ctx.Context)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entrypoint of the container: %w", err)
+	}
+
+	if len(args) == 0 {
+		if args, err = c.container.DefaultArgs(ctx.Context); err != nil {
+			return nil, fmt.Errorf("failed to get default args of the container: %w", err)
+		}
+	}
+
+	ghx, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	c.container = c.container.WithMountedFile(containerGhxPath, ctx.Dagger.Client.Host().File(ghx))
+
+	exec := []string{containerGhxPath, "stream", "--exit-code-file", containerExitCodePath, "--"}
+	exec = append(exec, entrypoint...)
+	exec = append(exec, args...)
+
+	return exec, nil
+}
+
 // extractLogFromError extracts the stdout and stderr from the error message
 func extractLogFromError(err error) string {
 	parts := strings.Split(err.Error(), "Stderr:")
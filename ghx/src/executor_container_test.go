@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplaceDirContent(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+
+	for _, file := range []string{"report.txt", "nested/kept.txt"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(src, file)), 0755); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if err := os.WriteFile(filepath.Join(src, file), []byte("new"), 0600); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+	}
+
+	for _, file := range []string{"removed.txt", "nested/kept.txt"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dst, file)), 0755); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dst, file), []byte("old"), 0600); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+	}
+
+	if err := replaceDirContent(src, dst); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	for _, file := range []string{"report.txt", "nested/kept.txt"} {
+		if data, err := os.ReadFile(filepath.Join(dst, file)); err != nil || string(data) != "new" {
+			t.Errorf("Expected %s to have the content of the source, but got %q and error %v", file, data, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dst, "removed.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected removed.txt to be removed, but got error %v", err)
+	}
+}
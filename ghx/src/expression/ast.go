@@ -24,20 +24,37 @@ func NewString(value string) *String {
 	}
 }
 
-// Eval evaluates the expression and returns the string value.
+// Eval evaluates the expression and returns the string value. Parse and evaluation errors are logged and an empty
+// string is returned.
 func (s *String) Eval(provider VariableProvider) string {
+	str, err := s.eval(provider, false)
+	if err != nil {
+		log.Errorf("failed to evaluate expression", "expression", s.Value, "error", err)
+		return ""
+	}
+
+	return str
+}
+
+// EvalStrict evaluates the expression and returns the string value. Unlike Eval, parse and evaluation errors are
+// returned as *Error and expressions resolving to an undefined property, e.g. `steps.build.outputs.versoin`, are
+// considered as error.
+func (s *String) EvalStrict(provider VariableProvider) (string, error) {
+	return s.eval(provider, true)
+}
+
+func (s *String) eval(provider VariableProvider, strict bool) (string, error) {
 	if s.Quoted {
-		return s.Value
+		return s.Value, nil
 	}
 
 	exprs, err := ParseExpressions(s.Value)
 	if err != nil {
-		log.Errorf("failed to parse expressions", "expression", s.Value, "error", err)
-		return ""
+		return "", err
 	}
 
 	if len(exprs) == 0 {
-		return s.Value
+		return s.Value, nil
 	}
 
 	str := s.Value
@@ -45,22 +62,29 @@ func (s *String) Eval(provider VariableProvider) string {
 	for _, expr := range exprs {
 		val, err := expr.Evaluate(provider)
 		if err != nil {
-			log.Errorf("failed to evaluate expression", "expression", expr, "error", err)
-			return ""
+			return "", err
 		}
 
 		if val == nil {
+			if path, ok := undefinedPath(expr.node); ok && strict {
+				return "", expr.errorAt(expr.node, fmt.Errorf("%s is undefined", path))
+			}
+
 			str = strings.Replace(str, expr.Value, "", 1)
 
 			continue
 		}
 
-		if v, ok := val.(string); ok {
+		// numbers and booleans are converted to string, e.g. `${{ strategy.job-index }}` in a script
+		switch v := val.(type) {
+		case string:
 			str = strings.Replace(str, expr.Value, v, 1)
+		case bool, int, float64:
+			str = strings.Replace(str, expr.Value, fmt.Sprintf("%v", v), 1)
 		}
 	}
 
-	return str
+	return str, nil
 }
 
 // value represents generic value with Github Actions expression support.
@@ -51,6 +51,8 @@ func TestString_Eval(t *testing.T) {
 		{"inline expression", "foobar-${{ github.token }}-baz", "foobar-1234567890-baz"},
 		{"multiple expressions", "foobar-${{ github.token }}-${{ github.token }}-baz", "foobar-1234567890-1234567890-baz"},
 		{"expression with missing variable", "foobar-${{ matrix.foo }}-baz", "foobar--baz"},
+		{"number expression", "shard-${{ 1 }}-of-${{ 2.5 }}", "shard-1-of-2.5"},
+		{"boolean expression", "enabled=${{ github.token == '1234567890' }}", "enabled=true"},
 	}
 
 	for _, tt := range tests {
@@ -71,6 +73,49 @@ func TestString_Eval(t *testing.T) {
 	}
 }
 
+func TestString_EvalStrict(t *testing.T) {
+	ctx := TestContext{
+		Github: context.GithubContext{
+			Token: "1234567890",
+		},
+		Matrix: map[string]string{"os": "linux"},
+	}
+
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+		err      string
+	}{
+		{"simple string", "foobar", "foobar", ""},
+		{"inline expression", "foobar-${{ matrix.os }}-baz", "foobar-linux-baz", ""},
+		{"undefined property", "foobar-${{ matrix.foo }}-baz", "", "expression '${{ matrix.foo }}' at line 1, column 12: matrix.foo is undefined"},
+		{"undefined index", "echo foo\necho ${{ matrix['foo'] }}", "", "expression '${{ matrix['foo'] }}' at line 2, column 10: matrix['foo'] is undefined"},
+		{"unknown variable", "${{ steps.build.outputs.version }}", "", "expression '${{ steps.build.outputs.version }}' at line 1, column 1: unknown variable: steps"},
+		{"unknown struct property", "${{ github.tokn }}", "", "expression '${{ github.tokn }}' at line 1, column 1: property 'tokn' not found in struct"},
+		{"invalid json", "${{ fromJSON('[') }}", "", "expression '${{ fromJSON('[') }}' at line 1, column 1: fromJSON() failed to parse JSON: unexpected end of JSON input"},
+		{"syntax error", "foo ${{ matrix. }}", "", "expression '${{ matrix. }}' at line 1, column 17: unexpected end of input while parsing object property dereference like 'a.b' or array element dereference like 'a.*'. expecting \"IDENT\", \"*\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := expression.NewString(tt.value).EvalStrict(&ctx)
+
+			if tt.err == "" && err != nil {
+				t.Errorf("Expected no error, but got %s", err.Error())
+			}
+
+			if tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Errorf("Expected error %s, but got %v", tt.err, err)
+			}
+
+			if result != tt.expected {
+				t.Errorf("Expected %s, but got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
 func TestBool_Eval(t *testing.T) {
 	ctx := TestContext{
 		Github: context.GithubContext{
@@ -1,6 +1,7 @@
 package expression
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
@@ -26,10 +27,30 @@ type Interpreter interface {
 
 // Expression represents a GitHub expression in a string with position.
 type Expression struct {
-	Value       string      // Value is a raw value of the string.
-	StartIndex  int         // StartIndex is a start index of the expression in the source string.
-	EndIndex    int         // EndIndex is an end index of the expression in the source string.
-	interpreter Interpreter // interpreter is an interpreter for the expression.
+	Value       string              // Value is a raw value of the string.
+	StartIndex  int                 // StartIndex is a start index of the expression in the source string.
+	EndIndex    int                 // EndIndex is an end index of the expression in the source string.
+	Line        int                 // Line is the 1-based line number of the expression in the source string.
+	Column      int                 // Column is the 1-based column number of the expression in the source string.
+	node        actionlint.ExprNode // node is the root node of the parsed expression.
+	interpreter Interpreter         // interpreter is an interpreter for the expression.
+}
+
+// Error is an error occurred while parsing or evaluating an expression. Line and Column point to the failing part of
+// the expression in the source string.
+type Error struct {
+	Expression string // Expression is the raw expression including the ${{ }} syntax.
+	Line       int    // Line is the 1-based line number of the failing part in the source string.
+	Column     int    // Column is the 1-based column number of the failing part in the source string.
+	Err        error  // Err is the underlying error.
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("expression '%s' at line %d, column %d: %v", e.Expression, e.Line, e.Column, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
 }
 
 // NewExpression parses a string and returns an Expression.
@@ -54,13 +75,16 @@ func NewExpression(value string) (*Expression, error) {
 
 	node, err := parser.Parse(lexer)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse expression: %w", err)
+		return nil, newParseError(value, value, 0, err)
 	}
 
 	return &Expression{
 		Value:       value,
 		StartIndex:  0,
 		EndIndex:    len(value) - 1,
+		Line:        1,
+		Column:      1,
+		node:        node,
 		interpreter: getInterpreterFromNode(node),
 	}, nil
 }
@@ -86,13 +110,18 @@ func ParseExpressions(input string) ([]*Expression, error) {
 
 		node, expErr := parser.Parse(lexer)
 		if expErr != nil {
-			return nil, fmt.Errorf("failed to parse expression: %w", expErr)
+			return nil, newParseError(input, value, match[0], expErr)
 		}
 
+		line, column := position(input, match[0])
+
 		expression := Expression{
 			Value:       value,
 			StartIndex:  match[0],
 			EndIndex:    match[1] - 1,
+			Line:        line,
+			Column:      column,
+			node:        node,
 			interpreter: getInterpreterFromNode(node),
 		}
 
@@ -102,7 +131,62 @@ func ParseExpressions(input string) ([]*Expression, error) {
 	return expressions, nil
 }
 
-// Evaluate evaluates the expression and returns the result.
+// Evaluate evaluates the expression and returns the result. Evaluation errors are returned as *Error pointing to the
+// start of the expression.
 func (e *Expression) Evaluate(provider VariableProvider) (interface{}, error) {
-	return e.interpreter.Evaluate(provider)
+	val, err := e.interpreter.Evaluate(provider)
+	if err != nil {
+		var exprErr *Error
+		if errors.As(err, &exprErr) {
+			return nil, err
+		}
+
+		return nil, &Error{Expression: e.Value, Line: e.Line, Column: e.Column, Err: err}
+	}
+
+	return val, nil
+}
+
+// errorAt returns an *Error for the given node of the expression. The position of the error is the position of the
+// node in the source string.
+func (e *Expression) errorAt(node actionlint.ExprNode, err error) *Error {
+	line, column := e.Line, e.Column
+
+	if tok := node.Token(); tok != nil {
+		// tokens are positioned relative to the content after the ${{ prefix
+		offset := len("${{") + tok.Offset
+
+		if tok.Line > 1 {
+			line, column = line+tok.Line-1, tok.Column
+		} else {
+			column += offset
+		}
+	}
+
+	return &Error{Expression: e.Value, Line: line, Column: column, Err: err}
+}
+
+// newParseError converts the parse error of the expression at the given index of the input to an *Error.
+func newParseError(input, value string, index int, err error) *Error {
+	var exprErr *actionlint.ExprError
+	if !errors.As(err, &exprErr) {
+		line, column := position(input, index)
+
+		return &Error{Expression: value, Line: line, Column: column, Err: err}
+	}
+
+	// offset is relative to the content after the ${{ prefix
+	line, column := position(input, min(index+len("${{")+exprErr.Offset, len(input)))
+
+	return &Error{Expression: value, Line: line, Column: column, Err: errors.New(exprErr.Message)}
+}
+
+// position returns the 1-based line and column of the given byte offset in the input.
+func position(input string, offset int) (int, int) {
+	before := input[:offset]
+
+	line := strings.Count(before, "\n") + 1
+	column := offset - strings.LastIndex(before, "\n")
+
+	return line, column
 }
@@ -1,6 +1,8 @@
 package expression
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"os"
@@ -625,10 +627,14 @@ func TestExpression_EvaluateFunctions(t *testing.T) {
 		{"not endsWith string", "endsWith('foo', 'bar')", false},
 		{"format string", "format('Hello {0}', 'World')", "Hello World"},
 		{"format with escaped string", "format('{{ Hello {0}{1} }}', 'World', '!')", "{ Hello World! }"},
+		{"format with null", "format('{0}', null)", ""},
+		{"format with missing property", "format('PR {0}', foo.missing)", "PR "},
 		{"join string", "join(foo.nested.slice.*.foo, '|')", "bar|baz|qux"},
 		{"join with fromJson", "join(fromJson('[\"foo\", \"bar\"]'), ',')", "foo,bar"},
 		{"join without separator", "join(foo.nested.slice.*.foo)", "bar,baz,qux"},
 		{"toJson string", "toJson('foo')", "\"foo\""},
+		{"toJson null", "toJson(null)", "null"},
+		{"join with null elements", "join(fromJson('[\"foo\", null]'), null)", "foo"},
 		{"toJson number", "toJson(1)", "1"},
 		{"toJson boolean", "toJson(true)", "true"},
 		{"toJson slice", "toJson(foo.nested.slice.*.foo)", "[\"bar\",\"baz\",\"qux\"]"},
@@ -660,6 +666,94 @@ func TestExpression_EvaluateFunctions(t *testing.T) {
 	}
 }
 
+func TestExpression_EvaluateFunctionErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"fromJson invalid json", "fromJson('[')", "fromJSON() failed to parse JSON: unexpected end of JSON input"},
+		{"unknown function", "foo('bar')", "function 'foo' not supported"},
+		{"panic in function", "toJson(faulty)", "toJson() failed: faulty marshaler"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := NewExpression(tt.input)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+
+			_, err = expr.Evaluate(&TestVariableProvider{})
+
+			var exprErr *Error
+			if !errors.As(err, &exprErr) {
+				t.Fatalf("Expected expression error, but got %v for input: %s", err, tt.input)
+			}
+
+			if exprErr.Err.Error() != tt.err {
+				t.Errorf("Expected error %s, but got %s for input: %s", tt.err, exprErr.Err.Error(), tt.input)
+			}
+		})
+	}
+}
+
+func TestExpression_EvaluateObjectFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string // expected is the JSON representation of the result
+	}{
+		{"array of objects", "fruits.*.name", `["apple","orange","pear"]`},
+		{"filter without property", "fruits.*", `[{"name":"apple","quantity":1},{"name":"orange","quantity":2},{"name":"pear"}]`},
+		{"missing property is skipped", "fruits.*.quantity", `[1,2]`},
+		{"index access on filter", "fruits.*['name']", `["apple","orange","pear"]`},
+		{"nested arrays", "vegetables.*.ediblePortions", `[["roots","stems","leaves"],["leaves"],["stems","roots"]]`},
+		{"chained filters", "vegetables.*.ediblePortions.*", `["roots","stems","leaves","leaves","stems","roots"]`},
+		{"index of nested arrays", "vegetables.*.ediblePortions[0]", `["roots","leaves","stems"]`},
+		{"object values", "needs.*.result", `["success","failure"]`},
+		{"nested object values", "needs.*.outputs.*", `["1.2.3","linux"]`},
+		{"property of nested objects", "github.event.pull_request.labels.*.name", `["bug","help wanted"]`},
+		{"struct values", "steps.*.outcome", `["success","failure"]`},
+		{"filter on scalar", "github.event.action.*", `[]`},
+		{"filter on missing value", "github.event.missing.*", `[]`},
+		{"property on filter of scalars", "needs.*.result.name", `[]`},
+		{"contains with filter", "contains(github.event.pull_request.labels.*.name, 'bug')", `true`},
+		{"contains with filter is case insensitive", "contains(github.event.pull_request.labels.*.name, 'Help Wanted')", `true`},
+		{"contains with object values", "contains(needs.*.result, 'failure')", `true`},
+		{"contains with struct values", "contains(steps.*.outcome, 'failure')", `true`},
+		{"not contains with struct values", "contains(steps.*.outcome, 'cancelled')", `false`},
+		{"contains with chained filters", "contains(vegetables.*.ediblePortions.*, 'stems')", `true`},
+		{"join with filter", "join(fruits.*.name, ', ')", `"apple, orange, pear"`},
+		{"join with chained filters", "join(vegetables.*.ediblePortions.*)", `"roots,stems,leaves,leaves,stems,roots"`},
+		{"toJSON with filter", "toJSON(needs.*.result)", `"[\"success\",\"failure\"]"`},
+		{"filter of fromJSON", "fromJSON('{\"a\": {\"b\": 1}, \"c\": {\"b\": 2}}').*.b", `[1,2]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := NewExpression(tt.input)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+
+			result, err := expr.Evaluate(&ObjectFilterVariableProvider{})
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+
+			actual, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+
+			if string(actual) != tt.expected {
+				t.Errorf("Expected %s, but got %s for input: %s", tt.expected, actual, tt.input)
+			}
+		})
+	}
+}
+
 func TestExpression_EvaluateHashFunc(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -674,7 +768,7 @@ func TestExpression_EvaluateHashFunc(t *testing.T) {
 		{
 			name:     "Hash of existing files matching pattern",
 			input:    `hashFiles('./testdata/file-*.txt')`,
-			expected: "7f83b1657ff1fc53b92dc18148a1d65dfc2d4b1fa3d677284addd200126d9069",
+			expected: "61f417374f4400b47dcae1a8f402d4f4dacf455a0442a06aa455a447b0d4e170",
 			files: []struct {
 				path    string
 				name    string
@@ -686,7 +780,7 @@ func TestExpression_EvaluateHashFunc(t *testing.T) {
 		{
 			name:     "multiple files matching",
 			input:    `hashFiles('./testdata/file-*.txt')`,
-			expected: "7f83b1657ff1fc53b92dc18148a1d65dfc2d4b1fa3d677284addd200126d9069b86691d0513ea10d252be103dba3459b45f4d4bb3aa5b07bd5460f5ef0357fc8",
+			expected: "95cdbcdd8d42ffa23e01603f1bf83cdeefde710fc7f51bb2dfbef90bd2a1b60d",
 			files: []struct {
 				path    string
 				name    string
@@ -699,7 +793,35 @@ func TestExpression_EvaluateHashFunc(t *testing.T) {
 		{
 			name:     "Hash of nested directory",
 			input:    `hashFiles('./testdata/nested/**/file-*.txt')`,
-			expected: "14d53b936bfc2c45b26e84f74742f32f1449bab0ee83f6bb3410ef87b3a4d4beb86691d0513ea10d252be103dba3459b45f4d4bb3aa5b07bd5460f5ef0357fc8",
+			expected: "151a6655041a9373414353d553d2cd49cf54ff8449b2cf02e0c9c3c66d982451",
+			files: []struct {
+				path    string
+				name    string
+				content []byte
+			}{
+				{path: "nested", name: "file-1.txt", content: []byte("Hello World!")},
+				{path: "nested/foo", name: "file-2.txt", content: []byte("Foo Bar!")},
+				{path: "nested/bar", name: "file-3.txt", content: []byte("Bar Foo!")},
+			},
+		},
+		{
+			name:     "Negated patterns are excluded",
+			input:    `hashFiles('./testdata/**', '!./testdata/**/file-2.txt', '!./testdata/bar')`,
+			expected: "61f417374f4400b47dcae1a8f402d4f4dacf455a0442a06aa455a447b0d4e170",
+			files: []struct {
+				path    string
+				name    string
+				content []byte
+			}{
+				{name: "file-1.txt", content: []byte("Hello World!")},
+				{path: "foo", name: "file-2.txt", content: []byte("Foo Bar!")},
+				{path: "bar", name: "file-3.txt", content: []byte("Bar Foo!")},
+			},
+		},
+		{
+			name:     "Directories are expanded to files",
+			input:    `hashFiles('./testdata/nested')`,
+			expected: "151a6655041a9373414353d553d2cd49cf54ff8449b2cf02e0c9c3c66d982451",
 			files: []struct {
 				path    string
 				name    string
@@ -763,6 +885,60 @@ func TestExpression_EvaluateHashFunc(t *testing.T) {
 	}
 }
 
+func TestExpression_EvaluateHashFuncWorkspace(t *testing.T) {
+	workspace := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(workspace, "file-1.txt"), []byte("Hello World!"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(filepath.Dir(workspace), "outside.txt"), []byte("Outside!"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &WorkspaceVariableProvider{workspace: workspace}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "relative to workspace", input: `hashFiles('**/file-*.txt')`, expected: "61f417374f4400b47dcae1a8f402d4f4dacf455a0442a06aa455a447b0d4e170"},
+		{name: "absolute path in workspace", input: fmt.Sprintf(`hashFiles('%s/file-1.txt')`, workspace), expected: "61f417374f4400b47dcae1a8f402d4f4dacf455a0442a06aa455a447b0d4e170"},
+		{name: "outside of workspace", input: fmt.Sprintf(`hashFiles('%s/*.txt')`, filepath.Dir(workspace)), expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := NewExpression(tt.input)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+
+			result, err := expr.Evaluate(provider)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+
+			if tt.expected != result {
+				t.Errorf("Expected %v, but got %v for input: %s", tt.expected, result, tt.input)
+			}
+		})
+	}
+}
+
+type WorkspaceVariableProvider struct {
+	workspace string
+}
+
+func (p *WorkspaceVariableProvider) GetVariable(name string) (interface{}, error) {
+	if name == "github" {
+		return map[string]interface{}{"workspace": p.workspace}, nil
+	}
+
+	return nil, fmt.Errorf("variable %s not found", name)
+}
+
 // TODO: find a better way. Currently tests are relying on static values. It's not maintainable for long term.
 
 type TestVariableProvider struct{}
@@ -787,6 +963,67 @@ func (p *TestVariableProvider) GetVariable(name string) (interface{}, error) {
 		return math.Inf(1), nil
 	case "nan":
 		return math.NaN(), nil
+	case "faulty":
+		return faultyValue{}, nil
+	}
+
+	return nil, fmt.Errorf("variable %s not found", name)
+}
+
+// faultyValue is a value panicking when it's marshaled to JSON, to test faults while evaluating functions.
+type faultyValue struct{}
+
+func (faultyValue) MarshalJSON() ([]byte, error) {
+	panic("faulty marshaler")
+}
+
+// testConclusion is a named string type to test object filters with values that are not plain strings.
+type testConclusion string
+
+// ObjectFilterVariableProvider provides contexts based on the object filter examples of the GitHub documentation.
+type ObjectFilterVariableProvider struct{}
+
+func (p *ObjectFilterVariableProvider) GetVariable(name string) (interface{}, error) {
+	var contexts = `{
+		"fruits": [
+			{"name": "apple", "quantity": 1},
+			{"name": "orange", "quantity": 2},
+			{"name": "pear"}
+		],
+		"vegetables": [
+			{"name": "carrot", "ediblePortions": ["roots", "stems", "leaves"]},
+			{"name": "cabbage", "ediblePortions": ["leaves"]},
+			{"name": "onion", "ediblePortions": ["stems", "roots"]}
+		],
+		"needs": {
+			"build": {"result": "success", "outputs": {"version": "1.2.3"}},
+			"test": {"result": "failure", "outputs": {"os": "linux"}}
+		},
+		"github": {
+			"event": {
+				"action": "opened",
+				"pull_request": {"labels": [{"name": "bug"}, {"name": "help wanted"}]}
+			}
+		}
+	}`
+
+	if name == "steps" {
+		return map[string]struct {
+			Outcome testConclusion `json:"outcome"`
+		}{
+			"build": {Outcome: "success"},
+			"test":  {Outcome: "failure"},
+		}, nil
+	}
+
+	var values map[string]interface{}
+
+	if err := json.Unmarshal([]byte(contexts), &values); err != nil {
+		return nil, err
+	}
+
+	if val, ok := values[name]; ok {
+		return val, nil
 	}
 
 	return nil, fmt.Errorf("variable %s not found", name)
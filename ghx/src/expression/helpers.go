@@ -128,6 +128,11 @@ func unwrapValue(value reflect.Value) (interface{}, error) {
 		value = value.Elem()
 	}
 
+	// nil pointers have no value to unwrap
+	if !value.IsValid() {
+		return nil, nil
+	}
+
 	return value.Interface(), nil
 }
 
@@ -149,6 +154,15 @@ func getSafeValue(value reflect.Value) interface{} {
 	return value.Interface()
 }
 
+// nullAsEmpty returns an empty string for nil values, the string representation of null in GitHub expressions.
+func nullAsEmpty(value interface{}) interface{} {
+	if value == nil {
+		return ""
+	}
+
+	return value
+}
+
 // isTruthy returns true if the given value is truthy, false otherwise.
 func isTruthy(input interface{}) bool {
 	value := reflect.ValueOf(input)
@@ -2,14 +2,19 @@ package expression
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/rhysd/actionlint"
+
+	"github.com/aweris/gale/common/fs"
 )
 
 var _ Interpreter = new(FuncCallNode)
@@ -17,7 +22,14 @@ var _ Interpreter = new(FuncCallNode)
 // FuncCallNode is a wrapper of actionlint.FuncCallNode
 type FuncCallNode actionlint.FuncCallNode
 
-func (n FuncCallNode) Evaluate(provider VariableProvider) (interface{}, error) {
+func (n FuncCallNode) Evaluate(provider VariableProvider) (val interface{}, err error) {
+	// functions work on arbitrary values, make sure unexpected values fail the evaluation instead of crashing
+	defer func() {
+		if r := recover(); r != nil {
+			val, err = nil, fmt.Errorf("%s() failed: %v", n.Callee, r)
+		}
+	}()
+
 	callee := strings.ToLower(n.Callee)
 
 	args := make([]reflect.Value, 0)
@@ -50,7 +62,7 @@ func (n FuncCallNode) Evaluate(provider VariableProvider) (interface{}, error) {
 	case "fromjson":
 		return fromJSON(args...)
 	case "hashfiles":
-		return hashFiles(args...)
+		return hashFiles(provider, args...)
 	case "success":
 		return success(provider)
 	case "failure":
@@ -72,14 +84,15 @@ func contains(args ...reflect.Value) (bool, error) {
 	searchValue := args[0]
 	itemValue := args[1]
 
+	// strings are searched case-insensitive and array items are compared with loose equality, same as GitHub
 	if searchValue.Kind() == reflect.String {
 		searchString := searchValue.String()
 		if itemValue.Kind() == reflect.String {
-			return strings.Contains(searchString, itemValue.String()), nil
+			return strings.Contains(strings.ToLower(searchString), strings.ToLower(itemValue.String())), nil
 		}
 	} else if searchValue.Kind() == reflect.Slice || searchValue.Kind() == reflect.Array {
 		for i := 0; i < searchValue.Len(); i++ {
-			if reflect.DeepEqual(searchValue.Index(i).Interface(), itemValue.Interface()) {
+			if equal, err := compareValues(reflect.ValueOf(searchValue.Index(i).Interface()), itemValue, actionlint.CompareOpNodeKindEq); err == nil && equal == true {
 				return true, nil
 			}
 		}
@@ -120,7 +133,7 @@ func format(args ...reflect.Value) (string, error) {
 	var values []interface{}
 	if len(args) >= 2 {
 		for i := 1; i < len(args); i++ {
-			values = append(values, args[i].Interface())
+			values = append(values, nullAsEmpty(getSafeValue(args[i])))
 		}
 	}
 
@@ -149,7 +162,7 @@ func join(args ...reflect.Value) (string, error) {
 	}
 
 	if len(args) >= 2 {
-		separator = args[1].String()
+		separator = fmt.Sprintf("%v", nullAsEmpty(getSafeValue(args[1])))
 	} else {
 		separator = ","
 	}
@@ -161,7 +174,7 @@ func join(args ...reflect.Value) (string, error) {
 		values = []string{array.String()}
 	case reflect.Slice, reflect.Array:
 		for i := 0; i < array.Len(); i++ {
-			value := array.Index(i).Interface()
+			value := nullAsEmpty(getSafeValue(array.Index(i)))
 			values = append(values, fmt.Sprintf("%v", value))
 		}
 	}
@@ -174,10 +187,11 @@ func toJSON(args ...reflect.Value) (string, error) {
 		return "", fmt.Errorf("toJSON() requires exactly one argument")
 	}
 
-	value := args[0].Interface()
+	// null values are invalid reflect values, marshal them as nil to get "null"
+	value := getSafeValue(args[0])
 	jsonBytes, err := json.Marshal(value)
 	if err != nil {
-		panic(fmt.Sprintf("Failed to convert value to JSON: %v", err))
+		return "", fmt.Errorf("toJSON() failed to convert value to JSON: %w", err)
 	}
 
 	return string(jsonBytes), nil
@@ -194,45 +208,102 @@ func fromJSON(args ...reflect.Value) (interface{}, error) {
 
 	err := json.Unmarshal([]byte(jsonString), &value)
 	if err != nil {
-		panic(fmt.Sprintf("Failed to parse JSON: %v", err))
+		return nil, fmt.Errorf("fromJSON() failed to parse JSON: %w", err)
 	}
 
 	return value, nil
 }
 
-// TODO: double check this how GHA handles this. This is just a some hacky implementation to get it working
-
-func hashFiles(args ...reflect.Value) (string, error) {
+// hashFiles returns a single SHA-256 hash of the files matching the given patterns, same as GitHub does. Patterns are
+// resolved from the workspace, matched files are sorted and the hash is computed over the SHA-256 hashes of the files.
+// If no file matches, an empty string is returned.
+func hashFiles(provider VariableProvider, args ...reflect.Value) (string, error) {
 	if len(args) < 1 {
 		return "", fmt.Errorf("hashFiles() requires at least one argument")
 	}
 
-	paths := make([]string, 0, len(args))
+	patterns := make([]string, 0, len(args))
 
-	for _, arg := range args {
-		paths = append(paths, arg.String())
+	for idx, arg := range args {
+		pattern := arg.String()
+
+		// symbolic links are always followed for files, the option is accepted for compatibility
+		if idx == 0 && pattern == "--follow-symbolic-links" {
+			continue
+		}
+
+		patterns = append(patterns, pattern)
 	}
 
-	var fileHashes []string
+	workspace, err := getWorkspace(provider)
+	if err != nil {
+		return "", err
+	}
+
+	files, err := fs.GlobFiles(workspace, patterns)
+	if err != nil {
+		return "", err
+	}
+
+	sort.Strings(files)
+
+	var (
+		count  int
+		result = sha256.New()
+	)
+
+	for _, file := range files {
+		// only files in the workspace are hashed
+		if !strings.HasPrefix(file, workspace+string(filepath.Separator)) {
+			continue
+		}
 
-	for _, path := range paths {
-		matches, err := filepath.Glob(path)
+		hash, err := hashFile(file)
 		if err != nil {
 			return "", err
 		}
 
-		for _, match := range matches {
-			fileBytes, err := os.ReadFile(match)
-			if err != nil {
-				return "", err
-			}
+		result.Write(hash)
+
+		count++
+	}
+
+	if count == 0 {
+		return "", nil
+	}
+
+	return hex.EncodeToString(result.Sum(nil)), nil
+}
 
-			hash := sha256.Sum256(fileBytes)
-			fileHashes = append(fileHashes, fmt.Sprintf("%x", hash))
+// getWorkspace returns the workspace from the github context. If the workspace is not available, the current working
+// directory is used.
+func getWorkspace(provider VariableProvider) (string, error) {
+	if github, err := provider.GetVariable("github"); err == nil && github != nil {
+		if workspace, err := getPropertyValue(reflect.ValueOf(github), "workspace"); err == nil {
+			if str, ok := workspace.(string); ok && str != "" {
+				return filepath.Clean(str), nil
+			}
 		}
 	}
 
-	return strings.Join(fileHashes, ""), nil
+	return os.Getwd()
+}
+
+// hashFile returns the SHA-256 hash of the given file.
+func hashFile(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+
+	return hash.Sum(nil), nil
 }
 
 func always() bool {
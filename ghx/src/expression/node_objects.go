@@ -1,7 +1,10 @@
 package expression
 
 import (
+	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/rhysd/actionlint"
 )
@@ -30,6 +33,11 @@ func (n ObjectDerefNode) Evaluate(provider VariableProvider) (interface{}, error
 		return nil, err
 	}
 
+	// properties of a filtered array are projected over its items
+	if filtered, ok := left.(filteredArray); ok {
+		return filtered.project(n.Property), nil
+	}
+
 	// get property value from the receiver value
 	return getPropertyValue(reflect.ValueOf(left), n.Property)
 }
@@ -44,7 +52,18 @@ func (n ArrayDerefNode) Evaluate(provider VariableProvider) (interface{}, error)
 		return nil, err
 	}
 
-	return getSafeValue(reflect.ValueOf(left)), nil
+	// chained filters flatten the items of the filtered array, e.g. `foo.*.bar.*`
+	if filtered, ok := left.(filteredArray); ok {
+		result := make(filteredArray, 0)
+
+		for _, item := range filtered {
+			result = append(result, filterValues(item)...)
+		}
+
+		return result, nil
+	}
+
+	return filterValues(left), nil
 }
 
 // IndexAccessNode is a wrapper of actionlint.IndexAccessNode
@@ -67,6 +86,11 @@ func (n IndexAccessNode) Evaluate(provider VariableProvider) (interface{}, error
 	leftValue := reflect.ValueOf(left)
 	rightValue := reflect.ValueOf(right)
 
+	// indexes of a filtered array are applied to its items
+	if filtered, ok := left.(filteredArray); ok {
+		return filtered.index(rightValue), nil
+	}
+
 	// evaluate the index value
 	switch rightValue.Kind() {
 	case reflect.String:
@@ -87,3 +111,157 @@ func (n IndexAccessNode) Evaluate(provider VariableProvider) (interface{}, error
 	// fallback to nil
 	return nil, nil
 }
+
+// filteredArray is the result of an object filter, e.g. `labels.*`. Unlike regular arrays, properties and indexes
+// accessed on a filtered array are applied to each item of the array, same as GitHub.
+type filteredArray []interface{}
+
+// project returns a new filtered array containing the given property of the items. Items without the property are
+// skipped.
+func (f filteredArray) project(property string) filteredArray {
+	result := make(filteredArray, 0, len(f))
+
+	for _, item := range f {
+		value := reflect.ValueOf(item)
+
+		for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+			value = value.Elem()
+		}
+
+		// only objects have properties, projecting over nested arrays requires another filter
+		if value.Kind() != reflect.Map && value.Kind() != reflect.Struct {
+			continue
+		}
+
+		val, err := getPropertyValue(value, property)
+		if err != nil || val == nil {
+			continue
+		}
+
+		result = append(result, val)
+	}
+
+	return result
+}
+
+// index returns a new filtered array containing the given index of the items. String indexes are properties of the
+// object items and integer indexes are elements of the array items.
+func (f filteredArray) index(index reflect.Value) filteredArray {
+	if index.Kind() == reflect.String {
+		return f.project(index.String())
+	}
+
+	result := make(filteredArray, 0, len(f))
+
+	if index.Kind() != reflect.Int {
+		return result
+	}
+
+	for _, item := range f {
+		value := reflect.ValueOf(item)
+
+		if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+			continue
+		}
+
+		if idx := int(index.Int()); idx >= 0 && idx < value.Len() {
+			result = append(result, value.Index(idx).Interface())
+		}
+	}
+
+	return result
+}
+
+// filterValues returns the items of the given array or the property values of the given object as a filtered array.
+// Map keys are sorted to return the values in a stable order. Other values result in an empty filtered array.
+func filterValues(val interface{}) filteredArray {
+	result := make(filteredArray, 0)
+
+	value := reflect.ValueOf(val)
+
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			result = append(result, value.Index(i).Interface())
+		}
+	case reflect.Map:
+		keys := value.MapKeys()
+
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+
+		for _, key := range keys {
+			result = append(result, value.MapIndex(key).Interface())
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if tag := value.Type().Field(i).Tag.Get("json"); tag == "" || tag == "-" {
+				continue
+			}
+
+			result = append(result, value.Field(i).Interface())
+		}
+	}
+
+	return result
+}
+
+// openContexts are the contexts holding arbitrary key-value pairs. Same as GitHub, missing properties of these contexts
+// are not considered undefined, e.g. `env.OPTIONAL_VAR` or `github.event.pull_request` in a push event.
+var openContexts = []string{"env", "secrets", "vars", "github.event"}
+
+// undefinedPath returns the property path of the given node, e.g. `steps.build.outputs.version`, if the node is a
+// property access that should be defined when it resolves to nil.
+func undefinedPath(node actionlint.ExprNode) (string, bool) {
+	if _, ok := node.(*actionlint.VariableNode); ok {
+		return "", false
+	}
+
+	path, ok := propertyPath(node)
+	if !ok {
+		return "", false
+	}
+
+	lower := strings.ToLower(path)
+
+	for _, c := range openContexts {
+		if strings.HasPrefix(lower, c+".") || strings.HasPrefix(lower, c+"[") {
+			return "", false
+		}
+	}
+
+	return path, true
+}
+
+// propertyPath returns the path of the property accessed by the given node. Only variables, property dereferences and
+// index accesses with literal indexes have a path.
+func propertyPath(node actionlint.ExprNode) (string, bool) {
+	switch n := node.(type) {
+	case *actionlint.VariableNode:
+		return n.Name, true
+	case *actionlint.ObjectDerefNode:
+		receiver, ok := propertyPath(n.Receiver)
+		if !ok {
+			return "", false
+		}
+
+		return receiver + "." + n.Property, true
+	case *actionlint.IndexAccessNode:
+		operand, ok := propertyPath(n.Operand)
+		if !ok {
+			return "", false
+		}
+
+		switch index := n.Index.(type) {
+		case *actionlint.StringNode:
+			return fmt.Sprintf("%s['%s']", operand, index.Value), true
+		case *actionlint.IntNode:
+			return fmt.Sprintf("%s[%d]", operand, index.Value), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,152 @@
+package expression
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/rhysd/actionlint"
+)
+
+// TraceNode is the evaluated value of a node in the syntax tree of an expression. It's useful to inspect intermediate
+// values while debugging complex expressions.
+type TraceNode struct {
+	Expr     string       // Expr is the source of the node, e.g. `steps.build.outputs`.
+	Value    interface{}  // Value is the evaluated value of the node.
+	Type     string       // Type is the type of the value. See TypeOf for possible values.
+	Err      error        // Err is the error occurred while evaluating the node, if any.
+	Children []*TraceNode // Children are the operands of the node in the order they appear in the expression.
+}
+
+// Trace evaluates every node of the expression and returns the evaluation tree. Unlike Evaluate, operands are
+// evaluated even if the result is already determined, e.g. right-hand side of `true || foo`, to show all values.
+func (e *Expression) Trace(provider VariableProvider) *TraceNode {
+	return traceNode(e.node, provider)
+}
+
+func traceNode(node actionlint.ExprNode, provider VariableProvider) *TraceNode {
+	val, err := getInterpreterFromNode(node).Evaluate(provider)
+
+	trace := &TraceNode{Expr: nodeString(node), Value: val, Type: TypeOf(val), Err: err}
+
+	for _, child := range nodeChildren(node) {
+		trace.Children = append(trace.Children, traceNode(child, provider))
+	}
+
+	return trace
+}
+
+// TypeOf returns the type of the given value using the type names of GitHub expressions: null, boolean, number,
+// string, array or object.
+func TypeOf(val interface{}) string {
+	value := reflect.ValueOf(val)
+
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return "null"
+		}
+
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Invalid:
+		return "null"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// nodeChildren returns the operands of the given node.
+func nodeChildren(node actionlint.ExprNode) []actionlint.ExprNode {
+	switch n := node.(type) {
+	case *actionlint.ObjectDerefNode:
+		return []actionlint.ExprNode{n.Receiver}
+	case *actionlint.ArrayDerefNode:
+		return []actionlint.ExprNode{n.Receiver}
+	case *actionlint.IndexAccessNode:
+		return []actionlint.ExprNode{n.Operand, n.Index}
+	case *actionlint.NotOpNode:
+		return []actionlint.ExprNode{n.Operand}
+	case *actionlint.CompareOpNode:
+		return []actionlint.ExprNode{n.Left, n.Right}
+	case *actionlint.LogicalOpNode:
+		return []actionlint.ExprNode{n.Left, n.Right}
+	case *actionlint.FuncCallNode:
+		return n.Args
+	default:
+		return nil
+	}
+}
+
+// compareOperators is the source representation of the compare operators.
+var compareOperators = map[actionlint.CompareOpNodeKind]string{
+	actionlint.CompareOpNodeKindLess:      "<",
+	actionlint.CompareOpNodeKindLessEq:    "<=",
+	actionlint.CompareOpNodeKindGreater:   ">",
+	actionlint.CompareOpNodeKindGreaterEq: ">=",
+	actionlint.CompareOpNodeKindEq:        "==",
+	actionlint.CompareOpNodeKindNotEq:     "!=",
+}
+
+// nodeString returns the source representation of the given node. Whitespaces are normalized and nested operators are
+// wrapped in parentheses.
+func nodeString(node actionlint.ExprNode) string {
+	switch n := node.(type) {
+	case *actionlint.NullNode:
+		return "null"
+	case *actionlint.BoolNode:
+		return strconv.FormatBool(n.Value)
+	case *actionlint.IntNode:
+		return strconv.Itoa(n.Value)
+	case *actionlint.FloatNode:
+		return strconv.FormatFloat(n.Value, 'g', -1, 64)
+	case *actionlint.StringNode:
+		return "'" + strings.ReplaceAll(n.Value, "'", "''") + "'"
+	case *actionlint.VariableNode:
+		return n.Name
+	case *actionlint.ObjectDerefNode:
+		return nodeString(n.Receiver) + "." + n.Property
+	case *actionlint.ArrayDerefNode:
+		return nodeString(n.Receiver) + ".*"
+	case *actionlint.IndexAccessNode:
+		return nodeString(n.Operand) + "[" + nodeString(n.Index) + "]"
+	case *actionlint.NotOpNode:
+		return "!" + operandString(n.Operand)
+	case *actionlint.CompareOpNode:
+		return operandString(n.Left) + " " + compareOperators[n.Kind] + " " + operandString(n.Right)
+	case *actionlint.LogicalOpNode:
+		return operandString(n.Left) + " " + n.Kind.String() + " " + operandString(n.Right)
+	case *actionlint.FuncCallNode:
+		args := make([]string, 0, len(n.Args))
+
+		for _, arg := range n.Args {
+			args = append(args, nodeString(arg))
+		}
+
+		return n.Callee + "(" + strings.Join(args, ", ") + ")"
+	default:
+		return fmt.Sprintf("%v", node)
+	}
+}
+
+// operandString returns the source representation of an operand, wrapping operators in parentheses.
+func operandString(node actionlint.ExprNode) string {
+	switch node.(type) {
+	case *actionlint.CompareOpNode, *actionlint.LogicalOpNode:
+		return "(" + nodeString(node) + ")"
+	default:
+		return nodeString(node)
+	}
+}
@@ -0,0 +1,165 @@
+package expression
+
+import (
+	"reflect"
+	"testing"
+)
+
+// traceSummary is a simplified version of the trace node to compare in tests.
+type traceSummary struct {
+	Expr     string
+	Value    interface{}
+	Type     string
+	Children []traceSummary
+}
+
+func summarizeTrace(trace *TraceNode) traceSummary {
+	summary := traceSummary{Expr: trace.Expr, Value: trace.Value, Type: trace.Type}
+
+	for _, child := range trace.Children {
+		summary.Children = append(summary.Children, summarizeTrace(child))
+	}
+
+	return summary
+}
+
+func TestExpression_Trace(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected traceSummary
+	}{
+		{
+			name:     "literal",
+			input:    "'foo'",
+			expected: traceSummary{Expr: "'foo'", Value: "foo", Type: "string"},
+		},
+		{
+			name:  "compare with property",
+			input: "foo.bar == 'baz'",
+			expected: traceSummary{Expr: "foo.bar == 'baz'", Value: true, Type: "boolean", Children: []traceSummary{
+				{Expr: "foo.bar", Value: "baz", Type: "string", Children: []traceSummary{
+					{Expr: "foo", Value: mustGetVariable(t, "foo"), Type: "object"},
+				}},
+				{Expr: "'baz'", Value: "baz", Type: "string"},
+			}},
+		},
+		{
+			name:  "logical with function",
+			input: "!(startsWith(foo.bar, 'b') && foo['missing'])",
+			expected: traceSummary{Expr: "!(startsWith(foo.bar, 'b') && foo['missing'])", Value: true, Type: "boolean", Children: []traceSummary{
+				{Expr: "startsWith(foo.bar, 'b') && foo['missing']", Value: nil, Type: "null", Children: []traceSummary{
+					{Expr: "startsWith(foo.bar, 'b')", Value: true, Type: "boolean", Children: []traceSummary{
+						{Expr: "foo.bar", Value: "baz", Type: "string", Children: []traceSummary{
+							{Expr: "foo", Value: mustGetVariable(t, "foo"), Type: "object"},
+						}},
+						{Expr: "'b'", Value: "b", Type: "string"},
+					}},
+					{Expr: "foo['missing']", Value: nil, Type: "null", Children: []traceSummary{
+						{Expr: "foo", Value: mustGetVariable(t, "foo"), Type: "object"},
+						{Expr: "'missing'", Value: "missing", Type: "string"},
+					}},
+				}},
+			}},
+		},
+		{
+			name:  "array dereference",
+			input: "toJSON(foo.nested.slice.*.foo)",
+			expected: traceSummary{Expr: "toJSON(foo.nested.slice.*.foo)", Value: `["bar","baz","qux"]`, Type: "string", Children: []traceSummary{
+				{Expr: "foo.nested.slice.*.foo", Value: filteredArray{"bar", "baz", "qux"}, Type: "array", Children: []traceSummary{
+					{Expr: "foo.nested.slice.*", Value: filteredArray{
+						map[string]interface{}{"foo": "bar"}, map[string]interface{}{"foo": "baz"}, map[string]interface{}{"foo": "qux"},
+					}, Type: "array", Children: []traceSummary{
+						{Expr: "foo.nested.slice", Value: mustEvaluate(t, "foo.nested.slice"), Type: "array", Children: []traceSummary{
+							{Expr: "foo.nested", Value: mustEvaluate(t, "foo.nested"), Type: "object", Children: []traceSummary{
+								{Expr: "foo", Value: mustGetVariable(t, "foo"), Type: "object"},
+							}},
+						}},
+					}},
+				}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := NewExpression(tt.input)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %s for input: %s", err.Error(), tt.input)
+			}
+
+			result := summarizeTrace(expr.Trace(&TestVariableProvider{}))
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %+v, but got %+v for input: %s", tt.expected, result, tt.input)
+			}
+		})
+	}
+}
+
+func TestExpression_TraceError(t *testing.T) {
+	expr, err := NewExpression("fromJSON(foo.bar)")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	trace := expr.Trace(&TestVariableProvider{})
+
+	if trace.Err == nil {
+		t.Errorf("Expected error for %s, but got nil", trace.Expr)
+	}
+
+	if len(trace.Children) != 1 || trace.Children[0].Err != nil || trace.Children[0].Value != "baz" {
+		t.Errorf("Expected argument to be evaluated without error, but got %+v", trace.Children)
+	}
+}
+
+func TestTypeOf(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, "null"},
+		{true, "boolean"},
+		{1, "number"},
+		{1.5, "number"},
+		{"foo", "string"},
+		{[]string{"foo"}, "array"},
+		{map[string]string{"foo": "bar"}, "object"},
+		{struct{ Foo string }{Foo: "bar"}, "object"},
+		{&struct{ Foo string }{Foo: "bar"}, "object"},
+	}
+
+	for _, tt := range tests {
+		if result := TypeOf(tt.value); result != tt.expected {
+			t.Errorf("Expected %s, but got %s for value: %v", tt.expected, result, tt.value)
+		}
+	}
+}
+
+func mustGetVariable(t *testing.T, name string) interface{} {
+	t.Helper()
+
+	val, err := new(TestVariableProvider).GetVariable(name)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	return val
+}
+
+func mustEvaluate(t *testing.T, input string) interface{} {
+	t.Helper()
+
+	expr, err := NewExpression(input)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	val, err := expr.Evaluate(&TestVariableProvider{})
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err.Error())
+	}
+
+	return val
+}
@@ -1,12 +1,18 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"strings"
+	"unicode"
 
 	"ghx/context"
 	"ghx/expression"
 
+	galefs "github.com/aweris/gale/common/fs"
 	"github.com/aweris/gale/common/model"
 )
 
@@ -35,7 +41,7 @@ func evalCondition(condition string, ac *context.Context) (bool, model.Conclusio
 	}
 
 	// evaluate the condition as boolean expression
-	run, err := expression.NewBoolExpr(condition).Eval(ac)
+	run, err := expression.NewBoolExpr(condition).Eval(ac.GetStepVariableProvider())
 	if err != nil {
 		return false, "", err
 	}
@@ -49,3 +55,118 @@ func evalCondition(condition string, ac *context.Context) (bool, model.Conclusio
 
 	return run, conclusion, nil
 }
+
+// splitArgs splits the given command line into arguments like a POSIX shell does, without any expansion. Arguments are
+// separated by whitespaces, quotes group the characters into a single argument and backslash escapes the next
+// character outside single quotes.
+func splitArgs(line string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool // inArg indicates that an argument is started, quotes start an argument even if it's empty
+		quote   rune // quote is the quote character of the current quoted section, zero if not quoted
+		escaped bool // escaped indicates that the previous character is an escaping backslash
+	)
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			// in double quotes, backslash only escapes the characters having a special meaning in double quotes
+			if quote == '"' && !strings.ContainsRune("\\\"$`", r) {
+				current.WriteRune('\\')
+			}
+
+			current.WriteRune(r)
+
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if escaped {
+		return nil, errors.New("unterminated escape character")
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote: %c", quote)
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
+
+// copyDir copies the content of the src directory to the dst directory. The dst directory is skipped if it's under
+// the src directory.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path == dst {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			return os.Symlink(link, target)
+		default:
+			return galefs.CopyFile(path, target)
+		}
+	})
+}
+
+// splitInputLines splits the given multiline input into lines. Lines are trimmed and empty lines are ignored.
+func splitInputLines(input string) []string {
+	var lines []string
+
+	for _, line := range strings.Split(input, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
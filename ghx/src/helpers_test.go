@@ -0,0 +1,45 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty", input: "", want: nil},
+		{name: "whitespace only", input: "  \t ", want: nil},
+		{name: "simple", input: "echo hello world", want: []string{"echo", "hello", "world"}},
+		{name: "multiple whitespaces", input: "  echo \t hello\nworld ", want: []string{"echo", "hello", "world"}},
+		{name: "double quotes", input: `echo "hello world"`, want: []string{"echo", "hello world"}},
+		{name: "single quotes", input: `echo 'hello world'`, want: []string{"echo", "hello world"}},
+		{name: "empty quotes", input: `echo "" ''`, want: []string{"echo", "", ""}},
+		{name: "adjacent quotes", input: `--name="hello world"'!'`, want: []string{"--name=hello world!"}},
+		{name: "escaped space", input: `echo hello\ world`, want: []string{"echo", "hello world"}},
+		{name: "escaped quote in double quotes", input: `echo "say \"hi\""`, want: []string{"echo", `say "hi"`}},
+		{name: "backslash in double quotes", input: `echo "a\b"`, want: []string{"echo", `a\b`}},
+		{name: "backslash in single quotes", input: `echo 'a\'`, want: []string{"echo", `a\`}},
+		{name: "no expansion", input: `echo $HOME *`, want: []string{"echo", "$HOME", "*"}},
+		{name: "unterminated double quote", input: `echo "hello`, wantErr: true},
+		{name: "unterminated single quote", input: `echo 'hello`, wantErr: true},
+		{name: "unterminated escape", input: `echo hello\`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitArgs(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
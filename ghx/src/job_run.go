@@ -8,13 +8,12 @@ import (
 	"github.com/aweris/gale/common/task"
 
 	"ghx/context"
-	"ghx/expression"
 	"ghx/idgen"
 	"github.com/aweris/gale/common/model"
 )
 
 // planJob plans the job and returns the job runner.
-func planJob(job model.Job) ([]*task.Runner[context.Context], error) {
+func planJob(job model.Job, opts StepOpts) ([]*task.Runner[context.Context], error) {
 	// step task executors that execute the steps
 	var (
 		setupFns = make([]task.RunFn[context.Context], 0)
@@ -28,7 +27,7 @@ func planJob(job model.Job) ([]*task.Runner[context.Context], error) {
 			step.ID = fmt.Sprintf("%d", idx)
 		}
 
-		sr, err := NewStep(step)
+		sr, err := NewStep(step, opts)
 		if err != nil {
 			return nil, err
 		}
@@ -41,39 +40,12 @@ func planJob(job model.Job) ([]*task.Runner[context.Context], error) {
 
 		// if step implements pre hook, add the pre task taskRunner to the tasks slice.
 		if hook, ok := sr.(PreHook); ok {
-			preRunFn := newTaskPreRunFnForStep(model.StepStagePre, step)
-			if pre, ok := sr.(PreRunHook); ok {
-				preRunFn = pre.preRun(model.StepStagePre)
-			}
-
-			postRunFn := newTaskPostRunFnForStep()
-			if post, ok := sr.(PostRunHook); ok {
-				postRunFn = post.postRun()
-			}
-			opt := task.Opts[context.Context]{
-				ConditionalFn: hook.preCondition(),
-				PreRunFn:      preRunFn,
-				PostRunFn:     postRunFn,
-			}
+			opt := newTaskOptsForStep(sr, step, model.StepStagePre, hook.preCondition())
 			pre = append(pre, task.New(getStepName("Pre", step), hook.pre(), opt))
 		}
 
-		preRunFn := newTaskPreRunFnForStep(model.StepStageMain, step)
-		if pre, ok := sr.(PreRunHook); ok {
-			preRunFn = pre.preRun(model.StepStageMain)
-		}
-
-		postRunFn := newTaskPostRunFnForStep()
-		if post, ok := sr.(PostRunHook); ok {
-			postRunFn = post.postRun()
-		}
-
 		// main task options
-		opt := task.Opts[context.Context]{
-			ConditionalFn: sr.condition(),
-			PreRunFn:      preRunFn,
-			PostRunFn:     postRunFn,
-		}
+		opt := newTaskOptsForStep(sr, step, model.StepStageMain, sr.condition())
 
 		// main tasks starts after pre tasks. so index is step index + len(steps)
 		prefix := ""
@@ -83,21 +55,7 @@ func planJob(job model.Job) ([]*task.Runner[context.Context], error) {
 		main = append(main, task.New(getStepName(prefix, step), sr.main(), opt))
 
 		if hook, ok := sr.(PostHook); ok {
-			preRunFn := newTaskPreRunFnForStep(model.StepStagePost, step)
-			if pre, ok := sr.(PreRunHook); ok {
-				preRunFn = pre.preRun(model.StepStagePost)
-			}
-
-			postRunFn := newTaskPostRunFnForStep()
-			if post, ok := sr.(PostRunHook); ok {
-				postRunFn = post.postRun()
-			}
-
-			opt := task.Opts[context.Context]{
-				ConditionalFn: hook.postCondition(),
-				PreRunFn:      preRunFn,
-				PostRunFn:     postRunFn,
-			}
+			opt := newTaskOptsForStep(sr, step, model.StepStagePost, hook.postCondition())
 			post = append(post, task.New(getStepName("Post", step), hook.post(), opt))
 		}
 	}
@@ -111,7 +69,16 @@ func planJob(job model.Job) ([]*task.Runner[context.Context], error) {
 	tasks = append(tasks, task.New[context.Context]("Complete job", complete()))
 
 	runFn := func(ctx *context.Context) (model.Conclusion, error) {
-		for _, te := range tasks {
+		// the job is resumed from the pause only once, the rest of the job runners should start from the beginning.
+		resume := ctx.Execution.Resume
+		ctx.Execution.Resume = nil
+
+		for idx, te := range tasks {
+			// skip the tasks executed before the pause. The setup task is always executed to load the actions.
+			if resume != nil && idx > 0 && idx < resume.Task {
+				continue
+			}
+
 			result, err := te.Run(ctx)
 
 			// no need to continue if the task taskRunner did not run.
@@ -123,6 +90,26 @@ func planJob(job model.Job) ([]*task.Runner[context.Context], error) {
 				log.Errorf(te.Name, "error", err)
 			}
 
+			// setup task resets the job status, so we need to restore the job state from the pause after the setup.
+			if resume != nil && idx == 0 {
+				if err := ctx.ResumeJob(resume); err != nil {
+					return model.ConclusionFailure, err
+				}
+			}
+
+			// pause the job at the failing task to be able to open an interactive shell with the step environment.
+			if ctx.GhxConfig.InteractiveOnFailure && result.Conclusion == model.ConclusionFailure {
+				if err := ctx.PauseJob(idx, te.Name); err != nil {
+					log.Warnf("Job can't be paused, continuing without interactive shell", "task", te.Name, "error", err)
+				} else {
+					log.Infof("Job paused", "task", te.Name)
+
+					ctx.SetJobResults(model.ConclusionFailure, model.ConclusionFailure, make(map[string]string))
+
+					return model.ConclusionFailure, nil
+				}
+			}
+
 			// set the job status to the conclusion of the job status is success and the conclusion is not success.
 			if ctx.Job.Status == model.ConclusionSuccess && result.Conclusion != ctx.Job.Status {
 				ctx.Job.Status = result.Conclusion
@@ -133,7 +120,14 @@ func planJob(job model.Job) ([]*task.Runner[context.Context], error) {
 		outputs := make(map[string]string, len(ctx.Execution.JobRun.Job.Outputs))
 
 		for k, v := range ctx.Execution.JobRun.Job.Outputs {
-			val := expression.NewString(v).Eval(ctx)
+			val, err := ctx.EvalString(ctx, v)
+			if err != nil {
+				log.Errorf("Failed to evaluate output", "key", k, "error", err)
+
+				ctx.Job.Status = model.ConclusionFailure
+
+				continue
+			}
 
 			log.Debugf("Evaluated output", "key", k, "value", val)
 
@@ -169,7 +163,7 @@ func planJob(job model.Job) ([]*task.Runner[context.Context], error) {
 	matrices := job.Strategy.Matrix.GenerateCombinations()
 
 	if len(matrices) > 0 {
-		for _, matrix := range matrices {
+		for idx, matrix := range matrices {
 			var values []string
 
 			for k, v := range matrix {
@@ -186,7 +180,7 @@ func planJob(job model.Job) ([]*task.Runner[context.Context], error) {
 
 			runner := task.New(sb.String(), runFn, task.Opts[context.Context]{
 				ConditionalFn: newTaskConditionalFnForJob(job),
-				PreRunFn:      newTaskPreRunFnForJob(job, matrix),
+				PreRunFn:      newTaskPreRunFnForJob(job, job.Strategy.JobRunStrategy(idx, len(matrices)), matrix),
 				PostRunFn:     newTaskPostRunFnForJob(),
 			})
 
@@ -196,7 +190,7 @@ func planJob(job model.Job) ([]*task.Runner[context.Context], error) {
 		// task runner options for the job
 		opt := task.Opts[context.Context]{
 			ConditionalFn: newTaskConditionalFnForJob(job),
-			PreRunFn:      newTaskPreRunFnForJob(job),
+			PreRunFn:      newTaskPreRunFnForJob(job, job.Strategy.JobRunStrategy(0, 1)),
 			PostRunFn:     newTaskPostRunFnForJob(),
 		}
 
@@ -253,14 +247,14 @@ func newTaskConditionalFnForJob(job model.Job) task.ConditionalFn[context.Contex
 
 // newTaskPreRunFnForJob returns a task pre run function that will be executed by the task taskRunner for the job. The
 // matrix parameter is optional. If it's provided, first matrix combination will be set to the job run.
-func newTaskPreRunFnForJob(job model.Job, matrix ...model.MatrixCombination) task.PreRunFn[context.Context] {
+func newTaskPreRunFnForJob(job model.Job, strategy model.JobRunStrategy, matrix ...model.MatrixCombination) task.PreRunFn[context.Context] {
 	return func(ctx *context.Context) error {
 		runID, err := idgen.GenerateJobRunID(ctx)
 		if err != nil {
 			return fmt.Errorf("failed to generate job run id: %w", err)
 		}
 
-		jr := &model.JobRun{RunID: runID, Job: job, Outputs: make(map[string]string)}
+		jr := &model.JobRun{RunID: runID, Job: job, Strategy: strategy, Outputs: make(map[string]string)}
 
 		if len(matrix) > 0 {
 			jr.Matrix = matrix[0]
@@ -9,12 +9,29 @@ import (
 	"dagger.io/dagger"
 
 	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/common/task"
 
 	"ghx/context"
 	"github.com/aweris/gale/common/model"
 )
 
 func main() {
+	// eval doesn't run a job, it only needs the contexts given with the flags
+	if len(os.Args) > 1 && os.Args[1] == "eval" {
+		if err := runEval(os.Args[2:], os.Stdout); err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	// stream runs a process in the container of a step to keep the order of its output streams
+	if len(os.Args) > 1 && os.Args[1] == "stream" {
+		os.Exit(runStream(os.Args[2:], os.Stdout))
+	}
+
 	stdctx := stdContext.Background()
 
 	client, err := dagger.Connect(stdctx, dagger.WithLogOutput(os.Stdout))
@@ -41,29 +58,79 @@ func main() {
 
 	ctx.Execution.Workflow = &wf
 
+	// Load action overrides
+	overrides, err := LoadActionOverrides(cfg.ActionOverrides)
+	if err != nil {
+		fmt.Printf("could not load action overrides: %v", err)
+		os.Exit(1)
+	}
+
+	ctx.Execution.ActionOverrides = overrides
+
+	warnUnmatchedActionOverrides(overrides, wf)
+
 	jm, ok := wf.Jobs[ctx.GhxConfig.Job]
 	if !ok {
 		fmt.Printf("job %s not found", ctx.GhxConfig.Job)
 		os.Exit(1)
 	}
 
-	runners, err := planJob(jm)
+	runners, err := planJob(jm, NewStepOpts(ctx))
 	if err != nil {
 		fmt.Printf("failed to plan job: %v", err)
 		os.Exit(1)
 	}
 
+	command := "run"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	switch command {
+	case "run":
+		err = runJob(ctx, runners, 0)
+	case "shell":
+		err = runShell(ctx, runners)
+	default:
+		err = fmt.Errorf("unknown command %s", command)
+	}
+
+	if err != nil {
+		fmt.Printf("%v", err)
+		os.Exit(1)
+	}
+}
+
+// runJob runs the job runners starting from the given index. If the job is paused, it writes the pause to the job run
+// directory and stops the execution.
+func runJob(ctx *context.Context, runners []*task.Runner[context.Context], start int) error {
+	// remove the pause left from the previous execution, if any, to not confuse the job state.
+	if err := ctx.RemovePause(); err != nil {
+		return fmt.Errorf("failed to remove pause: %w", err)
+	}
+
 	// FIXME: ignoring fail-fast for now. it is always true for now. Fix this later.
 	// FIXME: run all runners sequentially for now. Ignoring parallelism. Fix this later.
 
-	for _, runner := range runners {
+	for idx, runner := range runners[start:] {
 		_, err := runner.Run(ctx)
 		if err != nil {
-			fmt.Printf("failed to run job: %v", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to run job: %w", err)
 		}
 
+		// rest of the job runners will be executed when the job is resumed.
+		if pause := ctx.Execution.Pause; pause != nil {
+			pause.Runner = start + idx
+
+			if err := ctx.WritePause(); err != nil {
+				return fmt.Errorf("failed to write pause: %w", err)
+			}
+
+			return nil
+		}
 	}
+
+	return nil
 }
 
 func LoadWorkflow(cfg context.GhxConfig, path string) (model.Workflow, error) {
@@ -105,3 +172,42 @@ func LoadWorkflow(cfg context.GhxConfig, path string) (model.Workflow, error) {
 
 	return workflow, nil
 }
+
+// LoadActionOverrides loads the action override rules from the given file. If the path is empty, no overrides are
+// returned.
+func LoadActionOverrides(path string) (model.ActionOverrides, error) {
+	var overrides model.ActionOverrides
+
+	if path == "" {
+		return overrides, nil
+	}
+
+	if err := fs.ReadYAMLFile(path, &overrides); err != nil {
+		return overrides, err
+	}
+
+	if err := overrides.Validate(); err != nil {
+		return overrides, err
+	}
+
+	return overrides, nil
+}
+
+// warnUnmatchedActionOverrides logs a warning for each override rule not matching any step of the workflow, since a
+// rule silently matching nothing lets the action run, e.g. a deploy action. Steps of the composite actions are not known
+// before the actions are loaded, so rules only matching them are reported as well.
+func warnUnmatchedActionOverrides(overrides model.ActionOverrides, workflow model.Workflow) {
+	var uses []string
+
+	for _, job := range workflow.Jobs {
+		for _, step := range job.Steps {
+			if step.Uses != "" {
+				uses = append(uses, step.Uses)
+			}
+		}
+	}
+
+	for _, rule := range overrides.Unmatched(uses) {
+		log.Warnf("Action override rule matches no step of the workflow", "uses", rule.Uses)
+	}
+}
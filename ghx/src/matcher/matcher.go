@@ -0,0 +1,280 @@
+package matcher
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aweris/gale/common/model"
+)
+
+// Config is the problem matcher configuration file registered by add-matcher workflow command.
+//
+// See: https://github.com/actions/toolkit/blob/main/docs/problem-matchers.md
+type Config struct {
+	ProblemMatcher []Matcher `json:"problemMatcher"`
+}
+
+// Matcher is a problem matcher scans the output of the steps and creates annotations from the matched lines.
+type Matcher struct {
+	// Owner is the unique identifier of the matcher. Used to remove the matcher.
+	Owner string `json:"owner"`
+
+	// Severity is the default severity of the annotations created by the matcher. Possible values are error and
+	// warning. If not set, error is used.
+	Severity string `json:"severity,omitempty"`
+
+	// Pattern is the list of patterns to match consecutive lines of the output.
+	Pattern []Pattern `json:"pattern"`
+}
+
+// Pattern is a single line pattern of the problem matcher. Values are the index of the capture group of the regexp
+// to extract the value from the matched line.
+type Pattern struct {
+	Regexp   string `json:"regexp"`
+	File     int    `json:"file,omitempty"`
+	FromPath int    `json:"fromPath,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Severity int    `json:"severity,omitempty"`
+	Code     int    `json:"code,omitempty"`
+	Message  int    `json:"message,omitempty"`
+
+	// Loop indicates the pattern matches repeatedly until it fails to match. Only the last pattern of a multi-line
+	// matcher can loop.
+	Loop bool `json:"loop,omitempty"`
+}
+
+// Registry keeps the registered problem matchers and matches the output lines against them.
+type Registry struct {
+	matchers []*state
+}
+
+// state is the compiled matcher with the state of the multi-line matching.
+type state struct {
+	matcher  Matcher
+	patterns []*regexp.Regexp
+	index    int               // index is the index of the next pattern to match
+	values   map[string]string // values are the captured values from the previous patterns
+	looping  bool              // looping indicates all patterns matched and the last pattern matches repeatedly
+}
+
+// NewRegistry creates a new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Add adds the given matchers to the registry. If a matcher with the same owner already exists, it's replaced.
+func (r *Registry) Add(matchers ...Matcher) error {
+	for _, m := range matchers {
+		s, err := newState(m)
+		if err != nil {
+			return err
+		}
+
+		r.Remove(m.Owner)
+
+		r.matchers = append(r.matchers, s)
+	}
+
+	return nil
+}
+
+// Remove removes the matcher with the given owner from the registry.
+func (r *Registry) Remove(owner string) {
+	for i, s := range r.matchers {
+		if s.matcher.Owner == owner {
+			r.matchers = append(r.matchers[:i], r.matchers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Owners returns the owners of the registered matchers.
+func (r *Registry) Owners() []string {
+	owners := make([]string, 0, len(r.matchers))
+
+	for _, s := range r.matchers {
+		owners = append(owners, s.matcher.Owner)
+	}
+
+	return owners
+}
+
+// Match matches the given line against the registered matchers and returns the annotation if any matcher completes a
+// match with the line. When a matcher completes a match, the other matchers are reset like GitHub runner does.
+func (r *Registry) Match(line string) (model.Annotation, bool) {
+	for _, s := range r.matchers {
+		annotation, ok := s.match(line)
+		if !ok {
+			continue
+		}
+
+		for _, other := range r.matchers {
+			if other != s {
+				other.reset()
+			}
+		}
+
+		return annotation, true
+	}
+
+	return model.Annotation{}, false
+}
+
+func newState(m Matcher) (*state, error) {
+	if m.Owner == "" {
+		return nil, fmt.Errorf("problem matcher owner is required")
+	}
+
+	if len(m.Pattern) == 0 {
+		return nil, fmt.Errorf("problem matcher %s has no pattern", m.Owner)
+	}
+
+	patterns := make([]*regexp.Regexp, 0, len(m.Pattern))
+
+	for i, p := range m.Pattern {
+		if p.Loop && i != len(m.Pattern)-1 {
+			return nil, fmt.Errorf("problem matcher %s: only the last pattern can loop", m.Owner)
+		}
+
+		re, err := regexp.Compile(p.Regexp)
+		if err != nil {
+			return nil, fmt.Errorf("problem matcher %s: invalid regexp %s: %w", m.Owner, p.Regexp, err)
+		}
+
+		patterns = append(patterns, re)
+	}
+
+	if len(m.Pattern) == 1 && m.Pattern[0].Loop {
+		return nil, fmt.Errorf("problem matcher %s: loop requires multiple patterns", m.Owner)
+	}
+
+	return &state{matcher: m, patterns: patterns, values: make(map[string]string)}, nil
+}
+
+// match matches the given line with the next pattern of the matcher. It returns an annotation when the last pattern
+// matches.
+func (s *state) match(line string) (model.Annotation, bool) {
+	last := len(s.patterns) - 1
+
+	// in loop mode, the last pattern matches repeatedly. If it doesn't match, matching starts from the beginning.
+	if s.looping {
+		if captures := s.patterns[last].FindStringSubmatch(line); captures != nil {
+			return s.annotation(s.capture(s.values, last, captures))
+		}
+
+		s.reset()
+	}
+
+	captures := s.patterns[s.index].FindStringSubmatch(line)
+	if captures == nil {
+		// partial multi-line match is broken, try the line with the first pattern again
+		if s.index > 0 {
+			s.reset()
+
+			return s.match(line)
+		}
+
+		return model.Annotation{}, false
+	}
+
+	values := s.capture(s.values, s.index, captures)
+
+	if s.index < last {
+		s.values = values
+		s.index++
+
+		return model.Annotation{}, false
+	}
+
+	if s.matcher.Pattern[last].Loop {
+		s.looping = true
+	} else {
+		s.reset()
+	}
+
+	return s.annotation(values)
+}
+
+// capture returns a copy of the given values with the captured values of the pattern at the given index.
+func (s *state) capture(values map[string]string, index int, captures []string) map[string]string {
+	p := s.matcher.Pattern[index]
+
+	copied := make(map[string]string, len(values))
+
+	for k, v := range values {
+		copied[k] = v
+	}
+
+	groups := map[string]int{
+		"file":     p.File,
+		"fromPath": p.FromPath,
+		"line":     p.Line,
+		"column":   p.Column,
+		"severity": p.Severity,
+		"code":     p.Code,
+		"message":  p.Message,
+	}
+
+	for k, group := range groups {
+		if group > 0 && group < len(captures) && captures[group] != "" {
+			copied[k] = captures[group]
+		}
+	}
+
+	return copied
+}
+
+// annotation creates an annotation from the captured values. Message is required to create an annotation.
+func (s *state) annotation(values map[string]string) (model.Annotation, bool) {
+	if values["message"] == "" {
+		return model.Annotation{}, false
+	}
+
+	severity := values["severity"]
+	if severity == "" {
+		severity = s.matcher.Severity
+	}
+
+	file := values["file"]
+
+	// relative file paths are resolved from the directory of the fromPath, e.g. project file of the tool
+	if file != "" && !filepath.IsAbs(file) && values["fromPath"] != "" {
+		file = filepath.Join(filepath.Dir(values["fromPath"]), file)
+	}
+
+	// ignoring errors since invalid or missing values are not part of the annotation location
+	line, _ := strconv.Atoi(values["line"])
+	col, _ := strconv.Atoi(values["column"])
+
+	return model.Annotation{
+		Severity: parseSeverity(severity),
+		Message:  values["message"],
+		Title:    values["code"],
+		File:     file,
+		Line:     line,
+		Col:      col,
+	}, true
+}
+
+// reset resets the multi-line matching state of the matcher.
+func (s *state) reset() {
+	s.index = 0
+	s.values = make(map[string]string)
+	s.looping = false
+}
+
+// parseSeverity converts the severity value to annotation severity. Unknown values are treated as error.
+func parseSeverity(severity string) model.AnnotationSeverity {
+	switch {
+	case strings.HasPrefix(strings.ToLower(severity), "warn"):
+		return model.AnnotationSeverityWarning
+	case strings.HasPrefix(strings.ToLower(severity), "notice"):
+		return model.AnnotationSeverityNotice
+	default:
+		return model.AnnotationSeverityError
+	}
+}
@@ -0,0 +1,159 @@
+package matcher
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aweris/gale/common/model"
+)
+
+func TestRegistry_Match(t *testing.T) {
+	tests := []struct {
+		name     string
+		matchers []Matcher
+		lines    []string
+		expected []model.Annotation
+	}{
+		{
+			name: "Single line pattern",
+			matchers: []Matcher{
+				{
+					Owner: "go",
+					Pattern: []Pattern{
+						{Regexp: `^([^:]+):(\d+):(\d+): (.+)$`, File: 1, Line: 2, Column: 3, Message: 4},
+					},
+				},
+			},
+			lines: []string{"main.go:10:5: undefined: foo", "ok"},
+			expected: []model.Annotation{
+				{Severity: model.AnnotationSeverityError, Message: "undefined: foo", File: "main.go", Line: 10, Col: 5},
+			},
+		},
+		{
+			name: "Default severity and code",
+			matchers: []Matcher{
+				{
+					Owner:    "lint",
+					Severity: "warning",
+					Pattern: []Pattern{
+						{Regexp: `^(\S+):(\d+) (\S+) (.+)$`, File: 1, Line: 2, Code: 3, Message: 4},
+					},
+				},
+			},
+			lines: []string{"app.js:3 no-unused unused variable"},
+			expected: []model.Annotation{
+				{Severity: model.AnnotationSeverityWarning, Message: "unused variable", Title: "no-unused", File: "app.js", Line: 3},
+			},
+		},
+		{
+			name: "Multi-line pattern with loop",
+			matchers: []Matcher{
+				{
+					Owner: "eslint-stylish",
+					Pattern: []Pattern{
+						{Regexp: `^([^\s].*)$`, File: 1},
+						{Regexp: `^\s+(\d+):(\d+)\s+(error|warning)\s+(.*)$`, Line: 1, Column: 2, Severity: 3, Message: 4, Loop: true},
+					},
+				},
+			},
+			lines: []string{
+				"src/app.js",
+				"  1:10  error    'a' is defined but never used",
+				"  2:3   warning  Unexpected console statement",
+				"src/lib.js",
+				"  5:1   error    Missing semicolon",
+			},
+			expected: []model.Annotation{
+				{Severity: model.AnnotationSeverityError, Message: "'a' is defined but never used", File: "src/app.js", Line: 1, Col: 10},
+				{Severity: model.AnnotationSeverityWarning, Message: "Unexpected console statement", File: "src/app.js", Line: 2, Col: 3},
+				{Severity: model.AnnotationSeverityError, Message: "Missing semicolon", File: "src/lib.js", Line: 5, Col: 1},
+			},
+		},
+		{
+			name: "Multi-line pattern broken",
+			matchers: []Matcher{
+				{
+					Owner: "multi",
+					Pattern: []Pattern{
+						{Regexp: `^FILE (\S+)$`, File: 1},
+						{Regexp: `^ERROR (.+)$`, Message: 1},
+					},
+				},
+			},
+			lines: []string{"FILE a.txt", "something else", "ERROR ignored", "FILE b.txt", "ERROR reported"},
+			expected: []model.Annotation{
+				{Severity: model.AnnotationSeverityError, Message: "reported", File: "b.txt"},
+			},
+		},
+		{
+			name: "File relative to fromPath",
+			matchers: []Matcher{
+				{
+					Owner: "tsc",
+					Pattern: []Pattern{
+						{Regexp: `^(\S+)\|(\S+)\|(.+)$`, FromPath: 1, File: 2, Message: 3},
+					},
+				},
+			},
+			lines: []string{"web/tsconfig.json|src/index.ts|Type error"},
+			expected: []model.Annotation{
+				{Severity: model.AnnotationSeverityError, Message: "Type error", File: "web/src/index.ts"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+
+			if err := registry.Add(tt.matchers...); err != nil {
+				t.Fatalf("Failed to add matchers: %v", err)
+			}
+
+			var annotations []model.Annotation
+
+			for _, line := range tt.lines {
+				if annotation, ok := registry.Match(line); ok {
+					annotations = append(annotations, annotation)
+				}
+			}
+
+			if !reflect.DeepEqual(annotations, tt.expected) {
+				t.Errorf("Expected annotations %+v, but got %+v", tt.expected, annotations)
+			}
+		})
+	}
+}
+
+func TestRegistry_AddRemove(t *testing.T) {
+	registry := NewRegistry()
+
+	pattern := []Pattern{{Regexp: `^(.+)$`, Message: 1}}
+
+	if err := registry.Add(Matcher{Owner: "a", Pattern: pattern}, Matcher{Owner: "b", Pattern: pattern}); err != nil {
+		t.Fatalf("Failed to add matchers: %v", err)
+	}
+
+	// adding a matcher with the same owner replaces the existing one
+	if err := registry.Add(Matcher{Owner: "a", Pattern: pattern}); err != nil {
+		t.Fatalf("Failed to add matchers: %v", err)
+	}
+
+	if owners := registry.Owners(); !reflect.DeepEqual(owners, []string{"b", "a"}) {
+		t.Errorf("Expected owners [b a], but got %v", owners)
+	}
+
+	registry.Remove("b")
+
+	if owners := registry.Owners(); !reflect.DeepEqual(owners, []string{"a"}) {
+		t.Errorf("Expected owners [a], but got %v", owners)
+	}
+
+	if err := registry.Add(Matcher{Owner: "c", Pattern: []Pattern{{Regexp: `(`}}}); err == nil {
+		t.Errorf("Expected error for invalid regexp, but got nil")
+	}
+
+	if err := registry.Add(Matcher{Owner: "d", Pattern: []Pattern{{Regexp: `a`, Loop: true}, {Regexp: `b`}}}); err == nil {
+		t.Errorf("Expected error for loop in non-last pattern, but got nil")
+	}
+}
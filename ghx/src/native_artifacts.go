@@ -0,0 +1,369 @@
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/common/model"
+
+	"ghx/context"
+)
+
+var (
+	_ NativeAction = new(nativeUploadArtifact)
+	_ NativeAction = new(nativeDownloadArtifact)
+)
+
+// artifactAPIVersion is the api version of the artifact service requests, same as the actions toolkit.
+const artifactAPIVersion = "6.0-preview"
+
+// artifactResponse is the artifact returned by the artifact service.
+type artifactResponse struct {
+	Name                     string `json:"name"`
+	FileContainerResourceURL string `json:"fileContainerResourceUrl"`
+}
+
+// artifactContainerEntry is a single item of an artifact container returned by the artifact service.
+type artifactContainerEntry struct {
+	Path            string `json:"path"`
+	ItemType        string `json:"itemType"`
+	ContentLocation string `json:"contentLocation"`
+}
+
+// nativeUploadArtifact is the native implementation of actions/upload-artifact. Files are uploaded to the artifact
+// service directly.
+//
+// See: https://github.com/actions/upload-artifact
+type nativeUploadArtifact struct{}
+
+func (a *nativeUploadArtifact) meta() model.CustomActionMeta {
+	return model.CustomActionMeta{
+		Name: "Upload a Build Artifact",
+		Inputs: map[string]model.CustomActionInput{
+			"name":              {Default: "artifact"},
+			"path":              {Required: true},
+			"if-no-files-found": {Default: "warn"},
+		},
+	}
+}
+
+func (a *nativeUploadArtifact) main(ctx *context.Context, inputs context.InputsContext) error {
+	if ctx.Actions.RuntimeURL == "" {
+		return errors.New("artifact service is not available, ACTIONS_RUNTIME_URL is not set")
+	}
+
+	name := inputs["name"]
+
+	patterns := splitInputLines(inputs["path"])
+	if len(patterns) == 0 {
+		return errors.New("input required and not supplied: path")
+	}
+
+	files, root, err := findArtifactFiles(ctx.Github.Workspace, patterns)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		msg := fmt.Sprintf("No files were found with the provided path: %s. No artifacts will be uploaded.", strings.Join(patterns, ", "))
+
+		switch inputs["if-no-files-found"] {
+		case "error":
+			return errors.New(msg)
+		case "ignore":
+			log.Info(msg)
+		default:
+			log.Warn(msg)
+		}
+
+		return nil
+	}
+
+	endpoint := fmt.Sprintf("%s_apis/pipelines/workflows/%s/artifacts?api-version=%s", ctx.Actions.RuntimeURL, ctx.Github.RunID, artifactAPIVersion)
+
+	req, err := newServiceJSONRequest(ctx, http.MethodPost, endpoint, map[string]string{"Type": "actions_storage", "Name": name})
+	if err != nil {
+		return err
+	}
+
+	var container artifactResponse
+
+	if _, err := doServiceRequest(req, &container); err != nil {
+		return fmt.Errorf("failed to create artifact container: %w", err)
+	}
+
+	var total int64
+
+	for _, file := range files {
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+
+		size, err := uploadArtifactFile(ctx, container.FileContainerResourceURL, path.Join(name, filepath.ToSlash(rel)), file)
+		if err != nil {
+			return fmt.Errorf("failed to upload %s: %w", file, err)
+		}
+
+		total += size
+	}
+
+	req, err = newServiceJSONRequest(ctx, http.MethodPatch, endpoint+"&artifactName="+url.QueryEscape(name), map[string]int64{"Size": total})
+	if err != nil {
+		return err
+	}
+
+	if _, err := doServiceRequest(req, nil); err != nil {
+		return fmt.Errorf("failed to finalize artifact upload: %w", err)
+	}
+
+	log.Info(fmt.Sprintf("Artifact %s has been successfully uploaded! Total size: %d bytes, %d file(s)", name, total, len(files)))
+
+	return nil
+}
+
+// findArtifactFiles returns the files matching the given patterns and the root directory the artifact paths are
+// relative to. The root is the least common ancestor of the search paths, same as the original action.
+func findArtifactFiles(workspace string, patterns []string) ([]string, string, error) {
+	files, err := fs.GlobFiles(workspace, patterns)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var searchPaths []string
+
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			continue
+		}
+
+		base := fs.GlobBase(workspace, pattern)
+
+		// a single file is uploaded to the root of the artifact
+		if info, err := os.Stat(base); err == nil && !info.IsDir() {
+			base = filepath.Dir(base)
+		}
+
+		searchPaths = append(searchPaths, base)
+	}
+
+	return files, commonDir(searchPaths), nil
+}
+
+// commonDir returns the least common ancestor directory of the given absolute paths.
+func commonDir(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+
+	common := paths[0]
+
+	for _, p := range paths[1:] {
+		for common != p && !strings.HasPrefix(p, strings.TrimSuffix(common, string(filepath.Separator))+string(filepath.Separator)) {
+			common = filepath.Dir(common)
+		}
+	}
+
+	return common
+}
+
+// uploadArtifactFile uploads the given file to the artifact container with the given item path and returns the size of
+// the file.
+func uploadArtifactFile(ctx *context.Context, containerURL, itemPath, file string) (int64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	size := info.Size()
+
+	req, err := newServiceRequest(ctx, http.MethodPut, containerURL+"?itemPath="+url.QueryEscape(itemPath), f)
+	if err != nil {
+		return 0, err
+	}
+
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", max(size-1, 0), size))
+
+	if _, err := doServiceRequest(req, nil); err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// nativeDownloadArtifact is the native implementation of actions/download-artifact. Files are downloaded from the
+// artifact service directly.
+//
+// See: https://github.com/actions/download-artifact
+type nativeDownloadArtifact struct{}
+
+func (a *nativeDownloadArtifact) meta() model.CustomActionMeta {
+	return model.CustomActionMeta{
+		Name: "Download a Build Artifact",
+		Inputs: map[string]model.CustomActionInput{
+			"name": {},
+			"path": {},
+		},
+		Outputs: map[string]model.CustomActionOutput{
+			"download-path": {Description: "Path of artifact download"},
+		},
+	}
+}
+
+func (a *nativeDownloadArtifact) main(ctx *context.Context, inputs context.InputsContext) error {
+	if ctx.Actions.RuntimeURL == "" {
+		return errors.New("artifact service is not available, ACTIONS_RUNTIME_URL is not set")
+	}
+
+	name := inputs["name"]
+
+	dir := inputs["path"]
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(ctx.Github.Workspace, dir)
+	}
+
+	endpoint := fmt.Sprintf("%s_apis/pipelines/workflows/%s/artifacts?api-version=%s", ctx.Actions.RuntimeURL, ctx.Github.RunID, artifactAPIVersion)
+
+	req, err := newServiceRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	var list struct {
+		Value []artifactResponse `json:"value"`
+	}
+
+	if _, err := doServiceRequest(req, &list); err != nil {
+		return fmt.Errorf("failed to list artifacts: %w", err)
+	}
+
+	found := false
+
+	for _, artifact := range list.Value {
+		if name != "" && artifact.Name != name {
+			continue
+		}
+
+		found = true
+
+		// all artifacts are downloaded to their own directories if the name is not specified
+		target := dir
+		if name == "" {
+			target = filepath.Join(dir, artifact.Name)
+		}
+
+		if err := downloadArtifact(ctx, artifact, target); err != nil {
+			return fmt.Errorf("failed to download artifact %s: %w", artifact.Name, err)
+		}
+
+		log.Info(fmt.Sprintf("Artifact %s was downloaded to %s", artifact.Name, target))
+	}
+
+	if name != "" && !found {
+		return fmt.Errorf("unable to find an artifact with the name: %s", name)
+	}
+
+	return ctx.SetStepOutput("download-path", dir)
+}
+
+// downloadArtifact downloads all files of the given artifact to the target directory.
+func downloadArtifact(ctx *context.Context, artifact artifactResponse, target string) error {
+	req, err := newServiceRequest(ctx, http.MethodGet, artifact.FileContainerResourceURL+"?itemPath="+url.QueryEscape(artifact.Name), nil)
+	if err != nil {
+		return err
+	}
+
+	var items struct {
+		Value []artifactContainerEntry `json:"value"`
+	}
+
+	if _, err := doServiceRequest(req, &items); err != nil {
+		return err
+	}
+
+	for _, item := range items.Value {
+		if item.ItemType != "file" {
+			continue
+		}
+
+		rel := strings.TrimPrefix(item.Path, artifact.Name+"/")
+
+		dst := filepath.Join(target, filepath.FromSlash(rel))
+
+		if r, err := filepath.Rel(target, dst); err != nil || strings.HasPrefix(r, "..") {
+			return fmt.Errorf("invalid artifact item path: %s", item.Path)
+		}
+
+		if err := downloadArtifactFile(ctx, item.ContentLocation, dst); err != nil {
+			return fmt.Errorf("failed to download %s: %w", item.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// downloadArtifactFile downloads a single file from the given location to the destination. Gzip encoded contents are
+// decompressed.
+func downloadArtifactFile(ctx *context.Context, location, dst string) error {
+	req, err := newServiceRequest(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/octet-stream")
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var body io.Reader = resp.Body
+
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+
+		body = gz
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, body)
+
+	return err
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/common/model"
+
+	"ghx/context"
+)
+
+var _ NativePostAction = new(nativeCache)
+
+const (
+	// cacheCompressionMethod is the compression method of the cache archives. gzip is used since it's available in all
+	// runner images and the actions toolkit falls back to it when zstd is not available.
+	cacheCompressionMethod = "gzip"
+
+	// cacheVersionSalt is the salt used by the actions toolkit to compute the cache version.
+	cacheVersionSalt = "1.0"
+
+	// cacheUploadChunkSize is the size of the chunks used to upload the cache archive, same as the actions toolkit.
+	cacheUploadChunkSize = 32 * 1024 * 1024
+
+	// state keys used by actions/cache to pass the cache keys from the main stage to the post stage
+	cacheStateKey    = "CACHE_KEY"
+	cacheStateResult = "CACHE_RESULT"
+)
+
+// nativeCache is the native implementation of actions/cache. The cache is restored from and saved to the artifact cache
+// service directly, using the same archive format and cache version as the actions toolkit to be able to share the
+// caches with the original action.
+//
+// See: https://github.com/actions/cache
+type nativeCache struct{}
+
+// cacheEntry is the cache entry returned by the artifact cache service.
+type cacheEntry struct {
+	CacheKey        string `json:"cacheKey"`
+	ArchiveLocation string `json:"archiveLocation"`
+}
+
+func (c *nativeCache) meta() model.CustomActionMeta {
+	return model.CustomActionMeta{
+		Name: "Cache",
+		Inputs: map[string]model.CustomActionInput{
+			"path":               {Required: true},
+			"key":                {Required: true},
+			"restore-keys":       {},
+			"fail-on-cache-miss": {Default: "false"},
+			"lookup-only":        {Default: "false"},
+		},
+		Outputs: map[string]model.CustomActionOutput{
+			"cache-hit": {Description: "A boolean value to indicate an exact match was found for the primary key"},
+		},
+		Runs: model.CustomActionRuns{PostIf: "success()"},
+	}
+}
+
+func (c *nativeCache) main(ctx *context.Context, inputs context.InputsContext) error {
+	if ctx.Actions.CacheURL == "" {
+		return errors.New("cache service is not available, ACTIONS_CACHE_URL is not set")
+	}
+
+	key := inputs["key"]
+
+	keys := append([]string{key}, splitInputLines(inputs["restore-keys"])...)
+
+	for _, k := range keys {
+		if err := validateCacheKey(k); err != nil {
+			return err
+		}
+	}
+
+	paths := splitInputLines(inputs["path"])
+	if len(paths) == 0 {
+		return errors.New("input required and not supplied: path")
+	}
+
+	if err := ctx.SetStepState(cacheStateKey, key); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("keys", strings.Join(keys, ","))
+	query.Set("version", cacheVersion(paths))
+
+	req, err := newServiceRequest(ctx, http.MethodGet, ctx.Actions.CacheURL+"_apis/artifactcache/cache?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	var entry cacheEntry
+
+	status, err := doServiceRequest(req, &entry)
+	if err != nil {
+		return fmt.Errorf("failed to look up cache: %w", err)
+	}
+
+	if status == http.StatusNoContent || entry.ArchiveLocation == "" {
+		if inputs["fail-on-cache-miss"] == "true" {
+			return fmt.Errorf("failed to restore cache entry. Exiting as fail-on-cache-miss is set. Input key: %s", key)
+		}
+
+		log.Info(fmt.Sprintf("Cache not found for input keys: %s", strings.Join(keys, ", ")))
+
+		return ctx.SetStepOutput("cache-hit", "false")
+	}
+
+	if err := ctx.SetStepState(cacheStateResult, entry.CacheKey); err != nil {
+		return err
+	}
+
+	hit := strings.EqualFold(entry.CacheKey, key)
+
+	if inputs["lookup-only"] == "true" {
+		log.Info(fmt.Sprintf("Cache found and can be restored from key: %s", entry.CacheKey))
+
+		return ctx.SetStepOutput("cache-hit", fmt.Sprintf("%t", hit))
+	}
+
+	if err := restoreCacheArchive(ctx, entry.ArchiveLocation); err != nil {
+		return fmt.Errorf("failed to restore cache: %w", err)
+	}
+
+	log.Info(fmt.Sprintf("Cache restored from key: %s", entry.CacheKey))
+
+	return ctx.SetStepOutput("cache-hit", fmt.Sprintf("%t", hit))
+}
+
+func (c *nativeCache) post(ctx *context.Context, inputs context.InputsContext) error {
+	state := ctx.Steps[ctx.Execution.StepRun.Step.ID].State
+
+	key := state[cacheStateKey]
+	if key == "" {
+		log.Warn("Cache not saved, primary key is not found in the state.")
+		return nil
+	}
+
+	if matched := state[cacheStateResult]; strings.EqualFold(matched, key) {
+		log.Info(fmt.Sprintf("Cache hit occurred on the primary key %s, not saving cache.", key))
+		return nil
+	}
+
+	paths := splitInputLines(inputs["path"])
+
+	archive, size, err := createCacheArchive(ctx, paths)
+	if err != nil {
+		return fmt.Errorf("failed to create cache archive: %w", err)
+	}
+	defer os.RemoveAll(filepath.Dir(archive))
+
+	// same as the original action, failing to reserve the cache is not an error. Another job might be saving the same
+	// cache at the same time.
+	req, err := newServiceJSONRequest(ctx, http.MethodPost, ctx.Actions.CacheURL+"_apis/artifactcache/caches", map[string]interface{}{
+		"key":       key,
+		"version":   cacheVersion(paths),
+		"cacheSize": size,
+	})
+	if err != nil {
+		return err
+	}
+
+	var reserved struct {
+		CacheID uint64 `json:"cacheId"`
+	}
+
+	if _, err := doServiceRequest(req, &reserved); err != nil {
+		log.Warn(fmt.Sprintf("Failed to save: Unable to reserve cache with key %s, another job may be creating this cache.", key))
+		return nil
+	}
+
+	endpoint := fmt.Sprintf("%s_apis/artifactcache/caches/%d", ctx.Actions.CacheURL, reserved.CacheID)
+
+	if err := uploadCacheArchive(ctx, endpoint, archive, size); err != nil {
+		return fmt.Errorf("failed to upload cache: %w", err)
+	}
+
+	req, err = newServiceJSONRequest(ctx, http.MethodPost, endpoint, map[string]interface{}{"size": size})
+	if err != nil {
+		return err
+	}
+
+	if _, err := doServiceRequest(req, nil); err != nil {
+		return fmt.Errorf("failed to commit cache: %w", err)
+	}
+
+	log.Info(fmt.Sprintf("Cache saved with key: %s", key))
+
+	return nil
+}
+
+// cacheVersion returns the version of the cache for the given paths. The version is computed same as the actions
+// toolkit, so caches can be shared with the original action using the same compression method.
+func cacheVersion(paths []string) string {
+	components := append(append([]string{}, paths...), cacheCompressionMethod, cacheVersionSalt)
+
+	sum := sha256.Sum256([]byte(strings.Join(components, "|")))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// validateCacheKey validates the given cache key same as the actions toolkit.
+func validateCacheKey(key string) error {
+	if key == "" {
+		return errors.New("input required and not supplied: key")
+	}
+
+	if len(key) > 512 {
+		return fmt.Errorf("key validation failed: %s cannot be larger than 512 characters", key)
+	}
+
+	if strings.Contains(key, ",") {
+		return fmt.Errorf("key validation failed: %s cannot contain commas", key)
+	}
+
+	return nil
+}
+
+// createCacheArchive creates a gzip compressed tar archive of the paths matching the given patterns and returns the
+// path and the size of the archive. Paths are stored relative to the workspace, same as the actions toolkit.
+func createCacheArchive(ctx *context.Context, patterns []string) (string, int64, error) {
+	workspace := ctx.Github.Workspace
+
+	paths, err := fs.Glob(workspace, patterns)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if len(paths) == 0 {
+		return "", 0, errors.New("path validation error: path(s) specified in the action for caching do(es) not exist, hence no cache is being saved")
+	}
+
+	dir, err := os.MkdirTemp(ctx.Runner.Temp, "cache-")
+	if err != nil {
+		return "", 0, err
+	}
+
+	manifest := make([]string, 0, len(paths))
+
+	for _, p := range paths {
+		rel, err := filepath.Rel(workspace, p)
+		if err != nil {
+			return "", 0, err
+		}
+
+		manifest = append(manifest, rel)
+	}
+
+	manifestPath := filepath.Join(dir, "manifest.txt")
+
+	if err := os.WriteFile(manifestPath, []byte(strings.Join(manifest, "\n")), 0600); err != nil {
+		return "", 0, err
+	}
+
+	archive := filepath.Join(dir, "cache.tgz")
+
+	//nolint:gosec // arguments are controlled by the action
+	cmd := exec.Command("tar", "--posix", "-cz", "-f", archive, "-P", "-C", workspace, "--files-from", manifestPath)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", 0, fmt.Errorf("tar failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	info, err := os.Stat(archive)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return archive, info.Size(), nil
+}
+
+// restoreCacheArchive downloads the cache archive from the given location and extracts it to the workspace.
+func restoreCacheArchive(ctx *context.Context, location string) error {
+	req, err := newServiceRequest(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download cache archive, status %d", resp.StatusCode)
+	}
+
+	//nolint:gosec // arguments are controlled by the action
+	cmd := exec.Command("tar", "-xz", "-f", "-", "-P", "-C", ctx.Github.Workspace)
+	cmd.Stdin = resp.Body
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("tar failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// uploadCacheArchive uploads the archive to the reserved cache entry in chunks.
+func uploadCacheArchive(ctx *context.Context, endpoint, archive string, size int64) error {
+	file, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for start := int64(0); start < size; start += cacheUploadChunkSize {
+		end := min(start+cacheUploadChunkSize, size) - 1
+
+		req, err := newServiceRequest(ctx, http.MethodPatch, endpoint, io.NewSectionReader(file, start, end-start+1))
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Content-Type", "application/octet-stream")
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/*", start, end))
+
+		if _, err := doServiceRequest(req, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
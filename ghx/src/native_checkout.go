@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/common/model"
+
+	"ghx/context"
+)
+
+var _ NativeAction = new(nativeCheckout)
+
+// nativeCheckout is the native implementation of actions/checkout. The source of the repository is already mounted to
+// the workspace, so the source is copied from the workspace instead of cloning the repository.
+//
+// See: https://github.com/actions/checkout
+type nativeCheckout struct{}
+
+func (c *nativeCheckout) meta() model.CustomActionMeta {
+	return model.CustomActionMeta{
+		Name: "Checkout",
+		Inputs: map[string]model.CustomActionInput{
+			"repository":  {Default: "${{ github.repository }}"},
+			"ref":         {},
+			"path":        {},
+			"fetch-depth": {Default: "1"},
+		},
+		Outputs: map[string]model.CustomActionOutput{
+			"ref":    {Description: "The branch, tag or SHA that was checked out"},
+			"commit": {Description: "The commit SHA that was checked out"},
+		},
+	}
+}
+
+func (c *nativeCheckout) main(ctx *context.Context, inputs context.InputsContext) error {
+	if repo := inputs["repository"]; repo != "" && !strings.EqualFold(repo, ctx.Github.Repository) {
+		return fmt.Errorf("native checkout only supports the repository of the workflow %s, got %s", ctx.Github.Repository, repo)
+	}
+
+	workspace := ctx.Github.Workspace
+
+	target := filepath.Join(workspace, inputs["path"])
+
+	if rel, err := filepath.Rel(workspace, target); err != nil || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("repository path '%s' is not under '%s'", target, workspace)
+	}
+
+	// the workspace is the mounted source of the repository, only copy it when a different path is requested
+	if target != workspace {
+		log.Info(fmt.Sprintf("Copying '%s' to '%s'", workspace, target))
+
+		if err := copyDir(workspace, target); err != nil {
+			return fmt.Errorf("failed to copy repository source: %w", err)
+		}
+	}
+
+	ref, commit, err := checkoutRef(target, inputs["ref"], inputs["fetch-depth"])
+	if err != nil {
+		return err
+	}
+
+	if ref == "" {
+		ref = ctx.Github.Ref
+	}
+
+	if commit == "" {
+		commit = ctx.Github.SHA
+	}
+
+	if err := ctx.SetStepOutput("ref", ref); err != nil {
+		return err
+	}
+
+	return ctx.SetStepOutput("commit", commit)
+}
+
+// checkoutRef checks out the given ref in the repository at the given directory and returns the checked out ref and
+// commit. If the source has no git metadata, only the mounted source is available and empty values are returned.
+func checkoutRef(dir, ref, fetchDepth string) (string, string, error) {
+	repo, err := git.PlainOpen(dir)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		if ref != "" {
+			return "", "", fmt.Errorf("ref %s can not be checked out, the source has no git metadata", ref)
+		}
+
+		return "", "", nil
+	}
+
+	if err != nil {
+		return "", "", fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	if ref != "" {
+		hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+		if err != nil {
+			return "", "", fmt.Errorf("failed to resolve ref %s: %w", ref, err)
+		}
+
+		wt, err := repo.Worktree()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get worktree: %w", err)
+		}
+
+		if err := wt.Checkout(&git.CheckoutOptions{Hash: *hash, Force: true}); err != nil {
+			return "", "", fmt.Errorf("failed to checkout %s: %w", ref, err)
+		}
+
+		log.Info(fmt.Sprintf("Checked out '%s' at %s", ref, hash.String()))
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+
+	if err := applyFetchDepth(repo, head.Hash(), fetchDepth); err != nil {
+		return "", "", err
+	}
+
+	return ref, head.Hash().String(), nil
+}
+
+// applyFetchDepth limits the history of the repository to the given fetch-depth from the given commit, same as a
+// shallow clone does. Depth 0 requires the full history. The mounted source can't be deepened without network access,
+// so a depth exceeding the history of a shallow source is an error.
+func applyFetchDepth(repo *git.Repository, head plumbing.Hash, fetchDepth string) error {
+	depth, err := strconv.Atoi(fetchDepth)
+	if err != nil || depth < 0 {
+		return fmt.Errorf("invalid fetch-depth %q, must be a non-negative number", fetchDepth)
+	}
+
+	shallow, err := repo.Storer.Shallow()
+	if err != nil {
+		return fmt.Errorf("failed to read shallow commits: %w", err)
+	}
+
+	if depth == 0 {
+		if len(shallow) > 0 {
+			return errors.New("fetch-depth 0 requires the full history, but the source is a shallow clone and history can't be fetched without network access")
+		}
+
+		return nil
+	}
+
+	boundary := make(map[plumbing.Hash]bool, len(shallow))
+
+	for _, hash := range shallow {
+		boundary[hash] = true
+	}
+
+	var (
+		level   = 1
+		commits = []plumbing.Hash{head}
+		seen    = map[plumbing.Hash]bool{head: true}
+		cut     []plumbing.Hash
+	)
+
+	// walk the history level by level, commits at the requested depth become the new shallow commits
+	for len(commits) > 0 {
+		var parents []plumbing.Hash
+
+		for _, hash := range commits {
+			commit, err := repo.CommitObject(hash)
+			if err != nil {
+				return fmt.Errorf("failed to read commit %s: %w", hash, err)
+			}
+
+			if commit.NumParents() == 0 {
+				continue
+			}
+
+			if level == depth {
+				cut = append(cut, hash)
+				continue
+			}
+
+			if boundary[hash] {
+				return fmt.Errorf("fetch-depth %d exceeds the history of the source, the source is a shallow clone and history can't be fetched without network access", depth)
+			}
+
+			for _, parent := range commit.ParentHashes {
+				if !seen[parent] {
+					seen[parent] = true
+					parents = append(parents, parent)
+				}
+			}
+		}
+
+		commits = parents
+		level++
+	}
+
+	return repo.Storer.SetShallow(cut)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// newTestRepository creates a repository with the given number of commits in a linear history and returns the hashes
+// of the commits, the last one is the HEAD.
+func newTestRepository(t *testing.T, count int) (*git.Repository, []plumbing.Hash) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hashes []plumbing.Hash
+
+	for idx := 0; idx < count; idx++ {
+		if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte{byte('a' + idx)}, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := wt.Add("file.txt"); err != nil {
+			t.Fatal(err)
+		}
+
+		hash, err := wt.Commit("commit", &git.CommitOptions{Author: &object.Signature{Name: "test", When: time.Now()}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hashes = append(hashes, hash)
+	}
+
+	return repo, hashes
+}
+
+func TestApplyFetchDepth(t *testing.T) {
+	tests := []struct {
+		name        string
+		depth       string
+		shallow     int // shallow is the index of the commit to mark as shallow before, -1 for a full history
+		wantShallow int // wantShallow is the index of the expected shallow commit, -1 for a full history
+		wantErr     bool
+	}{
+		{name: "depth 1", depth: "1", shallow: -1, wantShallow: 3},
+		{name: "depth 3", depth: "3", shallow: -1, wantShallow: 1},
+		{name: "deeper than history", depth: "10", shallow: -1, wantShallow: -1},
+		{name: "full history", depth: "0", shallow: -1, wantShallow: -1},
+		{name: "shallow source", depth: "2", shallow: 2, wantShallow: 2},
+		{name: "deepen shallow source", depth: "3", shallow: 2, wantErr: true},
+		{name: "unshallow source", depth: "0", shallow: 2, wantErr: true},
+		{name: "invalid depth", depth: "-1", shallow: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, hashes := newTestRepository(t, 4)
+
+			if tt.shallow >= 0 {
+				if err := repo.Storer.SetShallow([]plumbing.Hash{hashes[tt.shallow]}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := applyFetchDepth(repo, hashes[len(hashes)-1], tt.depth)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, but got %v", tt.wantErr, err)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			shallow, err := repo.Storer.Shallow()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var expected []plumbing.Hash
+
+			if tt.wantShallow >= 0 {
+				expected = append(expected, hashes[tt.wantShallow])
+			}
+
+			if len(shallow) != len(expected) || (len(expected) > 0 && shallow[0] != expected[0]) {
+				t.Errorf("Expected shallow commits %v, but got %v", expected, shallow)
+			}
+		})
+	}
+}
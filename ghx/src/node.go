@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/common/model"
+
+	"ghx/context"
+)
+
+// nodeImage is the image used to provision Node.js runtimes. Debian based slim images are used since the runtime is
+// executed in Ubuntu based runner images.
+const nodeImage = "node:%s-bullseye-slim"
+
+// vendoredNodeDir is the directory of the Node.js runtimes in the actions directory. Vendored runtimes are used when
+// actions are resolved offline since the runtimes can't be pulled from the registry.
+const vendoredNodeDir = ".node"
+
+// NodeRuntime is a Node.js runtime provisioned to run JavaScript actions.
+type NodeRuntime struct {
+	Version string // Version is the exact version of the runtime. e.g. v20.10.0
+	Path    string // Path is the path of the node binary.
+}
+
+// EnsureNodeRuntime ensures that the Node.js runtime required by the given runs.using value exists in the tools
+// directory and returns it. The tools directory is a cache volume, so each major version is provisioned only once. In
+// offline mode, the runtime is used from the actions directory instead of provisioning it from the registry.
+func EnsureNodeRuntime(ctx *context.Context, using model.CustomActionRunsUsing) (*NodeRuntime, error) {
+	major, err := nodeMajorVersion(using)
+	if err != nil {
+		return nil, err
+	}
+
+	tools, err := ctx.GetToolsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		dir         = filepath.Join(tools, "node", major)
+		versionFile = filepath.Join(dir, ".version")
+	)
+
+	exist, err := fs.Exists(versionFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if node runtime exists locally: %w", err)
+	}
+
+	if !exist && ctx.GhxConfig.ActionsOffline {
+		dir = filepath.Join(ctx.GhxConfig.ActionsDir, vendoredNodeDir, major)
+		versionFile = filepath.Join(dir, ".version")
+
+		if exist, err = fs.Exists(versionFile); err != nil {
+			return nil, fmt.Errorf("failed to check if node runtime is vendored: %w", err)
+		}
+
+		if !exist {
+			return nil, fmt.Errorf("node %s runtime not found in the actions directory, vendor the actions before running offline", major)
+		}
+	}
+
+	if !exist {
+		log.Debugf("node runtime does not exist locally, provisioning...", "version", major, "target", dir)
+
+		if err := provisionNodeRuntime(ctx, major, dir); err != nil {
+			return nil, fmt.Errorf("failed to provision node %s runtime: %w", major, err)
+		}
+	}
+
+	version, err := os.ReadFile(versionFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NodeRuntime{Version: strings.TrimSpace(string(version)), Path: filepath.Join(dir, "bin", "node")}, nil
+}
+
+// nodeMajorVersion returns the Node.js major version for the given runs.using value.
+func nodeMajorVersion(using model.CustomActionRunsUsing) (string, error) {
+	switch using {
+	case model.ActionRunsUsingNode12:
+		return "12", nil
+	case model.ActionRunsUsingNode16:
+		return "16", nil
+	case model.ActionRunsUsingNode20:
+		return "20", nil
+	default:
+		return "", fmt.Errorf("not a node runtime: %s", using)
+	}
+}
+
+// provisionNodeRuntime exports the Node.js runtime of the given major version from the official image to the given
+// directory and records the exact version of the runtime in the .version file.
+func provisionNodeRuntime(ctx *context.Context, major, dir string) error {
+	ctr := ctx.Dagger.Client.Container().From(fmt.Sprintf(nodeImage, major))
+
+	version, err := ctr.WithExec([]string{"node", "--version"}).Stdout(ctx.Context)
+	if err != nil {
+		return err
+	}
+
+	if err := fs.EnsureDir(filepath.Dir(dir)); err != nil {
+		return err
+	}
+
+	// export the runtime to a temporary directory first and move it to the target directory after. Otherwise, a failed
+	// export would leave a broken runtime in the cache.
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if _, err := ctr.Directory("/usr/local").Export(ctx.Context, tmp); err != nil {
+		return err
+	}
+
+	if err := fs.WriteFile(filepath.Join(tmp, ".version"), []byte(strings.TrimSpace(version)), 0644); err != nil {
+		return err
+	}
+
+	// the same runtime might be provisioned by another run in the meantime, use it if that's the case
+	if exist, _ := fs.Exists(filepath.Join(dir, ".version")); exist {
+		return nil
+	}
+
+	// remove leftovers of a previously failed provisioning, if any
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, dir); err != nil {
+		if exist, _ := fs.Exists(filepath.Join(dir, ".version")); exist {
+			return nil
+		}
+
+		return err
+	}
+
+	return nil
+}
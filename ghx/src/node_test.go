@@ -0,0 +1,46 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/common/model"
+
+	"ghx/context"
+)
+
+func TestEnsureNodeRuntime_Offline(t *testing.T) {
+	dir := t.TempDir()
+
+	ctx := &context.Context{
+		GhxConfig: context.GhxConfig{
+			ToolsDir:       filepath.Join(dir, "tools"),
+			ActionsDir:     filepath.Join(dir, "actions"),
+			ActionsOffline: true,
+		},
+	}
+
+	if _, err := EnsureNodeRuntime(ctx, model.ActionRunsUsingNode20); err == nil {
+		t.Fatal("Expected error for node runtime missing in offline mode, but got nil")
+	}
+
+	vendored := filepath.Join(dir, "actions", ".node", "20")
+
+	if err := fs.WriteFile(filepath.Join(vendored, ".version"), []byte("v20.10.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := EnsureNodeRuntime(ctx, model.ActionRunsUsingNode20)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if node.Version != "v20.10.0" {
+		t.Errorf("Expected version v20.10.0, but got %s", node.Version)
+	}
+
+	if expected := filepath.Join(vendored, "bin", "node"); node.Path != expected {
+		t.Errorf("Expected path %s, but got %s", expected, node.Path)
+	}
+}
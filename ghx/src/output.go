@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"ghx/context"
+)
+
+// maxOutputLineSize is the maximum size of a single output line of the process. Longer lines are split.
+const maxOutputLineSize = 1 * MB
+
+// outputLine is a single line written to an output stream of the process.
+type outputLine struct {
+	stream string
+	text   string
+}
+
+// String returns the line tagged with its stream, e.g. "stderr some error". It's the format of the lines written by
+// `ghx stream` to keep the order of the streams in a single output.
+func (l outputLine) String() string {
+	return fmt.Sprintf("%s %s", l.stream, l.text)
+}
+
+// parseOutputLine parses a line tagged with its stream. Lines without a known stream tag belong to the given stream,
+// e.g. an error message of the container runtime.
+func parseOutputLine(line, stream string) outputLine {
+	if tag, text, ok := strings.Cut(line, " "); ok && (tag == context.StreamStdout || tag == context.StreamStderr) {
+		return outputLine{stream: tag, text: text}
+	}
+
+	// a tagged empty line has no separator
+	if line == context.StreamStdout || line == context.StreamStderr {
+		return outputLine{stream: line}
+	}
+
+	return outputLine{stream: stream, text: line}
+}
+
+// orderedOutput captures the output streams of a process as lines in a single order. Writes of both streams are
+// handled under the same lock as soon as they're read from the process, so lines are handled in the order they're
+// written unless both streams are written at the same instant.
+type orderedOutput struct {
+	mu      sync.Mutex
+	handle  func(line outputLine)
+	partial map[string][]byte
+}
+
+// newOrderedOutput creates a new ordered output calling the given function for each line of the streams.
+func newOrderedOutput(handle func(line outputLine)) *orderedOutput {
+	return &orderedOutput{handle: handle, partial: make(map[string][]byte)}
+}
+
+// Writer returns the writer of the given stream. Writers must not be *os.File to make exec.Cmd read the streams.
+func (o *orderedOutput) Writer(stream string) io.Writer {
+	return &streamWriter{output: o, stream: stream}
+}
+
+// Flush handles the last lines of the streams without a trailing new line.
+func (o *orderedOutput) Flush() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, stream := range []string{context.StreamStdout, context.StreamStderr} {
+		if buf := o.partial[stream]; len(buf) > 0 {
+			o.handle(outputLine{stream: stream, text: string(buf)})
+		}
+
+		delete(o.partial, stream)
+	}
+}
+
+func (o *orderedOutput) write(stream string, p []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	buf := append(o.partial[stream], p...)
+
+	for {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		o.handle(outputLine{stream: stream, text: strings.TrimSuffix(string(buf[:i]), "\r")})
+
+		buf = buf[i+1:]
+	}
+
+	// tools might print long lines without new line, e.g. progress bars. Split them to not keep them in memory.
+	for len(buf) >= maxOutputLineSize {
+		o.handle(outputLine{stream: stream, text: string(buf[:maxOutputLineSize])})
+
+		buf = buf[maxOutputLineSize:]
+	}
+
+	// copy the rest, buf might share the memory of p
+	o.partial[stream] = append([]byte(nil), buf...)
+}
+
+// streamWriter is the writer of a single stream of the ordered output.
+type streamWriter struct {
+	output *orderedOutput
+	stream string
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	w.output.write(w.stream, p)
+
+	return len(p), nil
+}
+
+// runStream runs the given command and writes both output streams of the command to the given writer in order, each
+// line tagged with its stream. It's used to keep the order of the streams of container steps since containers return
+// the streams separately. The exit code of the command is returned as is, unless --exit-code-file is given. Then the
+// exit code is written to the file and 0 is returned, so the container succeeds and its result can still be used.
+func runStream(args []string, out io.Writer) int {
+	var exitCodeFile string
+
+	if len(args) > 1 && args[0] == "--exit-code-file" {
+		exitCodeFile, args = args[1], args[2:]
+	}
+
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: ghx stream [--exit-code-file <path>] -- <command> [args...]")
+		return 2
+	}
+
+	output := newOrderedOutput(func(line outputLine) {
+		fmt.Fprintln(out, line.String())
+	})
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = output.Writer(context.StreamStdout)
+	cmd.Stderr = output.Writer(context.StreamStderr)
+
+	err := cmd.Run()
+
+	output.Flush()
+
+	var (
+		code    int
+		exitErr *exec.ExitError
+	)
+
+	switch {
+	case err == nil:
+		code = 0
+	case errors.As(err, &exitErr):
+		code = exitErr.ExitCode()
+	default:
+		fmt.Fprintln(out, outputLine{stream: context.StreamStderr, text: err.Error()}.String())
+		code = 127
+	}
+
+	if exitCodeFile == "" {
+		return code
+	}
+
+	if err := os.WriteFile(exitCodeFile, []byte(strconv.Itoa(code)), 0600); err != nil {
+		fmt.Fprintln(out, outputLine{stream: context.StreamStderr, text: err.Error()}.String())
+		return 1
+	}
+
+	return 0
+}
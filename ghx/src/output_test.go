@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ghx/context"
+)
+
+func TestOrderedOutput(t *testing.T) {
+	var lines []outputLine
+
+	output := newOrderedOutput(func(line outputLine) { lines = append(lines, line) })
+
+	stdout := output.Writer(context.StreamStdout)
+	stderr := output.Writer(context.StreamStderr)
+
+	stdout.Write([]byte("first\nsec"))
+	stderr.Write([]byte("error\r\n"))
+	stdout.Write([]byte("ond\n"))
+	stderr.Write([]byte("no new line"))
+
+	output.Flush()
+
+	expected := []outputLine{
+		{stream: context.StreamStdout, text: "first"},
+		{stream: context.StreamStderr, text: "error"},
+		{stream: context.StreamStdout, text: "second"},
+		{stream: context.StreamStderr, text: "no new line"},
+	}
+
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected lines %v, but got %v", expected, lines)
+	}
+}
+
+func TestParseOutputLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		stream   string
+		expected outputLine
+	}{
+		{
+			name:     "stdout line",
+			line:     "stdout hello world",
+			stream:   context.StreamStderr,
+			expected: outputLine{stream: context.StreamStdout, text: "hello world"},
+		},
+		{
+			name:     "stderr line",
+			line:     "stderr ::error::failed",
+			stream:   context.StreamStdout,
+			expected: outputLine{stream: context.StreamStderr, text: "::error::failed"},
+		},
+		{
+			name:     "empty line",
+			line:     "stderr",
+			stream:   context.StreamStdout,
+			expected: outputLine{stream: context.StreamStderr},
+		},
+		{
+			name:     "untagged line",
+			line:     "exec failed",
+			stream:   context.StreamStderr,
+			expected: outputLine{stream: context.StreamStderr, text: "exec failed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseOutputLine(tt.line, tt.stream); got != tt.expected {
+				t.Errorf("Expected %v, but got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRunStream(t *testing.T) {
+	var out bytes.Buffer
+
+	code := runStream([]string{"--", "sh", "-c", "echo out; echo err >&2; exit 3"}, &out)
+	if code != 3 {
+		t.Errorf("Expected exit code 3, but got %d", code)
+	}
+
+	var lines []outputLine
+
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		lines = append(lines, parseOutputLine(line, context.StreamStdout))
+	}
+
+	// order between the streams depends on the scheduling, only the tags are checked
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, but got %v", lines)
+	}
+
+	for _, line := range lines {
+		if (line.stream == context.StreamStdout) != (line.text == "out") {
+			t.Errorf("Expected line %q to be tagged with its stream, but got %s", line.text, line.stream)
+		}
+	}
+}
+
+func TestRunStream_ExitCodeFile(t *testing.T) {
+	var out bytes.Buffer
+
+	file := filepath.Join(t.TempDir(), "exit_code")
+
+	if code := runStream([]string{"--exit-code-file", file, "--", "sh", "-c", "exit 3"}, &out); code != 0 {
+		t.Errorf("Expected exit code 0, but got %d", code)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if string(data) != "3" {
+		t.Errorf("Expected recorded exit code 3, but got %s", data)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/aweris/gale/common/task"
+
+	"ghx/context"
+)
+
+// runShell opens an interactive shell with the exact environment of the failed step of the paused job. After the
+// shell exits, user can continue the job, retry the failed step or abort the job.
+func runShell(ctx *context.Context, runners []*task.Runner[context.Context]) error {
+	pause, err := ctx.ReadPause()
+	if err != nil {
+		return fmt.Errorf("failed to read pause, job %s is not paused: %w", ctx.GhxConfig.Job, err)
+	}
+
+	fmt.Printf("Job %s is paused at %s. Exit the shell to continue, retry or abort the job.\n", ctx.GhxConfig.Job, pause.Name)
+
+	//nolint:gosec // this is an interactive shell, we need to execute it as it is
+	cmd := exec.Command(getShell(pause.Shell.Env))
+	cmd.Env = pause.Shell.Env
+	cmd.Dir = pause.Shell.Workdir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// exit code of the shell is the exit code of the last command executed in the shell, so we're ignoring it.
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return fmt.Errorf("failed to run shell: %w", err)
+		}
+	}
+
+	choice, err := promptChoice(os.Stdin, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	switch choice {
+	case "continue":
+		pause.Task++
+	case "retry":
+		// drop the run of the failed step, it'll be added again when the step is retried
+		if len(pause.StepRuns) > 0 {
+			pause.StepRuns = pause.StepRuns[:len(pause.StepRuns)-1]
+		}
+	default:
+		return fmt.Errorf("job %s aborted at %s", ctx.GhxConfig.Job, pause.Name)
+	}
+
+	ctx.Execution.Resume = pause
+
+	return runJob(ctx, runners, pause.Runner)
+}
+
+// stepShellEnv returns the environment of the runner with the given step environment on top in KEY=VALUE format.
+func stepShellEnv(stepEnv map[string]string) []string {
+	env := os.Environ()
+
+	for k, v := range stepEnv {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	return env
+}
+
+// lookPath searches the given executable in the directories of the given PATH, same as exec.LookPath does with the
+// PATH of the process. Names with a path separator are returned as is.
+func lookPath(name, path string) (string, error) {
+	if strings.Contains(name, string(filepath.Separator)) {
+		return name, nil
+	}
+
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			dir = "."
+		}
+
+		file := filepath.Join(dir, name)
+
+		if info, err := os.Stat(file); err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+			return file, nil
+		}
+	}
+
+	return "", &exec.Error{Name: name, Err: exec.ErrNotFound}
+}
+
+// getShell returns the shell from the given environment variables. If the SHELL variable is not set, it returns bash.
+func getShell(env []string) string {
+	for _, e := range env {
+		if shell, ok := strings.CutPrefix(e, "SHELL="); ok && shell != "" {
+			return shell
+		}
+	}
+
+	return "bash"
+}
+
+// promptChoice asks user to continue, retry or abort the job until a valid choice is given.
+func promptChoice(in io.Reader, out io.Writer) (string, error) {
+	reader := bufio.NewReader(in)
+
+	for {
+		fmt.Fprint(out, "[c]ontinue, [r]etry or [a]bort? ")
+
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", fmt.Errorf("failed to read choice: %w", err)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "c", "continue":
+			return "continue", nil
+		case "r", "retry":
+			return "retry", nil
+		case "a", "abort":
+			return "abort", nil
+		}
+	}
+}
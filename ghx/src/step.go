@@ -2,11 +2,13 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/common/model"
 	"github.com/aweris/gale/common/task"
 
 	"ghx/context"
-	"github.com/aweris/gale/common/model"
 )
 
 // Step is an internal interface that defines contract for steps.
@@ -58,17 +60,44 @@ type PostRunHook interface {
 // TODO: add support for step pre and post run fns
 // TODO: if pre and post run fn is missing use default fns
 
+// StepOpts is the options to create the steps.
+type StepOpts struct {
+	NativeActions bool                  // NativeActions replaces the well-known actions with their native implementations.
+	Overrides     model.ActionOverrides // Overrides is the action override rules. Overridden actions are never replaced.
+}
+
+// NewStepOpts returns the step options configured in the given context.
+func NewStepOpts(ctx *context.Context) StepOpts {
+	return StepOpts{NativeActions: ctx.GhxConfig.NativeActions, Overrides: ctx.Execution.ActionOverrides}
+}
+
 // NewStep creates a new step from the given step configuration.
-func NewStep(s model.Step) (Step, error) {
+func NewStep(s model.Step, opts StepOpts) (Step, error) {
 	var step Step
 
 	switch s.Type() {
 	case model.StepTypeAction:
 		step = &StepAction{Step: s}
+
+		// override rules take precedence over native actions, same as they take precedence over downloading the action
+		if _, overridden := opts.Overrides.Match(s.Uses); opts.NativeActions && !overridden {
+			if native, ok := NewStepNative(s); ok {
+				step = native
+			}
+		}
 	case model.StepTypeRun:
 		step = &StepRun{Step: s}
 	case model.StepTypeDocker:
 		step = &StepDocker{Step: s}
+
+		// docker steps are overridden as well. Redirects to another image keep the step, other rules run as actions.
+		if rule, ok := opts.Overrides.Match(s.Uses); ok {
+			if strings.HasPrefix(rule.Redirect, "docker://") {
+				step = &StepDocker{Step: s, override: rule}
+			} else {
+				step = &StepAction{Step: s}
+			}
+		}
 	default:
 		return nil, fmt.Errorf("unknown step type: %s", s.Type())
 	}
@@ -76,6 +105,26 @@ func NewStep(s model.Step) (Step, error) {
 	return step, nil
 }
 
+// newTaskOptsForStep returns the task options for the given stage of the step. If the step implements pre or post run
+// hooks, the hooks are used. Otherwise, default pre and post run functions are used.
+func newTaskOptsForStep(sr Step, step model.Step, stage model.StepStage, condition task.ConditionalFn[context.Context]) task.Opts[context.Context] {
+	preRunFn := newTaskPreRunFnForStep(stage, step)
+	if pre, ok := sr.(PreRunHook); ok {
+		preRunFn = pre.preRun(stage)
+	}
+
+	postRunFn := newTaskPostRunFnForStep()
+	if post, ok := sr.(PostRunHook); ok {
+		postRunFn = post.postRun()
+	}
+
+	return task.Opts[context.Context]{
+		ConditionalFn: condition,
+		PreRunFn:      preRunFn,
+		PostRunFn:     postRunFn,
+	}
+}
+
 func newTaskPreRunFnForStep(stage model.StepStage, step model.Step) task.PreRunFn[context.Context] {
 	return func(ctx *context.Context) error {
 		return ctx.SetStep(
@@ -98,6 +147,10 @@ func newTaskPostRunFnForStep() task.PostRunFn[context.Context] {
 func executeStep(ctx *context.Context, executor Executor, continueOnError bool) (model.Conclusion, error) {
 	// execute the step
 	if err := executor.Execute(ctx); err != nil {
+		if annErr := ctx.AddStepAnnotation(model.Annotation{Severity: model.AnnotationSeverityError, Message: err.Error()}); annErr != nil {
+			log.Errorf("failed to add annotation", "error", annErr)
+		}
+
 		if continueOnError {
 			ctx.SetStepResults(model.ConclusionSuccess, model.ConclusionFailure)
 
@@ -25,6 +25,7 @@ var (
 // StepAction is a step that runs an action.
 type StepAction struct {
 	container *dagger.Container
+	node      *NodeRuntime // node is the Node.js runtime to run the action. Only available for JavaScript actions.
 	Step      model.Step
 	Action    model.CustomAction
 }
@@ -36,7 +37,9 @@ func (s *StepAction) setup() task.RunFn[context.Context] {
 			return model.ConclusionFailure, err
 		}
 
-		ca, err := LoadActionFromSource(ctx.Context, ctx.Dagger.Client, s.Step.Uses, path)
+		opts := LoadActionOpts{Offline: ctx.GhxConfig.ActionsOffline, Overrides: ctx.Execution.ActionOverrides}
+
+		ca, err := LoadActionFromSource(ctx.Context, ctx.Dagger.Client, s.Step.Uses, path, opts)
 		if err != nil {
 			return model.ConclusionFailure, err
 		}
@@ -44,14 +47,24 @@ func (s *StepAction) setup() task.RunFn[context.Context] {
 		// update the step action with the loaded action
 		s.Action = *ca
 
-		log.Info(fmt.Sprintf("Download action repository '%s'", s.Step.Uses))
+		if ca.Override != "" {
+			log.Info(fmt.Sprintf("Override action '%s' (%s)", s.Step.Uses, ca.Override))
+		} else {
+			log.Info(fmt.Sprintf("Download action repository '%s'", s.Step.Uses))
+		}
+
+		switch s.Action.Meta.Runs.Using {
+		case model.ActionRunsUsingNode12, model.ActionRunsUsingNode16, model.ActionRunsUsingNode20:
+			node, err := EnsureNodeRuntime(ctx, s.Action.Meta.Runs.Using)
+			if err != nil {
+				return model.ConclusionFailure, err
+			}
+
+			s.node = node
 
-		if s.Action.Meta.Runs.Using == model.ActionRunsUsingDocker {
-			var (
-				image        = ca.Meta.Runs.Image
-				workspace    = ctx.Github.Workspace
-				workspaceDir = ctx.Dagger.Client.Host().Directory(workspace)
-			)
+			log.Info(fmt.Sprintf("Using Node.js %s for '%s'", node.Version, s.Step.Uses))
+		case model.ActionRunsUsingDocker:
+			image := ca.Meta.Runs.Image
 
 			switch {
 			case image == "Dockerfile":
@@ -62,9 +75,6 @@ func (s *StepAction) setup() task.RunFn[context.Context] {
 				// This should never happen. Adding it for safety.
 				return model.ConclusionFailure, fmt.Errorf("invalid docker image: %s", image)
 			}
-
-			// add repository to the container
-			s.container = s.container.WithMountedDirectory(workspace, workspaceDir).WithWorkdir(workspace)
 		}
 
 		return model.ConclusionSuccess, nil
@@ -75,12 +85,20 @@ func (s *StepAction) preRun(stage model.StepStage) task.PreRunFn[context.Context
 	return func(ctx *context.Context) error {
 		ctx.SetAction(&s.Action)
 
+		var nodeVersion string
+
+		if s.node != nil {
+			nodeVersion = s.node.Version
+		}
+
 		return ctx.SetStep(
 			&model.StepRun{
-				Step:    s.Step,
-				Stage:   stage,
-				Outputs: make(map[string]string),
-				State:   make(map[string]string),
+				Step:        s.Step,
+				Stage:       stage,
+				Outputs:     make(map[string]string),
+				State:       make(map[string]string),
+				NodeVersion: nodeVersion,
+				Override:    s.Action.Override,
 			},
 		)
 	}
@@ -137,6 +155,8 @@ func (s *StepAction) main() task.RunFn[context.Context] {
 			executor = NewContainerExecutorFromStepAction(s, s.Action.Meta.Runs.Entrypoint)
 		case model.ActionRunsUsingNode12, model.ActionRunsUsingNode16, model.ActionRunsUsingNode20:
 			executor = NewCmdExecutorFromStepAction(s, s.Action.Meta.Runs.Main)
+		case model.ActionRunsUsingComposite:
+			executor = NewCompositeExecutorFromStepAction(s)
 		default:
 			return model.ConclusionFailure, fmt.Errorf("invalid action runs using: %s", s.Action.Meta.Runs.Using)
 		}
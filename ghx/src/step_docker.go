@@ -14,29 +14,29 @@ import (
 )
 
 var (
-	_ Step      = new(StepDocker)
-	_ SetupHook = new(StepDocker)
+	_ Step       = new(StepDocker)
+	_ SetupHook  = new(StepDocker)
+	_ PreRunHook = new(StepDocker)
 )
 
 type StepDocker struct {
 	container *dagger.Container
+	override  *model.ActionOverride // override is the rule redirecting the step to another image, if any.
 	Step      model.Step
 }
 
 func (s *StepDocker) setup() task.RunFn[context.Context] {
 	return func(ctx *context.Context) (model.Conclusion, error) {
-		var (
-			image        = strings.TrimPrefix(s.Step.Uses, "docker://")
-			workspace    = ctx.Github.Workspace
-			workspaceDir = ctx.Dagger.Client.Host().Directory(workspace)
-		)
-
-		// configure the step container
-		s.container = ctx.Dagger.Client.
-			Container().
-			From(image).
-			WithMountedDirectory(workspace, workspaceDir).
-			WithWorkdir(workspace)
+		image := strings.TrimPrefix(s.Step.Uses, "docker://")
+
+		if s.override != nil {
+			image = strings.TrimPrefix(s.override.Redirect, "docker://")
+
+			log.Info(fmt.Sprintf("Override image '%s' (%s)", s.Step.Uses, s.override))
+		}
+
+		// configure the step container, directories are mounted by the executor at execution time
+		s.container = ctx.Dagger.Client.Container().From(image)
 
 		// TODO: This will be print same log line if the image used multiple times. However, this scenario is not really common and no benefit to fix this scenario for now.
 		log.Info(fmt.Sprintf("Pull '%s'", image))
@@ -45,6 +45,23 @@ func (s *StepDocker) setup() task.RunFn[context.Context] {
 	}
 }
 
+func (s *StepDocker) preRun(stage model.StepStage) task.PreRunFn[context.Context] {
+	return func(ctx *context.Context) error {
+		sr := &model.StepRun{
+			Step:    s.Step,
+			Stage:   stage,
+			Outputs: make(map[string]string),
+			State:   make(map[string]string),
+		}
+
+		if s.override != nil {
+			sr.Override = s.override.String()
+		}
+
+		return ctx.SetStep(sr)
+	}
+}
+
 func (s *StepDocker) condition() task.ConditionalFn[context.Context] {
 	return func(ctx *context.Context) (bool, model.Conclusion, error) {
 		return evalCondition(s.Step.If, ctx)
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/common/model"
+	"github.com/aweris/gale/common/task"
+
+	"ghx/context"
+)
+
+var (
+	_ Step        = new(StepNative)
+	_ PreRunHook  = new(StepNative)
+	_ PostHook    = new(StepNative)
+	_ PostRunHook = new(StepNative)
+	_ SetupHook   = new(StepNative)
+)
+
+// NativeAction is a well-known action implemented natively in ghx. Native actions skip downloading the action and
+// starting a Node.js process, while keeping the inputs and outputs of the original action.
+type NativeAction interface {
+	// meta returns the metadata of the original action. Inputs and defaults are used to resolve the step inputs.
+	meta() model.CustomActionMeta
+
+	// main executes the main stage of the action with the given inputs.
+	main(ctx *context.Context, inputs context.InputsContext) error
+}
+
+// NativePostAction is a native action that performs a post execution task, same as the original action.
+type NativePostAction interface {
+	NativeAction
+
+	// post executes the post stage of the action with the given inputs.
+	post(ctx *context.Context, inputs context.InputsContext) error
+}
+
+// nativeActions is the list of native actions by the repository of the original action. All versions of the action are
+// replaced with the same implementation.
+var nativeActions = map[string]func() NativeAction{
+	"actions/checkout":          func() NativeAction { return new(nativeCheckout) },
+	"actions/cache":             func() NativeAction { return new(nativeCache) },
+	"actions/upload-artifact":   func() NativeAction { return new(nativeUploadArtifact) },
+	"actions/download-artifact": func() NativeAction { return new(nativeDownloadArtifact) },
+}
+
+// StepNative is a step that runs a native implementation of the action instead of the action itself.
+type StepNative struct {
+	native NativeAction
+	Step   model.Step
+	Action model.CustomAction
+}
+
+// NewStepNative returns a native step for the given step if the action used by the step has a native implementation.
+func NewStepNative(step model.Step) (*StepNative, bool) {
+	name, _, found := strings.Cut(step.Uses, "@")
+	if !found {
+		return nil, false
+	}
+
+	fn, ok := nativeActions[strings.ToLower(name)]
+	if !ok {
+		return nil, false
+	}
+
+	native := fn()
+
+	return &StepNative{native: native, Step: step, Action: model.CustomAction{Meta: native.meta()}}, true
+}
+
+func (s *StepNative) setup() task.RunFn[context.Context] {
+	return func(_ *context.Context) (model.Conclusion, error) {
+		log.Info(fmt.Sprintf("Use native implementation of '%s'", s.Step.Uses))
+
+		return model.ConclusionSuccess, nil
+	}
+}
+
+func (s *StepNative) preRun(stage model.StepStage) task.PreRunFn[context.Context] {
+	return func(ctx *context.Context) error {
+		ctx.SetAction(&s.Action)
+
+		return ctx.SetStep(
+			&model.StepRun{
+				Step:     s.Step,
+				Stage:    stage,
+				Outputs:  make(map[string]string),
+				State:    make(map[string]string),
+				Override: "native",
+			},
+		)
+	}
+}
+
+func (s *StepNative) postRun() task.PostRunFn[context.Context] {
+	return func(ctx *context.Context, result task.Result) {
+		ctx.UnsetStep(model.RunResult(result))
+		ctx.UnsetAction()
+	}
+}
+
+func (s *StepNative) condition() task.ConditionalFn[context.Context] {
+	return func(ctx *context.Context) (bool, model.Conclusion, error) {
+		return evalCondition(s.Step.If, ctx)
+	}
+}
+
+func (s *StepNative) main() task.RunFn[context.Context] {
+	return func(ctx *context.Context) (model.Conclusion, error) {
+		return executeStep(ctx, &NativeExecutor{fn: s.native.main}, s.Step.ContinueOnError)
+	}
+}
+
+func (s *StepNative) postCondition() task.ConditionalFn[context.Context] {
+	return func(ctx *context.Context) (bool, model.Conclusion, error) {
+		if _, ok := s.native.(NativePostAction); !ok {
+			return false, "", nil
+		}
+
+		return evalCondition(s.Action.Meta.Runs.PostIf, ctx)
+	}
+}
+
+func (s *StepNative) post() task.RunFn[context.Context] {
+	return func(ctx *context.Context) (model.Conclusion, error) {
+		native, ok := s.native.(NativePostAction)
+		if !ok {
+			// This should never happen since the post condition is not met. Adding it for safety.
+			return model.ConclusionFailure, fmt.Errorf("native action %s has no post stage", s.Step.Uses)
+		}
+
+		return executeStep(ctx, &NativeExecutor{fn: native.post}, s.Step.ContinueOnError)
+	}
+}
+
+var _ Executor = new(NativeExecutor)
+
+// NativeExecutor executes a stage of a native action with the inputs of the current step.
+type NativeExecutor struct {
+	fn func(ctx *context.Context, inputs context.InputsContext) error
+}
+
+func (n *NativeExecutor) Execute(ctx *context.Context) error {
+	inputs, err := ctx.GetActionInputs()
+	if err != nil {
+		return err
+	}
+
+	return n.fn(ctx, inputs)
+}
+
+// newServiceRequest creates a request to the internal services of the runner, e.g. artifact and cache services. The
+// request is authenticated with the runtime token, same as the actions toolkit does.
+func newServiceRequest(ctx *context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx.Context, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", ctx.Actions.Token))
+
+	return req, nil
+}
+
+// newServiceJSONRequest creates a request to the internal services of the runner with the given value as JSON body.
+func newServiceJSONRequest(ctx *context.Context, method, url string, val interface{}) (*http.Request, error) {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := newServiceRequest(ctx, method, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	return req, nil
+}
+
+// doServiceRequest sends the request and decodes the JSON response into out if out is not nil. Responses with a non
+// 2xx status code are returned as error.
+func doServiceRequest(req *http.Request, out interface{}) (int, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(resp.Body)
+
+		return resp.StatusCode, fmt.Errorf("%s %s failed with status %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return resp.StatusCode, nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to decode response of %s %s: %w", req.Method, req.URL.Path, err)
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aweris/gale/common/model"
+)
+
+func TestNewStep_NativeActions(t *testing.T) {
+	overrides := model.ActionOverrides{Overrides: []model.ActionOverride{{Uses: "actions/cache@*", Noop: true}}}
+
+	tests := []struct {
+		name       string
+		uses       string
+		opts       StepOpts
+		wantNative bool
+	}{
+		{name: "checkout", uses: "actions/checkout@v4", opts: StepOpts{NativeActions: true}, wantNative: true},
+		{name: "upload artifact", uses: "actions/upload-artifact@v3", opts: StepOpts{NativeActions: true}, wantNative: true},
+		{name: "case insensitive", uses: "Actions/Download-Artifact@v3", opts: StepOpts{NativeActions: true}, wantNative: true},
+		{name: "disabled", uses: "actions/checkout@v4", opts: StepOpts{}, wantNative: false},
+		{name: "unknown action", uses: "actions/setup-go@v4", opts: StepOpts{NativeActions: true}, wantNative: false},
+		{name: "sub path", uses: "actions/cache/restore@v3", opts: StepOpts{NativeActions: true}, wantNative: false},
+		{name: "overridden", uses: "actions/cache@v3", opts: StepOpts{NativeActions: true, Overrides: overrides}, wantNative: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, err := NewStep(model.Step{ID: "test", Uses: tt.uses}, tt.opts)
+			if err != nil {
+				t.Fatalf("NewStep() error = %v", err)
+			}
+
+			if _, ok := step.(*StepNative); ok != tt.wantNative {
+				t.Errorf("NewStep() native = %v, want %v", ok, tt.wantNative)
+			}
+		})
+	}
+}
+
+func TestCommonDir(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  string
+	}{
+		{name: "single", paths: []string{"/a/b"}, want: "/a/b"},
+		{name: "same", paths: []string{"/a/b", "/a/b"}, want: "/a/b"},
+		{name: "nested", paths: []string{"/a/b", "/a/b/c"}, want: "/a/b"},
+		{name: "siblings", paths: []string{"/a/b/c", "/a/b/d"}, want: "/a/b"},
+		{name: "common prefix is not a directory", paths: []string{"/a/bc", "/a/bd"}, want: "/a"},
+		{name: "root", paths: []string{"/a", "/b"}, want: "/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commonDir(tt.paths); got != tt.want {
+				t.Errorf("commonDir() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,14 +1,17 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/aweris/gale/common/fs"
 	"github.com/aweris/gale/common/task"
 
 	"ghx/context"
-	"ghx/expression"
 	"github.com/aweris/gale/common/model"
 )
 
@@ -21,7 +24,7 @@ type StepRun struct {
 	Shell     string   // Shell is the shell to use to run the script.
 	ShellArgs []string // ShellArgs are the arguments to pass to the shell.
 	Path      string   // Path is the script path to run.
-
+	Dir       string   // Dir is the working directory to run the script in.
 }
 
 func (s *StepRun) condition() task.ConditionalFn[context.Context] {
@@ -30,74 +33,78 @@ func (s *StepRun) condition() task.ConditionalFn[context.Context] {
 	}
 }
 
-const (
-	extSH   = ".sh"
-	extPY   = ".py"
-	extPWSH = ".ps1"
-)
+// shellArgs is the default arguments of the built-in shells. {0} is replaced with the path of the script.
+//
+// Docs: https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions#jobsjob_idstepsshell
+// Ref: https://github.com/actions/runner/blob/efffbaeabc6d53c4c1ec05b11cea58331ff38e3c/src/Runner.Worker/Handlers/ScriptHandlerHelpers.cs
+var shellArgs = map[string]string{
+	"bash":       "--noprofile --norc -e -o pipefail {0}",
+	"sh":         "-e {0}",
+	"python":     "{0}",
+	"pwsh":       `-command ". '{0}'"`,
+	"powershell": `-command ". '{0}'"`,
+}
+
+// shellExtensions is the script file extensions of the known shells. Scripts of the other shells have no extension.
+var shellExtensions = map[string]string{
+	"bash":       ".sh",
+	"sh":         ".sh",
+	"python":     ".py",
+	"pwsh":       ".ps1",
+	"powershell": ".ps1",
+}
 
 func (s *StepRun) main() task.RunFn[context.Context] {
 	return func(ctx *context.Context) (model.Conclusion, error) {
-		var (
-			pre   string
-			pos   string
-			args  []string
-			shell = s.Step.Shell
-		)
-
-		dir, err := ctx.GetStepRunPath()
+		command, err := parseShell(s.Step.Shell)
 		if err != nil {
 			return model.ConclusionFailure, err
 		}
 
-		path := filepath.Join(dir, "run")
-
-		// TODO: add support and test for "pwsh" shell. This is not supported for now because we don't have a pwsh image,
-		//  and we don't have a way to test it for now. We'll add support for it later after making sure that it works.
-
-		// set the shell and shell args according to the shell type. Windows is not supported platform for now. So, we
-		// don't need to handle shell type for windows.
-		//
-		// Docs: https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions#jobsjob_idstepsshell
-		// Ref: https://github.com/actions/runner/blob/efffbaeabc6d53c4c1ec05b11cea58331ff38e3c/src/Runner.Worker/Handlers/ScriptHandlerHelpers.cs
-		// Ref: https://github.com/actions/runner/blob/efffbaeabc6d53c4c1ec05b11cea58331ff38e3c/src/Runner.Worker/Handlers/ScriptHandler.cs
-		switch shell {
-		case "":
-			path += extSH
-			shell = "bash"
-			args = []string{"-e", path}
-		case "bash":
-			path += extSH
-			args = []string{"--noprofile", "--norc", "-e", "-o", "pipefail", path}
-		case "python":
-			path += extPY
-			args = []string{path}
-		case "pwsh":
-			path += extPWSH
-			pre = "$ErrorActionPreference = 'stop'"
-			pos = "if ((Test-Path -LiteralPath variable:/LASTEXITCODE)) { exit $LASTEXITCODE }"
-			args = []string{"-command", fmt.Sprintf(". '%s'", path)}
-		case "sh":
-			path += extSH
-			args = []string{"-e", path}
-		default:
-			return model.ConclusionFailure, fmt.Errorf("not supported shell: %s", shell)
+		vp, err := ctx.GetVariableProvider()
+		if err != nil {
+			return model.ConclusionFailure, err
 		}
 
 		// evaluate run script against the expressions
-		run := expression.NewString(s.Step.Run).Eval(ctx)
+		run, err := ctx.EvalString(vp, s.Step.Run)
+		if err != nil {
+			return model.ConclusionFailure, err
+		}
 
-		content := []byte(fmt.Sprintf("%s\n%s\n%s", pre, run, pos))
+		// shell is the executable name without the path and extension, e.g. /usr/bin/bash -> bash
+		shell := strings.TrimSuffix(filepath.Base(command[0]), filepath.Ext(command[0]))
 
-		err = fs.WriteFile(path, content, 0755)
+		// powershell doesn't stop on errors and doesn't return the exit code of the last command by default
+		if shell == "pwsh" || shell == "powershell" {
+			run = fmt.Sprintf("$ErrorActionPreference = 'stop'\n%s\nif ((Test-Path -LiteralPath variable:/LASTEXITCODE)) { exit $LASTEXITCODE }", run)
+		}
+
+		path, err := writeScript(ctx.Runner.Temp, shellExtensions[shell], run)
 		if err != nil {
 			return model.ConclusionFailure, err
 		}
 
-		s.Shell = shell
-		s.ShellArgs = args
+		// path is replaced after splitting the command, so the paths with spaces remain a single argument
+		for idx, arg := range command {
+			command[idx] = strings.ReplaceAll(arg, "{0}", path)
+		}
+
+		s.Shell = command[0]
+		s.ShellArgs = command[1:]
 		s.Path = path
 
+		// relative working directories are relative to the workspace
+		if wd := s.Step.WorkingDirectory; wd != "" {
+			if s.Dir, err = ctx.EvalString(vp, wd); err != nil {
+				return model.ConclusionFailure, err
+			}
+
+			if !filepath.IsAbs(s.Dir) {
+				s.Dir = filepath.Join(ctx.Github.Workspace, s.Dir)
+			}
+		}
+
 		executor := NewCmdExecutorFromStepRun(s)
 
 		// execute the step
@@ -122,3 +129,62 @@ func (s *StepRun) main() task.RunFn[context.Context] {
 		return model.ConclusionSuccess, nil
 	}
 }
+
+// parseShell parses the shell option of the step and returns the command to run the script. The script path placeholder
+// {0} is not replaced in the returned command. Built-in shells are used with their default arguments, any other shell
+// must be a command template containing {0}, e.g. `bash -l {0}` or `perl {0}`. If the shell is not set, bash is used if
+// it's available, otherwise sh.
+func parseShell(shell string) ([]string, error) {
+	shell = strings.TrimSpace(shell)
+
+	switch {
+	case shell == "":
+		shell = "sh -e {0}"
+
+		if _, err := exec.LookPath("bash"); err == nil {
+			shell = "bash -e {0}"
+		}
+	case shell == "cmd":
+		return nil, errors.New("invalid shell option 'cmd', cmd shell is only supported on Windows runners")
+	case shellArgs[shell] != "":
+		shell = shell + " " + shellArgs[shell]
+	case !strings.Contains(shell, "{0}"):
+		return nil, fmt.Errorf("invalid shell option '%s', shell must be a valid built-in (bash, sh, cmd, powershell, pwsh, python) or a format string containing '{0}'", shell)
+	}
+
+	command, err := splitArgs(shell)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shell option '%s': %w", shell, err)
+	}
+
+	return command, nil
+}
+
+// writeScript writes the given script to a new file with the given extension in the given directory, and returns the
+// path of the file. Same as the runner, scripts are written to RUNNER_TEMP with a unique name.
+func writeScript(dir, ext, script string) (string, error) {
+	if err := fs.EnsureDir(dir); err != nil {
+		return "", err
+	}
+
+	file, err := os.CreateTemp(dir, "*"+ext)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := file.WriteString(script); err != nil {
+		file.Close()
+		return "", err
+	}
+
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	//nolint:gosec // script must be executable
+	if err := os.Chmod(file.Name(), 0755); err != nil {
+		return "", err
+	}
+
+	return file.Name(), nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseShell(t *testing.T) {
+	tests := []struct {
+		name    string
+		shell   string
+		want    []string
+		wantErr string
+	}{
+		{name: "bash", shell: "bash", want: []string{"bash", "--noprofile", "--norc", "-e", "-o", "pipefail", "{0}"}},
+		{name: "sh", shell: "sh", want: []string{"sh", "-e", "{0}"}},
+		{name: "python", shell: "python", want: []string{"python", "{0}"}},
+		{name: "pwsh", shell: "pwsh", want: []string{"pwsh", "-command", ". '{0}'"}},
+		{name: "custom bash", shell: "bash -l {0}", want: []string{"bash", "-l", "{0}"}},
+		{name: "custom perl", shell: " perl {0} ", want: []string{"perl", "{0}"}},
+		{name: "quoted executable", shell: `"/opt/my shell/run" --file={0}`, want: []string{"/opt/my shell/run", "--file={0}"}},
+		{name: "cmd", shell: "cmd", wantErr: "only supported on Windows runners"},
+		{name: "unknown shell", shell: "perl", wantErr: "format string containing '{0}'"},
+		{name: "invalid template", shell: `perl "{0}`, wantErr: "invalid shell option"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseShell(tt.shell)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseShell() error = %v, want %s", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseShell() unexpected error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseShell() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteScript(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "runner_temp")
+
+	first, err := writeScript(dir, ".sh", "echo hello")
+	if err != nil {
+		t.Fatalf("writeScript() unexpected error = %v", err)
+	}
+
+	second, err := writeScript(dir, ".sh", "echo hello")
+	if err != nil {
+		t.Fatalf("writeScript() unexpected error = %v", err)
+	}
+
+	if first == second || filepath.Dir(first) != dir || filepath.Ext(first) != ".sh" {
+		t.Errorf("writeScript() = %s and %s, want unique .sh files in %s", first, second, dir)
+	}
+
+	stat, err := os.Stat(first)
+	if err != nil {
+		t.Fatalf("failed to stat script: %v", err)
+	}
+
+	if stat.Mode().Perm()&0100 == 0 {
+		t.Errorf("writeScript() file mode = %s, want executable", stat.Mode())
+	}
+
+	content, err := os.ReadFile(first)
+	if err != nil || string(content) != "echo hello" {
+		t.Errorf("writeScript() content = %q, error = %v", content, err)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aweris/gale/common/model"
+)
+
+func TestNewStep_DockerOverrides(t *testing.T) {
+	overrides := model.ActionOverrides{
+		Overrides: []model.ActionOverride{
+			{Uses: "docker://my-org/deploy:*", Noop: true},
+			{Uses: "docker://alpine:*", Redirect: "docker://alpine:3.19"},
+		},
+	}
+
+	opts := StepOpts{Overrides: overrides}
+
+	t.Run("noop", func(t *testing.T) {
+		step, err := NewStep(model.Step{ID: "test", Uses: "docker://my-org/deploy:v1"}, opts)
+		if err != nil {
+			t.Fatalf("NewStep() error = %v", err)
+		}
+
+		if _, ok := step.(*StepAction); !ok {
+			t.Errorf("Expected overridden docker step to run as action, but got %T", step)
+		}
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		step, err := NewStep(model.Step{ID: "test", Uses: "docker://alpine:3.18"}, opts)
+		if err != nil {
+			t.Fatalf("NewStep() error = %v", err)
+		}
+
+		docker, ok := step.(*StepDocker)
+		if !ok {
+			t.Fatalf("Expected redirected docker step to stay docker step, but got %T", step)
+		}
+
+		if docker.override != &overrides.Overrides[1] {
+			t.Errorf("Expected override %v, but got %v", overrides.Overrides[1], docker.override)
+		}
+	})
+
+	t.Run("not matching", func(t *testing.T) {
+		step, err := NewStep(model.Step{ID: "test", Uses: "docker://busybox:latest"}, opts)
+		if err != nil {
+			t.Fatalf("NewStep() error = %v", err)
+		}
+
+		if docker, ok := step.(*StepDocker); !ok || docker.override != nil {
+			t.Errorf("Expected docker step without override, but got %T", step)
+		}
+	})
+}
@@ -1,19 +1,33 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
+	"github.com/aweris/gale/common/fs"
 	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/common/model"
 
 	"ghx/context"
+	"ghx/matcher"
 )
 
 var (
 	commandReColon = regexp.MustCompile(`^::([\w-]+)(?:\s+((?:[\w-]+=[^,]+,)*[\w-]+=[^,]+))??::(.*?)$`)
 	commandReHash  = regexp.MustCompile(`##\[(\S+)([^]]*)](.*)?$`) //
+
+	// Special characters in the values and properties of the commands are escaped by the toolkit. Escaped percent sign
+	// is replaced last, so escaped sequences like %250A are decoded as %0A.
+	//
+	// See: https://github.com/actions/toolkit/blob/main/packages/core/src/command.ts
+	dataUnescaper     = strings.NewReplacer("%0D", "\r", "%0A", "\n", "%25", "%")
+	propertyUnescaper = strings.NewReplacer("%0D", "\r", "%0A", "\n", "%3A", ":", "%2C", ",", "%25", "%")
+	legacyUnescaper   = strings.NewReplacer("%3B", ";", "%0D", "\r", "%0A", "\n", "%5D", "]", "%25", "%")
 )
 
 // WorkflowCommand represents a Workflow command to communicate with Runner.
@@ -27,22 +41,59 @@ type WorkflowCommand struct {
 type CommandName string
 
 const (
-	CommandNameGroup      CommandName = "group"
-	CommandNameEndGroup   CommandName = "endgroup"
-	CommandNameDebug      CommandName = "debug"
-	CommandNameError      CommandName = "error"
-	CommandNameWarning    CommandName = "warning"
-	CommandNameNotice     CommandName = "notice"
-	CommandNameSetEnv     CommandName = "set-env"
-	CommandNameSetOutput  CommandName = "set-output"
-	CommandNameSaveState  CommandName = "save-state"
-	CommandNameAddMask    CommandName = "add-mask"
-	CommandNameAddMatcher CommandName = "add-matcher"
-	CommandNameAddPath    CommandName = "add-path"
+	CommandNameGroup         CommandName = "group"
+	CommandNameEndGroup      CommandName = "endgroup"
+	CommandNameDebug         CommandName = "debug"
+	CommandNameError         CommandName = "error"
+	CommandNameWarning       CommandName = "warning"
+	CommandNameNotice        CommandName = "notice"
+	CommandNameSetEnv        CommandName = "set-env"
+	CommandNameSetOutput     CommandName = "set-output"
+	CommandNameSaveState     CommandName = "save-state"
+	CommandNameAddMask       CommandName = "add-mask"
+	CommandNameAddMatcher    CommandName = "add-matcher"
+	CommandNameRemoveMatcher CommandName = "remove-matcher"
+	CommandNameAddPath       CommandName = "add-path"
+	CommandNameStopCommands  CommandName = "stop-commands"
+	CommandNameEcho          CommandName = "echo"
+)
+
+// commandNames is the set of the commands known by the runner. Lines looking like a command with any other name are
+// processed as regular output.
+var commandNames = map[CommandName]bool{
+	CommandNameGroup:         true,
+	CommandNameEndGroup:      true,
+	CommandNameDebug:         true,
+	CommandNameError:         true,
+	CommandNameWarning:       true,
+	CommandNameNotice:        true,
+	CommandNameSetEnv:        true,
+	CommandNameSetOutput:     true,
+	CommandNameSaveState:     true,
+	CommandNameAddMask:       true,
+	CommandNameAddMatcher:    true,
+	CommandNameRemoveMatcher: true,
+	CommandNameAddPath:       true,
+	CommandNameStopCommands:  true,
+	CommandNameEcho:          true,
+}
+
+// envAllowUnsecureCommands is the environment variable to opt into the disabled set-env, add-path and set-output
+// commands.
+const envAllowUnsecureCommands = "ACTIONS_ALLOW_UNSECURE_COMMANDS"
+
+// Changelogs explaining the deprecation of the commands.
+const (
+	changelogSetEnvAddPath      = "https://github.blog/changelog/2020-10-01-github-actions-deprecating-set-env-and-add-path-commands/"
+	changelogSetOutputSaveState = "https://github.blog/changelog/2022-10-11-github-actions-deprecating-save-state-and-set-output-commands/"
 )
 
 type CommandProcessor struct {
-	exclude map[CommandName]bool // exclude is a map of command names to exclude from processing
+	exclude   map[CommandName]bool // exclude is a map of command names to exclude from processing
+	err       error                // err is the first error failing the step, e.g. use of a disabled command
+	stopToken string               // stopToken is the token to resume the command processing, if commands are stopped
+	echo      *bool                // echo is the echo mode set by the step. If not set, commands are echoed in debug mode
+	groups    int                  // groups is the number of the groups opened by the step and not closed yet
 }
 
 func NewCommandProcessor(excluded ...CommandName) *CommandProcessor {
@@ -56,59 +107,123 @@ func NewCommandProcessor(excluded ...CommandName) *CommandProcessor {
 }
 
 func (p *CommandProcessor) ProcessOutput(ctx *context.Context, output string) error {
-	isCmd, cmd := parseCommand(output)
-	if !isCmd {
-		log.Info(output)
+	// commands are not processed until the step prints the token given with stop-commands
+	if p.stopToken != "" {
+		if output == fmt.Sprintf("::%s::", p.stopToken) {
+			p.stopToken = ""
+			return nil
+		}
 
-		return nil
+		return p.processLine(ctx, output)
+	}
+
+	isCmd, cmd := parseCommand(output)
+	if !isCmd || !commandNames[CommandName(cmd.Name)] {
+		return p.processLine(ctx, output)
 	}
 
 	if p.exclude[CommandName(cmd.Name)] {
 		return nil
 	}
 
+	// messages of the commands are logged, so the masked values are hidden in them like in the regular output
+	switch CommandName(cmd.Name) {
+	case CommandNameGroup, CommandNameDebug, CommandNameError, CommandNameWarning, CommandNameNotice:
+		cmd.Value = ctx.MaskValues(cmd.Value)
+
+		if title, ok := cmd.Parameters["title"]; ok {
+			cmd.Parameters["title"] = ctx.MaskValues(title)
+		}
+	}
+
+	// commands are echoed as they are, except add-mask to not reveal the masked value
+	if CommandName(cmd.Name) != CommandNameAddMask && p.echoEnabled(ctx) {
+		if err := p.processLine(ctx, output); err != nil {
+			return err
+		}
+	}
+
 	switch CommandName(cmd.Name) {
 	case CommandNameGroup:
 		log.Info(cmd.Value)
 		log.StartGroup()
+
+		p.groups++
 	case CommandNameEndGroup:
+		// ignore the end of the groups not opened by the step, otherwise it would close the group of the step itself
+		if p.groups == 0 {
+			return nil
+		}
+
 		log.EndGroup()
+
+		p.groups--
+	case CommandNameStopCommands:
+		if cmd.Value == "" || commandNames[CommandName(cmd.Value)] {
+			return fmt.Errorf("invalid stop-commands token '%s', token must not be empty or a command name", cmd.Value)
+		}
+
+		p.stopToken = cmd.Value
+	case CommandNameEcho:
+		value := strings.ToLower(cmd.Value)
+		if value != "on" && value != "off" {
+			return fmt.Errorf("invalid echo value '%s', allowed values are on and off", cmd.Value)
+		}
+
+		echo := value == "on"
+
+		p.echo = &echo
 	case CommandNameDebug:
 		log.Debug(cmd.Value)
 	case CommandNameError:
-		log.Errorf(cmd.Value, "file", cmd.Parameters["file"], "line", cmd.Parameters["line"], "col", cmd.Parameters["col"], "endLine", cmd.Parameters["endLine"], "endCol", cmd.Parameters["endCol"], "title", cmd.Parameters["title"])
+		log.Errorf(cmd.Value, "file", cmd.Parameters["file"], "line", cmd.Parameters["line"], "col", cmd.Parameters["col"], "endLine", cmd.Parameters["endLine"], "endColumn", getEndColumn(cmd), "title", cmd.Parameters["title"])
+		if err := ctx.AddStepAnnotation(newAnnotation(model.AnnotationSeverityError, cmd)); err != nil {
+			return err
+		}
 	case CommandNameWarning:
-		log.Warnf(cmd.Value, "file", cmd.Parameters["file"], "line", cmd.Parameters["line"], "col", cmd.Parameters["col"], "endLine", cmd.Parameters["endLine"], "endCol", cmd.Parameters["endCol"], "title", cmd.Parameters["title"])
+		log.Warnf(cmd.Value, "file", cmd.Parameters["file"], "line", cmd.Parameters["line"], "col", cmd.Parameters["col"], "endLine", cmd.Parameters["endLine"], "endColumn", getEndColumn(cmd), "title", cmd.Parameters["title"])
+		if err := ctx.AddStepAnnotation(newAnnotation(model.AnnotationSeverityWarning, cmd)); err != nil {
+			return err
+		}
 	case CommandNameNotice:
-		log.Noticef(cmd.Value, "file", cmd.Parameters["file"], "line", cmd.Parameters["line"], "col", cmd.Parameters["col"], "endLine", cmd.Parameters["endLine"], "endCol", cmd.Parameters["endCol"], "title", cmd.Parameters["title"])
-	case CommandNameSetEnv:
-		if err := os.Setenv(cmd.Parameters["name"], cmd.Value); err != nil {
+		log.Noticef(cmd.Value, "file", cmd.Parameters["file"], "line", cmd.Parameters["line"], "col", cmd.Parameters["col"], "endLine", cmd.Parameters["endLine"], "endColumn", getEndColumn(cmd), "title", cmd.Parameters["title"])
+		if err := ctx.AddStepAnnotation(newAnnotation(model.AnnotationSeverityNotice, cmd)); err != nil {
 			return err
 		}
-		// FIXME: for now it's just reporting but we should use this as source of truth for step extra env
+	case CommandNameSetEnv:
+		if !p.allowUnsecureCommand(ctx, cmd, changelogSetEnvAddPath) {
+			return nil
+		}
 		if err := ctx.SetStepEnv(cmd.Parameters["name"], cmd.Value); err != nil {
 			return err
 		}
 	case CommandNameSetOutput:
+		if !p.allowUnsecureCommand(ctx, cmd, changelogSetOutputSaveState) {
+			return nil
+		}
 		if err := ctx.SetStepOutput(cmd.Parameters["name"], cmd.Value); err != nil {
 			return err
 		}
 	case CommandNameSaveState:
+		warnDeprecatedCommand(ctx, cmd, changelogSetOutputSaveState)
 		if err := ctx.SetStepState(cmd.Parameters["name"], cmd.Value); err != nil {
 			return err
 		}
 	case CommandNameAddMask:
-		log.Info(cmd.Value)
+		ctx.AddMask(cmd.Value)
 	case CommandNameAddMatcher:
-		log.Info(cmd.Value)
-	case CommandNameAddPath:
-		// FIXME: for now it's just reporting but we should use this as source of truth for step path
-		if err := ctx.AddStepPath(cmd.Value); err != nil {
+		if err := addMatchers(ctx, cmd.Value); err != nil {
 			return err
 		}
-		path := os.Getenv("PATH")
-		path = fmt.Sprintf("%s:%s", path, cmd.Value)
-		if err := os.Setenv("PATH", path); err != nil {
+	case CommandNameRemoveMatcher:
+		if ctx.Execution.Matchers != nil {
+			ctx.Execution.Matchers.Remove(cmd.Parameters["owner"])
+		}
+	case CommandNameAddPath:
+		if !p.allowUnsecureCommand(ctx, cmd, changelogSetEnvAddPath) {
+			return nil
+		}
+		if err := prependPath(ctx, cmd.Value); err != nil {
 			return err
 		}
 	}
@@ -116,6 +231,174 @@ func (p *CommandProcessor) ProcessOutput(ctx *context.Context, output string) er
 	return nil
 }
 
+// Err returns the error failing the step caused by the processed commands, if any.
+func (p *CommandProcessor) Err() error {
+	return p.err
+}
+
+// EndGroups closes the groups left open by the step, so the output of the next steps is not nested in them.
+func (p *CommandProcessor) EndGroups() {
+	for ; p.groups > 0; p.groups-- {
+		log.EndGroup()
+	}
+}
+
+// processLine processes the given line as regular output of the step.
+func (p *CommandProcessor) processLine(ctx *context.Context, output string) error {
+	// secrets and masked values are hidden before the line is logged or matched
+	output = ctx.MaskValues(output)
+
+	log.Info(output)
+
+	if err := ctx.AddStepLog(output); err != nil {
+		return err
+	}
+
+	return p.matchOutput(ctx, output)
+}
+
+// echoEnabled returns true if the commands should be echoed to the output. Same as GitHub, commands are echoed in debug
+// mode unless the step turns it off with the echo command.
+func (p *CommandProcessor) echoEnabled(ctx *context.Context) bool {
+	if p.echo != nil {
+		return *p.echo
+	}
+
+	return ctx.Debug()
+}
+
+// allowUnsecureCommand returns true if the given deprecated command is allowed to run. Same as GitHub, the command is
+// disabled and fails the step unless ACTIONS_ALLOW_UNSECURE_COMMANDS is set to true. Allowed commands are reported with
+// a deprecation warning.
+func (p *CommandProcessor) allowUnsecureCommand(ctx *context.Context, cmd *WorkflowCommand, changelog string) bool {
+	allowed, ok := ctx.Env[envAllowUnsecureCommands]
+	if !ok {
+		allowed = os.Getenv(envAllowUnsecureCommands)
+	}
+
+	if strings.EqualFold(strings.TrimSpace(allowed), "true") {
+		warnDeprecatedCommand(ctx, cmd, changelog)
+		return true
+	}
+
+	msg := fmt.Sprintf(
+		"The `%s` command is disabled. Please upgrade to using Environment Files or opt into unsecure command execution by setting the `%s` environment variable to `true`. For more information see: %s",
+		cmd.Name, envAllowUnsecureCommands, changelog,
+	)
+
+	log.Error(msg)
+
+	if err := ctx.AddStepAnnotation(model.Annotation{Severity: model.AnnotationSeverityError, Message: msg}); err != nil {
+		log.Errorf("failed to add annotation", "error", err)
+	}
+
+	if p.err == nil {
+		p.err = fmt.Errorf("unable to process command '%s', the command is disabled", cmd.Name)
+	}
+
+	return false
+}
+
+// warnDeprecatedCommand reports the use of the given deprecated command as warning.
+func warnDeprecatedCommand(ctx *context.Context, cmd *WorkflowCommand, changelog string) {
+	msg := fmt.Sprintf(
+		"The `%s` command is deprecated and will be disabled soon. Please upgrade to using Environment Files. For more information see: %s",
+		cmd.Name, changelog,
+	)
+
+	log.Warn(msg)
+
+	if err := ctx.AddStepAnnotation(model.Annotation{Severity: model.AnnotationSeverityWarning, Message: msg}); err != nil {
+		log.Errorf("failed to add annotation", "error", err)
+	}
+}
+
+// ProcessStreamOutput processes the output line written to the given stream of the step process. The stream of the
+// line is recorded in the step log.
+func (p *CommandProcessor) ProcessStreamOutput(ctx *context.Context, stream, output string) error {
+	if stepLog := ctx.Execution.StepLog; stepLog != nil {
+		stepLog.SetStream(stream)
+		defer stepLog.SetStream("")
+	}
+
+	return p.ProcessOutput(ctx, output)
+}
+
+// matchOutput matches the output line with the problem matchers registered for the job and adds the annotation to the
+// step if any matcher matches.
+func (p *CommandProcessor) matchOutput(ctx *context.Context, output string) error {
+	if ctx.Execution.Matchers == nil {
+		return nil
+	}
+
+	annotation, ok := ctx.Execution.Matchers.Match(output)
+	if !ok {
+		return nil
+	}
+
+	log.Debugf("Problem matcher matched", "severity", annotation.Severity, "message", annotation.Message, "file", annotation.File, "line", annotation.Line)
+
+	return ctx.AddStepAnnotation(annotation)
+}
+
+// addMatchers registers the problem matchers in the given file to the job. If the path is relative, it's resolved from
+// the workspace.
+func addMatchers(ctx *context.Context, path string) error {
+	if ctx.Execution.Matchers == nil {
+		return errors.New("no job is set")
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(ctx.Github.Workspace, path)
+	}
+
+	var config matcher.Config
+
+	if err := fs.ReadJSONFile(path, &config); err != nil {
+		return fmt.Errorf("failed to read problem matcher file %s: %w", path, err)
+	}
+
+	if err := ctx.Execution.Matchers.Add(config.ProblemMatcher...); err != nil {
+		return err
+	}
+
+	for _, m := range config.ProblemMatcher {
+		log.Debugf("Problem matcher added", "owner", m.Owner, "file", path)
+	}
+
+	return nil
+}
+
+// newAnnotation creates a new annotation with the given severity from the workflow command.
+func newAnnotation(severity model.AnnotationSeverity, cmd *WorkflowCommand) model.Annotation {
+	// ignoring errors since invalid or missing values are not part of the annotation location
+	line, _ := strconv.Atoi(cmd.Parameters["line"])
+	endLine, _ := strconv.Atoi(cmd.Parameters["endLine"])
+	col, _ := strconv.Atoi(cmd.Parameters["col"])
+	endColumn, _ := strconv.Atoi(getEndColumn(cmd))
+
+	return model.Annotation{
+		Severity:  severity,
+		Message:   cmd.Value,
+		Title:     cmd.Parameters["title"],
+		File:      cmd.Parameters["file"],
+		Line:      line,
+		EndLine:   endLine,
+		Col:       col,
+		EndColumn: endColumn,
+	}
+}
+
+// getEndColumn returns the end column parameter of the workflow command. GitHub documents the parameter as endColumn,
+// however endCol is accepted as well for compatibility.
+func getEndColumn(cmd *WorkflowCommand) string {
+	if endColumn, ok := cmd.Parameters["endColumn"]; ok {
+		return endColumn
+	}
+
+	return cmd.Parameters["endCol"]
+}
+
 // parseCommand parses a Workflow command string and returns a Command object. If the string is not a valid Workflow
 // command, it returns false.
 func parseCommand(str string) (bool, *WorkflowCommand) {
@@ -124,13 +407,13 @@ func parseCommand(str string) (bool, *WorkflowCommand) {
 	if matches := commandReColon.FindStringSubmatch(str); matches != nil {
 		// Extract the command keyword
 		command.Name = matches[1]
-		command.Parameters = parseParameters(matches[2], ",")
-		command.Value = matches[3]
+		command.Parameters = parseParameters(matches[2], ",", propertyUnescaper)
+		command.Value = dataUnescaper.Replace(matches[3])
 	} else if matches := commandReHash.FindStringSubmatch(str); matches != nil {
 		// Extract the command keyword
 		command.Name = matches[1]
-		command.Parameters = parseParameters(matches[2], ";")
-		command.Value = matches[3]
+		command.Parameters = parseParameters(matches[2], ";", legacyUnescaper)
+		command.Value = legacyUnescaper.Replace(matches[3])
 	} else {
 		return false, nil
 	}
@@ -138,7 +421,9 @@ func parseCommand(str string) (bool, *WorkflowCommand) {
 	return true, &command
 }
 
-func parseParameters(parametersStr string, separator string) map[string]string {
+// parseParameters parses the parameters of a workflow command separated with the given separator. Values are decoded
+// with the given unescaper.
+func parseParameters(parametersStr string, separator string, unescaper *strings.Replacer) map[string]string {
 	parameters := make(map[string]string)
 
 	if parametersStr == "" {
@@ -148,7 +433,7 @@ func parseParameters(parametersStr string, separator string) map[string]string {
 	for _, parameter := range strings.Split(parametersStr, separator) {
 		parts := strings.SplitN(parameter, "=", 2)
 		if len(parts) == 2 {
-			parameters[parts[0]] = parts[1]
+			parameters[strings.TrimSpace(parts[0])] = unescaper.Replace(parts[1])
 		}
 	}
 
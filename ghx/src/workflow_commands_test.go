@@ -1,8 +1,13 @@
 package main
 
 import (
+	"os"
 	"reflect"
 	"testing"
+
+	"github.com/aweris/gale/common/model"
+
+	"ghx/context"
 )
 
 func TestParseCommand(t *testing.T) {
@@ -67,6 +72,32 @@ func TestParseCommand(t *testing.T) {
 			},
 			expectedMatch: true,
 		},
+		{
+			name:  "Command with escaped property and value",
+			input: "::error file=src%2Capp.js,title=Lint%3A error%0Aline::100%25 failed%0D%0Anext line %250A",
+			expectedResult: &WorkflowCommand{
+				Name: "error",
+				Parameters: map[string]string{
+					"file":  "src,app.js",
+					"title": "Lint: error\nline",
+				},
+				Value: "100% failed\r\nnext line %0A",
+			},
+			expectedMatch: true,
+		},
+		{
+			name:  "Hash command with escaped property and value",
+			input: "##[error file=a%3Bb.js;title=[x%5D]failed%0A",
+			expectedResult: &WorkflowCommand{
+				Name: "error",
+				Parameters: map[string]string{
+					"file":  "a;b.js",
+					"title": "[x]",
+				},
+				Value: "failed\n",
+			},
+			expectedMatch: true,
+		},
 		{
 			name:           "Invalid command format",
 			input:          "This is not a valid command",
@@ -89,3 +120,252 @@ func TestParseCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestNewAnnotation(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		severity model.AnnotationSeverity
+		expected model.Annotation
+	}{
+		{
+			name:     "Annotation with location",
+			input:    "::error file=app.js,line=1,col=5,endLine=2,endColumn=7,title=Lint::Missing semicolon",
+			severity: model.AnnotationSeverityError,
+			expected: model.Annotation{
+				Severity:  model.AnnotationSeverityError,
+				Message:   "Missing semicolon",
+				Title:     "Lint",
+				File:      "app.js",
+				Line:      1,
+				EndLine:   2,
+				Col:       5,
+				EndColumn: 7,
+			},
+		},
+		{
+			name:     "Annotation with endCol parameter",
+			input:    "::warning file=app.js,line=1,endCol=7::Unused variable",
+			severity: model.AnnotationSeverityWarning,
+			expected: model.Annotation{
+				Severity:  model.AnnotationSeverityWarning,
+				Message:   "Unused variable",
+				File:      "app.js",
+				Line:      1,
+				EndColumn: 7,
+			},
+		},
+		{
+			name:     "Annotation without location",
+			input:    "::notice::Deployment skipped",
+			severity: model.AnnotationSeverityNotice,
+			expected: model.Annotation{
+				Severity: model.AnnotationSeverityNotice,
+				Message:  "Deployment skipped",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cmd := parseCommand(tt.input)
+
+			annotation := newAnnotation(tt.severity, cmd)
+
+			if !reflect.DeepEqual(annotation, tt.expected) {
+				t.Errorf("Expected annotation %+v, but got %+v", tt.expected, annotation)
+			}
+		})
+	}
+}
+
+func TestCommandProcessor_UnsecureCommands(t *testing.T) {
+	tests := []struct {
+		name        string
+		allow       string
+		expectedErr bool
+		expectedEnv string
+		severity    model.AnnotationSeverity
+	}{
+		{name: "disabled by default", expectedErr: true, severity: model.AnnotationSeverityError},
+		{name: "disabled explicitly", allow: "false", expectedErr: true, severity: model.AnnotationSeverityError},
+		{name: "allowed", allow: "TRUE", expectedEnv: "bar", severity: model.AnnotationSeverityWarning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PATH", "/usr/bin")
+
+			ctx := &context.Context{
+				Env: map[string]string{envAllowUnsecureCommands: tt.allow},
+				Execution: context.ExecutionContext{
+					StepRun: &model.StepRun{Outputs: map[string]string{}, State: map[string]string{}},
+				},
+			}
+
+			p := NewCommandProcessor()
+
+			for _, output := range []string{"::set-env name=FOO::bar", "::add-path::/opt/bin", "::set-output name=foo::bar"} {
+				if err := p.ProcessOutput(ctx, output); err != nil {
+					t.Fatalf("Expected no error, but got %v", err)
+				}
+			}
+
+			if (p.Err() != nil) != tt.expectedErr {
+				t.Errorf("Expected error %t, but got %v", tt.expectedErr, p.Err())
+			}
+
+			if env := ctx.Execution.Env.GithubEnv["FOO"]; env != tt.expectedEnv {
+				t.Errorf("Expected FOO to be '%s', but got '%s'", tt.expectedEnv, env)
+			}
+
+			if output := ctx.Execution.StepRun.Outputs["foo"]; output != tt.expectedEnv {
+				t.Errorf("Expected output foo to be '%s', but got '%s'", tt.expectedEnv, output)
+			}
+
+			if len(ctx.Execution.StepRun.Annotations) != 3 {
+				t.Fatalf("Expected 3 annotations, but got %d", len(ctx.Execution.StepRun.Annotations))
+			}
+
+			for _, annotation := range ctx.Execution.StepRun.Annotations {
+				if annotation.Severity != tt.severity {
+					t.Errorf("Expected %s annotation, but got %s: %s", tt.severity, annotation.Severity, annotation.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestCommandProcessor_AddPathPrepends(t *testing.T) {
+	t.Setenv(envAllowUnsecureCommands, "true")
+	t.Setenv("PATH", "/usr/bin")
+
+	ctx := &context.Context{Execution: context.ExecutionContext{StepRun: &model.StepRun{}}}
+
+	p := NewCommandProcessor()
+
+	for _, output := range []string{"::add-path::/opt/first", "::add-path::/opt/second"} {
+		if err := p.ProcessOutput(ctx, output); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+	}
+
+	if path := ctx.StepPath(os.Getenv("PATH")); path != "/opt/second:/opt/first:/usr/bin" {
+		t.Errorf("Expected last added path to take precedence, but got %s", path)
+	}
+
+	// added paths are kept in the job, the PATH of the runner is not changed
+	if path := os.Getenv("PATH"); path != "/usr/bin" {
+		t.Errorf("Expected PATH of the runner to stay /usr/bin, but got %s", path)
+	}
+
+	if !reflect.DeepEqual(ctx.Execution.StepRun.Path, []string{"/opt/first", "/opt/second"}) {
+		t.Errorf("Expected step paths in order, but got %v", ctx.Execution.StepRun.Path)
+	}
+}
+
+func TestCommandProcessor_StopCommandsAndEcho(t *testing.T) {
+	ctx := &context.Context{
+		Execution: context.ExecutionContext{
+			StepRun: &model.StepRun{Outputs: map[string]string{}, State: map[string]string{}},
+		},
+	}
+
+	p := NewCommandProcessor()
+
+	lines := []string{
+		"::stop-commands::pause-token",
+		"::warning::not a command",
+		"::pause-token::",
+		"::unknown-command::printed as output",
+		"::echo::on",
+		"::notice::echoed",
+		"::add-mask::secret",
+		"::echo::off",
+		"::notice::not echoed",
+	}
+
+	for _, line := range lines {
+		if err := p.ProcessOutput(ctx, line); err != nil {
+			t.Fatalf("Expected no error, but got %v for line %s", err, line)
+		}
+	}
+
+	expectedLog := []string{
+		"::warning::not a command",
+		"::unknown-command::printed as output",
+		"::notice::echoed",
+		"::echo::off",
+	}
+
+	if !reflect.DeepEqual(ctx.Execution.StepRun.Log, expectedLog) {
+		t.Errorf("Expected log %q, but got %q", expectedLog, ctx.Execution.StepRun.Log)
+	}
+
+	if len(ctx.Execution.StepRun.Annotations) != 2 {
+		t.Errorf("Expected only the annotations of the processed commands, but got %+v", ctx.Execution.StepRun.Annotations)
+	}
+
+	for _, token := range []string{"", "group"} {
+		if err := NewCommandProcessor().ProcessOutput(ctx, "::stop-commands::"+token); err == nil {
+			t.Errorf("Expected error for stop-commands token '%s', but got nil", token)
+		}
+	}
+}
+
+func TestCommandProcessor_AddMask(t *testing.T) {
+	ctx := &context.Context{
+		Secrets:   context.SecretsContext{Data: map[string]string{"TOKEN": "token"}},
+		Execution: context.ExecutionContext{StepRun: &model.StepRun{}},
+	}
+
+	p := NewCommandProcessor()
+
+	lines := []string{
+		"::add-mask::hunter2",
+		"password is hunter2",
+		"::warning title=hunter2::leaked hunter2",
+		"token and tokenizer",
+	}
+
+	for _, line := range lines {
+		if err := p.ProcessOutput(ctx, line); err != nil {
+			t.Fatalf("Expected no error, but got %v for line %s", err, line)
+		}
+	}
+
+	expectedLog := []string{"password is ***", "*** and ***izer"}
+
+	if !reflect.DeepEqual(ctx.Execution.StepRun.Log, expectedLog) {
+		t.Errorf("Expected log %q, but got %q", expectedLog, ctx.Execution.StepRun.Log)
+	}
+
+	expected := model.Annotation{Severity: model.AnnotationSeverityWarning, Title: "***", Message: "leaked ***"}
+
+	if len(ctx.Execution.StepRun.Annotations) != 1 || ctx.Execution.StepRun.Annotations[0] != expected {
+		t.Errorf("Expected annotation %+v, but got %+v", expected, ctx.Execution.StepRun.Annotations)
+	}
+}
+
+func TestCommandProcessor_Groups(t *testing.T) {
+	ctx := &context.Context{Execution: context.ExecutionContext{StepRun: &model.StepRun{}}}
+
+	p := NewCommandProcessor()
+
+	for _, line := range []string{"::group::outer", "::group::inner", "::endgroup::"} {
+		if err := p.ProcessOutput(ctx, line); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+	}
+
+	if p.groups != 1 {
+		t.Errorf("Expected 1 open group, but got %d", p.groups)
+	}
+
+	p.EndGroups()
+
+	// end of a group not opened by the step is ignored
+	if err := p.ProcessOutput(ctx, "::endgroup::"); err != nil || p.groups != 0 {
+		t.Errorf("Expected unmatched endgroup to be ignored, but got %d open groups and error %v", p.groups, err)
+	}
+}
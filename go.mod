@@ -7,6 +7,7 @@ require (
 	github.com/Khan/genqlient v0.6.0
 	github.com/aweris/gale/common v0.0.0-00010101000000-000000000000
 	github.com/google/uuid v1.4.0
+	github.com/yuin/goldmark v1.6.0
 	golang.org/x/exp v0.0.0-20231006140011-7918f672742d
 	golang.org/x/sync v0.4.0
 	gopkg.in/yaml.v3 v3.0.1
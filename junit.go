@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aweris/gale/common/model"
+)
+
+// junitTestSuites is the root element of the JUnit XML report. Each job run, or each matrix leg of a job run, is
+// reported as a test suite and each step of the job as a test case.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr"`
+	Contents string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// Returns the workflow run results as a JUnit XML report. Each job, or each matrix leg of a job, is reported as a test
+// suite and each step of the job as a test case.
+func (wr *WorkflowRun) Junit(ctx context.Context) (*File, error) {
+	if len(wr.JobRuns) == 0 {
+		return nil, fmt.Errorf("no job runs found")
+	}
+
+	report := junitTestSuites{Name: wr.Workflow.Name}
+
+	var total time.Duration
+
+	for _, jr := range wr.JobRuns {
+		jrrs, err := jr.reports(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, jrr := range jrrs {
+			suite, duration := newJunitTestSuite(jrr)
+
+			report.Tests += suite.Tests
+			report.Failures += suite.Failures
+			report.Skipped += suite.Skipped
+			report.Suites = append(report.Suites, suite)
+
+			total += duration
+		}
+	}
+
+	report.Time = junitTime(total)
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal junit report: %w", err)
+	}
+
+	return dag.Directory().WithNewFile("junit.xml", xml.Header+string(data)).File("junit.xml"), nil
+}
+
+// reports returns the original job run reports of the job run. If the job has a matrix, it returns the report of each
+// matrix leg in execution order.
+func (jr *JobRun) reports(ctx context.Context) ([]model.JobRunReport, error) {
+	legs, err := jr.legs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]model.JobRunReport, 0, len(legs))
+
+	for _, leg := range legs {
+		reports = append(reports, leg.Report)
+	}
+
+	return reports, nil
+}
+
+// newJunitTestSuite creates a new test suite from the given job run report and returns it with the total duration of
+// the test cases.
+func newJunitTestSuite(report model.JobRunReport) (junitTestSuite, time.Duration) {
+	suite := junitTestSuite{Name: jobRunName(report)}
+
+	var total time.Duration
+
+	for _, step := range report.Steps {
+		// steps without conclusion are not applicable to the job, e.g. pre or post stage of an action without them
+		if step.Conclusion == "" {
+			continue
+		}
+
+		// ignoring error since it's not important for the report, zero duration is fine
+		duration, _ := time.ParseDuration(step.Duration)
+
+		total += duration
+
+		tc := junitTestCase{
+			Name:      junitTestCaseName(step),
+			Classname: suite.Name,
+			Time:      junitTime(duration),
+		}
+
+		switch step.Conclusion {
+		case model.ConclusionFailure:
+			message := "step failed"
+
+			for _, annotation := range step.Annotations {
+				if annotation.Severity == model.AnnotationSeverityError {
+					message = annotation.Message
+					break
+				}
+			}
+
+			tc.Failure = &junitFailure{Message: message, Type: string(step.Stage), Contents: junitStepOutput(step)}
+
+			suite.Failures++
+		case model.ConclusionSkipped:
+			tc.Skipped = &junitSkipped{Message: "step skipped"}
+
+			suite.Skipped++
+		default:
+			// step failed but continue-on-error is enabled, keep the output to not lose the failure details
+			if step.Outcome == model.ConclusionFailure {
+				tc.SystemOut = junitStepOutput(step)
+			}
+		}
+
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	suite.Time = junitTime(total)
+
+	return suite, total
+}
+
+// junitTestCaseName returns the test case name for the given step. Steps in pre and post stages are prefixed with the
+// stage name like in GitHub Actions UI.
+func junitTestCaseName(step model.StepRunSummary) string {
+	name := step.Name
+	if name == "" {
+		name = step.ID
+	}
+
+	switch step.Stage {
+	case model.StepStagePre:
+		return "Pre " + name
+	case model.StepStagePost:
+		return "Post " + name
+	default:
+		return name
+	}
+}
+
+// junitStepOutput returns the error annotations and the log excerpt of the step as a single string.
+func junitStepOutput(step model.StepRunSummary) string {
+	var sb strings.Builder
+
+	for _, annotation := range step.Annotations {
+		if annotation.Severity != model.AnnotationSeverityError {
+			continue
+		}
+
+		if annotation.File != "" {
+			sb.WriteString(fmt.Sprintf("%s:%d:%d: ", annotation.File, annotation.Line, annotation.Col))
+		}
+
+		sb.WriteString(annotation.Message)
+		sb.WriteString("\n")
+	}
+
+	if len(step.Log) > 0 {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+
+		sb.WriteString(strings.Join(step.Log, "\n"))
+	}
+
+	return sb.String()
+}
+
+// junitTime returns the given duration in seconds as JUnit expects.
+func junitTime(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
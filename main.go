@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/aweris/gale/common/log"
@@ -102,6 +103,9 @@ func (g *Gale) Run(
 	// File with the complete webhook event payload.
 	// +optional=true
 	eventFile *File,
+	// Username of the user that triggered the workflow. Defaults to the sender of the event or the repository owner.
+	// +optional=true
+	actor string,
 	// Container to use for the runner(default: ghcr.io/catthehacker/ubuntu:act-latest).
 	// +optional=true
 	container *Container,
@@ -124,6 +128,24 @@ func (g *Gale) Run(
 	// GitHub token to use for authentication.
 	// +optional=true
 	token *Secret,
+	// Pauses the job at the failing step to be able to open an interactive shell with the exact step environment.
+	// +optional=true
+	// +default=false
+	interactiveOnFailure bool,
+	// Directory of the vendored actions. If provided, actions are resolved only from this directory without network access.
+	// +optional=true
+	actionsDir *Directory,
+	// File with the action override rules to redirect, stub or skip the actions used by the workflow.
+	// +optional=true
+	actionOverrides *File,
+	// Runs actions/checkout, actions/cache, actions/upload-artifact and actions/download-artifact with their native implementations instead of running the actions.
+	// +optional=true
+	// +default=false
+	nativeActions bool,
+	// Fails the step when an expression can't be evaluated or refers to an undefined property instead of evaluating it to an empty string.
+	// +optional=true
+	// +default=true
+	strictExpressions bool,
 ) (*WorkflowRun, error) {
 	if eventFile == nil {
 		eventFile = dag.Directory().WithNewFile("event.json", "{}").File("event.json")
@@ -155,15 +177,21 @@ func (g *Gale) Run(
 			Job:          job,
 		},
 		&RunnerOpts{
-			Ctr:             container,
-			Debug:           runnerDebug,
-			UseNativeDocker: useNativeDocker,
-			DockerHost:      dockerHost,
-			UseDind:         useDind,
+			Ctr:                  container,
+			Debug:                runnerDebug,
+			UseNativeDocker:      useNativeDocker,
+			DockerHost:           dockerHost,
+			UseDind:              useDind,
+			InteractiveOnFailure: interactiveOnFailure,
+			ActionsDir:           actionsDir,
+			ActionOverrides:      actionOverrides,
+			NativeActions:        nativeActions,
+			StrictExpressions:    strictExpressions,
 		},
 		&EventOpts{
-			Name: event,
-			File: eventFile,
+			Name:  event,
+			File:  eventFile,
+			Actor: actor,
 		},
 		&SecretOpts{
 			Token: token,
@@ -199,3 +227,72 @@ func (g *Gale) Action(
 
 	return actions.Action(stepID, uses, env, with)
 }
+
+// Actions returns the actions of the repository to manage the actions used by the workflows.
+func (g *Gale) Actions() *Actions {
+	return &Actions{
+		Repo:      g.Repo,
+		Workflows: g.Workflows,
+	}
+}
+
+// Eval evaluates the given expression and returns the result with its type and the evaluated values of the expression
+// nodes. Contexts are loaded from the data of a recorded workflow run and the given contexts file.
+func (g *Gale) Eval(
+	// Context of the operation.
+	ctx context.Context,
+	// Expression to evaluate. Expressions in ${{ }} are evaluated as string, otherwise as a single expression like if conditions.
+	expression string,
+	// Data directory of a recorded workflow run, e.g. the directory exported by the data command of the run.
+	// +optional=true
+	data *Directory,
+	// Job of the recorded workflow run to load the contexts from. Only required if the run has more than one job.
+	// +optional=true
+	job string,
+	// JSON file with the contexts to evaluate the expression with, e.g. {"steps": {...}}. Overrides the recorded values.
+	// +optional=true
+	contexts *File,
+	// File with the event payload. Overrides the recorded event.
+	// +optional=true
+	eventFile *File,
+	// Environment variables for the expression. Format: name=value.
+	// +optional=true
+	env []string,
+	// Fails the evaluation if an expression in ${{ }} refers to an undefined property.
+	// +optional=true
+	// +default=true
+	strict bool,
+) (string, error) {
+	var (
+		dir       = "/home/runner/_temp/gale/eval"
+		container = dag.Container().From("ghcr.io/catthehacker/ubuntu:act-latest").With(dag.Ghx().Binary)
+		args      = []string{"ghx", "eval", fmt.Sprintf("--strict=%t", strict)}
+	)
+
+	if data != nil {
+		container = container.WithMountedDirectory(filepath.Join(dir, "data"), data)
+		args = append(args, "--data", filepath.Join(dir, "data"))
+	}
+
+	if job != "" {
+		args = append(args, "--job", job)
+	}
+
+	if contexts != nil {
+		container = container.WithMountedFile(filepath.Join(dir, "contexts.json"), contexts)
+		args = append(args, "--context", filepath.Join(dir, "contexts.json"))
+	}
+
+	if eventFile != nil {
+		container = container.WithMountedFile(filepath.Join(dir, "event.json"), eventFile)
+		args = append(args, "--event", filepath.Join(dir, "event.json"))
+	}
+
+	for _, e := range env {
+		args = append(args, "--env", e)
+	}
+
+	args = append(args, "--", expression)
+
+	return container.WithExec(args, ContainerWithExecOpts{SkipEntrypoint: true}).Stdout(ctx)
+}
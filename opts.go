@@ -20,6 +20,9 @@ type EventOpts struct {
 
 	// File containing the event data in JSON format.
 	File *File
+
+	// Username of the user that triggered the workflow. If empty, ghx uses the sender of the event.
+	Actor string
 }
 
 type RunnerOpts struct {
@@ -38,6 +41,21 @@ type RunnerOpts struct {
 	// Enables docker-in-dagger support to be able to run docker commands isolated from the host.
 	// Enabling DinD may lead to longer execution times.
 	UseDind bool
+
+	// Pauses the job at the failing step to be able to open an interactive shell with the exact step environment.
+	InteractiveOnFailure bool
+
+	// Directory of the vendored actions. If provided, actions are resolved only from this directory.
+	ActionsDir *Directory
+
+	// File with the action override rules to redirect, stub or skip the actions used by the workflow.
+	ActionOverrides *File
+
+	// Runs the well-known actions with their native implementations instead of running the actions.
+	NativeActions bool
+
+	// Fails the step when an expression can't be evaluated instead of evaluating it to an empty string.
+	StrictExpressions bool
 }
 
 type SecretOpts struct {
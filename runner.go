@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -36,7 +39,7 @@ type RunnerContainer struct {
 	Ctr   *Container
 }
 
-func (r *Runner) Container(runID string) (*RunnerContainer, error) {
+func (r *Runner) Container(ctx context.Context, runID string) (*RunnerContainer, error) {
 	var (
 		repo     = r.Repo
 		workflow = r.Workflow
@@ -72,6 +75,8 @@ func (r *Runner) Container(runID string) (*RunnerContainer, error) {
 	var (
 		metadata  = "/home/runner/_temp/gale/metadata"
 		actions   = "/home/runner/_temp/gale/actions"
+		tools     = "/home/runner/_temp/gale/tools"
+		overrides = "/home/runner/_temp/gale/action-overrides.yaml"
 		cacheOpts = ContainerWithMountedCacheOpts{Sharing: Shared}
 	)
 
@@ -79,7 +84,28 @@ func (r *Runner) Container(runID string) (*RunnerContainer, error) {
 	ctr = ctr.WithMountedCache(metadata, dag.CacheVolume("gale-metadata"), cacheOpts)
 
 	ctr = ctr.WithEnvVariable("GHX_ACTIONS_DIR", actions)
-	ctr = ctr.WithMountedCache(actions, dag.CacheVolume("gale-actions"), cacheOpts)
+
+	// vendored actions replace the actions cache and ghx resolves the actions only from them
+	if r.RunnerOpts.ActionsDir != nil {
+		ctr = ctr.WithMountedDirectory(actions, r.RunnerOpts.ActionsDir)
+		ctr = ctr.WithEnvVariable("GHX_ACTIONS_OFFLINE", "true")
+	} else {
+		ctr = ctr.WithMountedCache(actions, dag.CacheVolume("gale-actions"), cacheOpts)
+	}
+
+	if r.RunnerOpts.ActionOverrides != nil {
+		ctr = ctr.WithMountedFile(overrides, r.RunnerOpts.ActionOverrides)
+		ctr = ctr.WithEnvVariable("GHX_ACTION_OVERRIDES", overrides)
+	}
+
+	if r.RunnerOpts.NativeActions {
+		ctr = ctr.WithEnvVariable("GHX_NATIVE_ACTIONS", "true")
+	}
+
+	ctr = ctr.WithEnvVariable("GHX_STRICT_EXPRESSIONS", strconv.FormatBool(r.RunnerOpts.StrictExpressions))
+
+	ctr = ctr.WithEnvVariable("GHX_TOOLS_DIR", tools)
+	ctr = ctr.WithMountedCache(tools, dag.CacheVolume("gale-tools"), cacheOpts)
 
 	// Configure repository
 	workdir := fmt.Sprintf("/home/runner/work/%s/%s", repo.Name, repo.Name)
@@ -111,6 +137,11 @@ func (r *Runner) Container(runID string) (*RunnerContainer, error) {
 		ctr = ctr.WithEnvVariable("RUNNER_DEBUG", "1")
 	}
 
+	// Configure interactive mode if enabled
+	if r.RunnerOpts.InteractiveOnFailure {
+		ctr = ctr.WithEnvVariable("GHX_INTERACTIVE_ON_FAILURE", "true")
+	}
+
 	// Configure event
 	eventPath := filepath.Join(home, "run", "event.json")
 
@@ -118,6 +149,32 @@ func (r *Runner) Container(runID string) (*RunnerContainer, error) {
 	ctr = ctr.WithEnvVariable("GITHUB_EVENT_PATH", eventPath)
 	ctr = ctr.WithMountedFile(eventPath, r.EventOpts.File)
 
+	// Configure the github context only available in the event payload
+	event, err := r.eventInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.Repository.ID != "" {
+		ctr = ctr.WithEnvVariable("GITHUB_REPOSITORY_ID", event.Repository.ID.String())
+	}
+
+	if event.Repository.Owner.ID != "" {
+		ctr = ctr.WithEnvVariable("GITHUB_REPOSITORY_OWNER_ID", event.Repository.Owner.ID.String())
+	}
+
+	// base and head refs are only available for the pull request events
+	if strings.HasPrefix(r.EventOpts.Name, "pull_request") {
+		ctr = ctr.WithEnvVariable("GITHUB_BASE_REF", event.PullRequest.Base.Ref)
+		ctr = ctr.WithEnvVariable("GITHUB_HEAD_REF", event.PullRequest.Head.Ref)
+	}
+
+	// Configure actor, otherwise ghx uses the sender of the event or the repository owner
+	if r.EventOpts.Actor != "" {
+		ctr = ctr.WithEnvVariable("GITHUB_ACTOR", r.EventOpts.Actor)
+		ctr = ctr.WithEnvVariable("GITHUB_TRIGGERING_ACTOR", r.EventOpts.Actor)
+	}
+
 	// Configure workflow
 	ctr = ctr.WithEnvVariable("GITHUB_RUN_ID", runID)
 	ctr = ctr.WithEnvVariable("GITHUB_RUN_NUMBER", "1")
@@ -188,13 +245,55 @@ func (rc *RunnerContainer) RunJob(ctx context.Context, job *Job, conclusion stri
 		return nil, err
 	}
 
+	// ghx writes pause.json to the job directory when the job is paused at a failing step
+	entries, err := ctr.Directory(current).Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	jr = &JobRun{
 		Job:     job,
 		Ctr:     ctr,
 		Data:    ctr.Directory(current),
 		Report:  report,
 		LogFile: ctr.Directory(current).File("job_run.log"),
+		Paused:  slices.Contains(entries, "pause.json"),
 	}
 
 	return jr, nil
 }
+
+// eventPayload is the part of the event payload used to configure the github context of the runner.
+type eventPayload struct {
+	Repository struct {
+		ID    json.Number `json:"id"`
+		Owner struct {
+			ID json.Number `json:"id"`
+		} `json:"owner"`
+	} `json:"repository"`
+
+	PullRequest struct {
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+		Head struct {
+			Ref string `json:"ref"`
+		} `json:"head"`
+	} `json:"pull_request"`
+}
+
+// eventInfo returns the information of the event payload used to configure the github context of the runner.
+func (r *Runner) eventInfo(ctx context.Context) (*eventPayload, error) {
+	contents, err := r.EventOpts.File.Contents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var info eventPayload
+
+	if err := json.Unmarshal([]byte(contents), &info); err != nil {
+		return nil, fmt.Errorf("failed to parse event file: %w", err)
+	}
+
+	return &info, nil
+}
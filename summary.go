@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+
+	"github.com/aweris/gale/common/log"
+)
+
+const (
+	// summaryStepSizeLimit is the maximum size of a step summary displayed by GitHub.
+	//
+	// See: https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#step-isolation-and-limits
+	summaryStepSizeLimit = 1024 * 1024
+
+	// summaryCountLimit is the maximum number of step summaries displayed by GitHub for a job.
+	//
+	// See: https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#step-isolation-and-limits
+	summaryCountLimit = 20
+)
+
+// Returns the job summaries of the workflow run, added by the steps using GITHUB_STEP_SUMMARY, in execution order.
+func (wr *WorkflowRun) Summary(
+	// Context to use for the operation
+	ctx context.Context,
+	// Format of the summary. Possible values are markdown and html. HTML format follows the GitHub limits for the
+	// summaries.
+	// +optional=true
+	// +default=markdown
+	format string,
+) (*File, error) {
+	if format != "markdown" && format != "html" {
+		return nil, fmt.Errorf("unsupported summary format %s, possible values are markdown and html", format)
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("# %s\n\n", wr.Workflow.Name))
+
+	for _, jr := range wr.JobRuns {
+		legs, err := jr.legs(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// each matrix leg has its own steps, so the summaries are rendered per leg like GitHub
+		for _, leg := range legs {
+			summaries, err := stepSummaries(ctx, leg.Data)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(summaries) == 0 {
+				continue
+			}
+
+			name := jobRunName(leg.Report)
+
+			if format == "html" {
+				summaries = limitSummaries(name, summaries)
+			}
+
+			sb.WriteString(fmt.Sprintf("## %s\n\n", name))
+
+			for _, summary := range summaries {
+				sb.WriteString(summary)
+
+				if !strings.HasSuffix(summary, "\n") {
+					sb.WriteString("\n")
+				}
+
+				sb.WriteString("\n")
+			}
+		}
+	}
+
+	if format == "markdown" {
+		return dag.Directory().WithNewFile("summary.md", sb.String()).File("summary.md"), nil
+	}
+
+	page, err := renderSummaryHTML(wr.Workflow.Name, sb.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return dag.Directory().WithNewFile("summary.html", page).File("summary.html"), nil
+}
+
+// stepSummaries returns the step summaries in the given job run data in execution order.
+func stepSummaries(ctx context.Context, data *Directory) ([]string, error) {
+	entries, err := data.Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(entries, "steps") {
+		return nil, nil
+	}
+
+	steps, err := data.Directory("steps").Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// step directories are named as <index>.<id>, so we need to sort them by index to keep the execution order
+	sort.Slice(steps, func(i, j int) bool {
+		return stepDirIndex(steps[i]) < stepDirIndex(steps[j])
+	})
+
+	summaries := make([]string, 0, len(steps))
+
+	for _, step := range steps {
+		dir := data.Directory(filepath.Join("steps", step))
+
+		files, err := dir.Entries(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if !slices.Contains(files, "summary.md") {
+			continue
+		}
+
+		summary, err := dir.File("summary.md").Contents(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+// stepDirIndex returns the step index from the step directory name in <index>.<id> format.
+func stepDirIndex(name string) int {
+	index, _, _ := strings.Cut(strings.TrimSuffix(name, "/"), ".")
+
+	// ignoring error since the directory name is always in the expected format
+	i, _ := strconv.Atoi(index)
+
+	return i
+}
+
+// limitSummaries applies the GitHub limits to the given step summaries of the job and logs a warning for each summary
+// that is not displayed.
+func limitSummaries(job string, summaries []string) []string {
+	limited := make([]string, 0, len(summaries))
+
+	for _, summary := range summaries {
+		if len(summary) > summaryStepSizeLimit {
+			log.Warnf("Step summary exceeds the size limit, skipping it", "job", job, "size", len(summary), "limit", summaryStepSizeLimit)
+			continue
+		}
+
+		if len(limited) == summaryCountLimit {
+			log.Warnf("Job exceeds the step summary limit, skipping the rest of the summaries", "job", job, "limit", summaryCountLimit)
+			break
+		}
+
+		limited = append(limited, summary)
+	}
+
+	return limited
+}
+
+// renderSummaryHTML renders the given markdown summary as a self-contained HTML page.
+func renderSummaryHTML(title, markdown string) (string, error) {
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		// summaries are generated by the workflow steps and may contain raw HTML like GitHub summaries do
+		goldmark.WithRendererOptions(gmhtml.WithUnsafe()),
+	)
+
+	var body bytes.Buffer
+
+	if err := md.Convert([]byte(markdown), &body); err != nil {
+		return "", fmt.Errorf("failed to render summary: %w", err)
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString(fmt.Sprintf("<title>%s</title>\n", html.EscapeString(title)))
+	sb.WriteString("<style>\n")
+	sb.WriteString("body{font-family:-apple-system,BlinkMacSystemFont,\"Segoe UI\",Helvetica,Arial,sans-serif;max-width:1012px;margin:0 auto;padding:32px;line-height:1.5;color:#1f2328}\n")
+	sb.WriteString("table{border-collapse:collapse}th,td{border:1px solid #d0d7de;padding:6px 13px}\n")
+	sb.WriteString("pre,code{background:#f6f8fa;border-radius:6px}pre{padding:16px;overflow:auto}\n")
+	sb.WriteString("</style>\n</head>\n<body>\n")
+	sb.Write(body.Bytes())
+	sb.WriteString("</body>\n</html>\n")
+
+	return sb.String(), nil
+}
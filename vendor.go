@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/aweris/gale/common/model"
+)
+
+// Vendor downloads all actions referenced by the workflows of the repository, including the actions used by composite
+// actions and reusable workflows, and returns them as a directory. The directory uses the same layout as the actions
+// directory of the runner, so it can be passed to run as actions directory to run workflows without network access.
+func (a *Actions) Vendor(
+	// Context to use for the operation
+	ctx context.Context,
+	// GitHub token to use for downloading private actions.
+	// +optional=true
+	token *Secret,
+) (*Directory, error) {
+	workflows, err := a.Workflows.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &actionsVendor{repo: a.Repo, token: token, dir: dag.Directory(), visited: make(map[string]bool)}
+
+	for _, workflow := range workflows {
+		if err := v.vendorWorkflow(ctx, workflow.Src); err != nil {
+			return nil, fmt.Errorf("failed to vendor actions of workflow %s: %w", workflow.Path, err)
+		}
+	}
+
+	return v.dir, nil
+}
+
+// actionsVendor downloads the actions and keeps track of the visited references to download each reference only once.
+type actionsVendor struct {
+	repo    *RepoInfo
+	token   *Secret
+	dir     *Directory
+	visited map[string]bool
+}
+
+// vendorWorkflow vendors the actions and reusable workflows used by the jobs of the given workflow file.
+func (v *actionsVendor) vendorWorkflow(ctx context.Context, file *File) error {
+	data, err := file.Contents(ctx)
+	if err != nil {
+		return err
+	}
+
+	var wm model.Workflow
+
+	if err := yaml.Unmarshal([]byte(data), &wm); err != nil {
+		return err
+	}
+
+	for _, job := range wm.Jobs {
+		if job.Uses != "" {
+			if err := v.vendor(ctx, job.Uses); err != nil {
+				return err
+			}
+		}
+
+		if err := v.vendorSteps(ctx, job.Steps); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// vendorSteps vendors the actions used by the given steps. Docker images are not vendored.
+func (v *actionsVendor) vendorSteps(ctx context.Context, steps []model.Step) error {
+	for _, step := range steps {
+		if step.Type() != model.StepTypeAction {
+			continue
+		}
+
+		if err := v.vendor(ctx, step.Uses); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// vendor downloads the given action or reusable workflow reference and vendors its references recursively. Local
+// references are resolved from the repository source, same as the runner does.
+func (v *actionsVendor) vendor(ctx context.Context, uses string) error {
+	if v.visited[uses] {
+		return nil
+	}
+
+	v.visited[uses] = true
+
+	var (
+		src  *Directory
+		path string
+	)
+
+	if strings.HasPrefix(uses, "./") {
+		src = v.repo.Source
+		path = strings.TrimPrefix(uses, "./")
+	} else {
+		repo, p, ref, err := parseActionRef(uses)
+		if err != nil {
+			return err
+		}
+
+		src = v.download(repo, ref)
+		path = p
+
+		// keep the whole repository, same as the runner clones the repository of the action
+		v.dir = v.dir.WithDirectory(uses, src)
+	}
+
+	if strings.HasSuffix(path, ".yml") || strings.HasSuffix(path, ".yaml") {
+		return v.vendorWorkflow(ctx, src.File(path))
+	}
+
+	dir := src
+	if path != "" {
+		dir = src.Directory(path)
+	}
+
+	meta, err := loadActionMeta(ctx, dir)
+	if err != nil {
+		return fmt.Errorf("failed to load action %s: %w", uses, err)
+	}
+
+	switch meta.Runs.Using {
+	case model.ActionRunsUsingComposite:
+		return v.vendorSteps(ctx, meta.Runs.Steps)
+	case model.ActionRunsUsingNode12, model.ActionRunsUsingNode16, model.ActionRunsUsingNode20:
+		return v.vendorNode(ctx, strings.TrimPrefix(string(meta.Runs.Using), "node"))
+	default:
+		return nil
+	}
+}
+
+// vendorNode vendors the Node.js runtime of the given major version to the .node directory with the same layout the
+// runner provisions the runtimes, so JavaScript actions can run without pulling the runtime from the registry.
+func (v *actionsVendor) vendorNode(ctx context.Context, major string) error {
+	if v.visited["node"+major] {
+		return nil
+	}
+
+	v.visited["node"+major] = true
+
+	ctr := dag.Container().From(fmt.Sprintf("node:%s-bullseye-slim", major))
+
+	version, err := ctr.WithExec([]string{"node", "--version"}).Stdout(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to vendor node %s runtime: %w", major, err)
+	}
+
+	dir := ".node/" + major
+
+	v.dir = v.dir.
+		WithDirectory(dir, ctr.Directory("/usr/local")).
+		WithNewFile(dir+"/.version", strings.TrimSpace(version))
+
+	return nil
+}
+
+// download returns the source of the given repository at the given ref. The ref could be a branch, tag or commit SHA.
+func (v *actionsVendor) download(repo, ref string) *Directory {
+	container := dag.Container().
+		From("alpine/git:latest").
+		WithEnvVariable("REPO", repo).
+		WithEnvVariable("REF", ref)
+
+	if v.token != nil {
+		container = container.WithSecretVariable("GITHUB_TOKEN", v.token)
+	}
+
+	script := `git clone --quiet "https://${GITHUB_TOKEN:+x-access-token:$GITHUB_TOKEN@}github.com/$REPO.git" /action && git -C /action checkout --quiet "$REF"`
+
+	return container.
+		WithExec([]string{"sh", "-c", script}, ContainerWithExecOpts{SkipEntrypoint: true}).
+		Directory("/action").
+		WithoutDirectory(".git")
+}
+
+// loadActionMeta loads the action metadata from the action.yml or action.yaml file in the given directory.
+func loadActionMeta(ctx context.Context, dir *Directory) (*model.CustomActionMeta, error) {
+	entries, err := dir.Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry != "action.yml" && entry != "action.yaml" {
+			continue
+		}
+
+		data, err := dir.File(entry).Contents(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var meta model.CustomActionMeta
+
+		if err := yaml.Unmarshal([]byte(data), &meta); err != nil {
+			return nil, err
+		}
+
+		return &meta, nil
+	}
+
+	return nil, fmt.Errorf("action.yml or action.yaml not found")
+}
+
+// actionRefRegex matches the action references in the format "{owner}/{repo}/{path}@{ref}".
+var actionRefRegex = regexp.MustCompile(`^([^/]+)/([^/@]+)(?:/([^@]+))?@(.+)$`)
+
+// parseActionRef parses a string in the format "{owner}/{repo}/{path}@{ref}" and returns the parsed components.
+// If {path} is not present in the input string, an empty string is returned for the path component.
+func parseActionRef(input string) (repo string, path string, ref string, err error) {
+	matches := actionRefRegex.FindStringSubmatch(input)
+	if len(matches) == 0 {
+		return "", "", "", fmt.Errorf("invalid action reference: %q", input)
+	}
+
+	return matches[1] + "/" + matches[2], matches[3], matches[4], nil
+}